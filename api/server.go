@@ -0,0 +1,329 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/mikeocool/bbox/core"
+	"github.com/mikeocool/bbox/input"
+	"github.com/mikeocool/bbox/output"
+	"github.com/mikeocool/bbox/proj"
+)
+
+// Maximum size of a request body, large enough for most shapefiles and GeoJSON documents
+const maxRequestBytes = 256 << 20
+
+const defaultFormat = output.FormatSpace
+
+// Server timeouts. Reading and writing are generous, since bodies can be large files that take a
+// while to upload and read.
+const (
+	readHeaderTimeout = 10 * time.Second
+	readTimeout       = 5 * time.Minute
+	writeTimeout      = 5 * time.Minute
+)
+
+// Server serves the bbox input parsing, transforms and formatters over HTTP
+type Server struct {
+	Host string
+	Port int
+}
+
+// ErrorResponse is the JSON body returned for any failed request
+type ErrorResponse struct {
+	Error ErrorDetails `json:"error"`
+}
+
+type ErrorDetails struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Field   string `json:"field,omitempty"`
+}
+
+// apiError is an error with the HTTP status and details to return to the client
+type apiError struct {
+	Status  int
+	Details ErrorDetails
+}
+
+func (e apiError) Error() string {
+	return e.Details.Message
+}
+
+// Handler returns the HTTP handler with all of the API endpoints.
+//
+// Each endpoint accepts a POST with raw text, GeoJSON or shapefile bytes as the body,
+// and formats the result using the format, geojson_type and geojson_indent query parameters.
+//   - /bbox returns the bounding box of the input
+//   - /center returns the center point of the bounding box
+//   - /slice returns the box sliced into the number of columns and rows query parameters
+//   - /buffer returns the box grown or shrunk by the distance query parameter
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/bbox", boxHandler(func(r *http.Request, bbox core.Bbox, settings output.OutputSettings) (string, error) {
+		return output.FormatBbox(bbox, settings)
+	}))
+
+	mux.HandleFunc("/center", boxHandler(func(r *http.Request, bbox core.Bbox, settings output.OutputSettings) (string, error) {
+		return output.FormatPoint(bbox.Center(), settings)
+	}))
+
+	mux.HandleFunc("/slice", boxHandler(func(r *http.Request, bbox core.Bbox, settings output.OutputSettings) (string, error) {
+		columns, err := positiveIntParam(r, "columns")
+		if err != nil {
+			return "", err
+		}
+		rows, err := positiveIntParam(r, "rows")
+		if err != nil {
+			return "", err
+		}
+		return output.FormatCollection(bbox.Slice(columns, rows), settings)
+	}))
+
+	mux.HandleFunc("/buffer", func(w http.ResponseWriter, r *http.Request) {
+		distance := r.URL.Query().Get("distance")
+		if distance == "" {
+			writeError(w, validationError("distance", "distance is required"))
+			return
+		}
+		handleBox(w, r, distance, func(r *http.Request, bbox core.Bbox, settings output.OutputSettings) (string, error) {
+			return output.FormatBbox(bbox, settings)
+		})
+	})
+
+	return mux
+}
+
+// Start starts the API server and blocks until it is interrupted
+func (s *Server) Start() error {
+	server := &http.Server{
+		Addr:              fmt.Sprintf("%s:%d", s.Host, s.Port),
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		log.Printf("Serving API on http://%s\n", server.Addr)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			errCh <- err
+		}
+	}()
+
+	// Set up signal handling for graceful shutdown
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("server error: %w", err)
+	case sig := <-sigCh:
+		log.Printf("Stopping on signal: %s\n", sig)
+	}
+
+	log.Println("Shutting down server...")
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("server shutdown error: %w", err)
+	}
+	log.Println("Server stopped")
+	return nil
+}
+
+type formatFunc func(*http.Request, core.Bbox, output.OutputSettings) (string, error)
+
+func boxHandler(format formatFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleBox(w, r, "", format)
+	}
+}
+
+// handleBox parses the request body into a box, optionally buffers it and writes the formatted result
+func handleBox(w http.ResponseWriter, r *http.Request, buffer string, format formatFunc) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, apiError{
+			Status:  http.StatusMethodNotAllowed,
+			Details: ErrorDetails{Code: "method_not_allowed", Message: "Method not allowed"},
+		})
+		return
+	}
+
+	settings, err := outputSettings(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeError(w, apiError{
+				Status:  http.StatusRequestEntityTooLarge,
+				Details: ErrorDetails{Code: "request_too_large", Message: err.Error()},
+			})
+			return
+		}
+		writeError(w, apiError{
+			Status:  http.StatusBadRequest,
+			Details: ErrorDetails{Code: "invalid_request", Message: "Failed to read request body"},
+		})
+		return
+	}
+
+	// leave the params empty when there's no body, so it's reported as missing input
+	var params input.InputParams
+	if len(bytes.TrimSpace(body)) > 0 {
+		params = input.InputParams{
+			Raw:    body,
+			Buffer: buffer,
+		}
+	}
+	bbox, crs, err := params.GetBboxWithCrs()
+	if err != nil {
+		writeError(w, inputError(err))
+		return
+	}
+
+	// boxes without a known CRS are assumed to be WGS84
+	settings.Srid = proj.EPSGWgs84
+	if crs != nil {
+		settings.Srid = crs.Code
+	}
+
+	formatted, err := format(r, bbox, settings)
+	if err != nil {
+		var apiErr apiError
+		if errors.As(err, &apiErr) {
+			writeError(w, apiErr)
+			return
+		}
+		writeError(w, apiError{
+			Status:  http.StatusBadRequest,
+			Details: ErrorDetails{Code: "format_error", Message: err.Error(), Field: "format"},
+		})
+		return
+	}
+
+	if settings.FormatType == output.FormatGeoJson {
+		w.Header().Set("Content-Type", "application/geo+json")
+	} else {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintln(w, formatted)
+}
+
+// outputSettings builds the output settings from the request's query parameters
+func outputSettings(r *http.Request) (output.OutputSettings, error) {
+	query := r.URL.Query()
+
+	formatStr := query.Get("format")
+	if formatStr == "" {
+		formatStr = defaultFormat
+	}
+	formatType, details := output.ParseFormat(formatStr)
+
+	settings := output.OutputSettings{
+		FormatType:    formatType,
+		FormatDetails: details,
+		GeojsonType:   query.Get("geojson_type"),
+	}
+
+	if indent := query.Get("geojson_indent"); indent != "" {
+		val, err := strconv.Atoi(indent)
+		if err != nil {
+			return output.OutputSettings{}, validationError("geojson_indent", "must be an integer")
+		}
+		settings.GeojsonIndent = val
+	}
+
+	if bbox := query.Get("geojson_bbox"); bbox != "" {
+		val, err := strconv.ParseBool(bbox)
+		if err != nil {
+			return output.OutputSettings{}, validationError("geojson_bbox", "must be true or false")
+		}
+		settings.GeojsonBbox = val
+	}
+
+	return settings, nil
+}
+
+func positiveIntParam(r *http.Request, name string) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return 0, validationError(name, fmt.Sprintf("%s is required", name))
+	}
+	val, err := strconv.Atoi(value)
+	if err != nil || val <= 0 {
+		return 0, validationError(name, fmt.Sprintf("%s must be a positive integer", name))
+	}
+	return val, nil
+}
+
+func validationError(field string, message string) apiError {
+	return apiError{
+		Status:  http.StatusBadRequest,
+		Details: ErrorDetails{Code: "invalid_parameter", Message: message, Field: field},
+	}
+}
+
+// inputError maps errors from building the bounding box to an API error
+func inputError(err error) apiError {
+	var validationErr input.InputValidationError
+	var noUsableBuilderErr input.NoUsableBuilderError
+
+	switch {
+	case errors.As(err, &validationErr):
+		return validationError(validationErr.Field, validationErr.Message)
+	case errors.As(err, &noUsableBuilderErr):
+		return apiError{
+			Status:  http.StatusBadRequest,
+			Details: ErrorDetails{Code: "no_input", Message: "request body did not contain any input"},
+		}
+	case errors.Is(err, input.ErrNoFeaturesFound):
+		return apiError{
+			Status:  http.StatusUnprocessableEntity,
+			Details: ErrorDetails{Code: "no_features", Message: err.Error()},
+		}
+	default:
+		// anything else is a problem parsing the input
+		return apiError{
+			Status:  http.StatusUnprocessableEntity,
+			Details: ErrorDetails{Code: "invalid_input", Message: err.Error()},
+		}
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	var apiErr apiError
+	if !errors.As(err, &apiErr) {
+		apiErr = apiError{
+			Status:  http.StatusInternalServerError,
+			Details: ErrorDetails{Code: "internal_error", Message: err.Error()},
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(apiErr.Status)
+	if err := json.NewEncoder(w).Encode(ErrorResponse{Error: apiErr.Details}); err != nil {
+		log.Printf("Failed to write error response: %v", err)
+	}
+}
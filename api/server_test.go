@@ -0,0 +1,247 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func doRequest(t *testing.T, method, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	server := &Server{}
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandlerSuccess(t *testing.T) {
+	tests := []struct {
+		name        string
+		target      string
+		body        string
+		wantBody    string
+		contentType string
+	}{
+		{
+			name:        "Raw bbox",
+			target:      "/bbox",
+			body:        "1 2 3 4",
+			wantBody:    "1 2 3 4\n",
+			contentType: "text/plain; charset=utf-8",
+		},
+		{
+			name:        "Comma format",
+			target:      "/bbox?format=comma",
+			body:        "1 2 3 4",
+			wantBody:    "1,2,3,4\n",
+			contentType: "text/plain; charset=utf-8",
+		},
+		{
+			name:        "GeoJSON input and coordinates output",
+			target:      "/bbox?format=geojson&geojson_type=coordinates",
+			body:        `{"type":"Feature","geometry":{"type":"Point","coordinates":[5,10]}}`,
+			wantBody:    "[[[5,10],[5,10],[5,10],[5,10],[5,10]]]\n",
+			contentType: "application/geo+json",
+		},
+		{
+			name:        "GeoJSON feature with a bbox member",
+			target:      "/bbox?format=geojson&geojson_type=feature&geojson_bbox=true",
+			body:        "1 2 3 4",
+			wantBody:    `{"type":"Feature","bbox":[1,2,3,4],"geometry":{"type":"Polygon","coordinates":[[[1,2],[3,2],[3,4],[1,4],[1,2]]]}}` + "\n",
+			contentType: "application/geo+json",
+		},
+		{
+			name:        "Template format",
+			target:      "/bbox?format=go-template%3D%7B%7B.Top%7D%7D",
+			body:        "1 2 3 4",
+			wantBody:    "4\n",
+			contentType: "text/plain; charset=utf-8",
+		},
+		{
+			name:        "EWKT with the CRS from the input",
+			target:      "/bbox?format=ewkt",
+			body:        `{"type":"Feature","crs":{"type":"name","properties":{"name":"urn:ogc:def:crs:EPSG::3857"}},"geometry":{"type":"Point","coordinates":[5,10]}}`,
+			wantBody:    "SRID=3857;POLYGON((5 10, 5 10, 5 10, 5 10, 5 10))\n",
+			contentType: "text/plain; charset=utf-8",
+		},
+		{
+			name:        "Center",
+			target:      "/center",
+			body:        "0 0 10 20",
+			wantBody:    "5 10\n",
+			contentType: "text/plain; charset=utf-8",
+		},
+		{
+			name:        "Slice",
+			target:      "/slice?columns=2&rows=1",
+			body:        "0 0 10 20",
+			wantBody:    "0 0 5 20\n5 0 10 20\n",
+			contentType: "text/plain; charset=utf-8",
+		},
+		{
+			name:        "Buffer",
+			target:      "/buffer?distance=1",
+			body:        "0 0 10 20",
+			wantBody:    "-1 -1 11 21\n",
+			contentType: "text/plain; charset=utf-8",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doRequest(t, http.MethodPost, tt.target, tt.body)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+			}
+			if rec.Body.String() != tt.wantBody {
+				t.Errorf("expected body %q, got %q", tt.wantBody, rec.Body.String())
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("expected content type %q, got %q", tt.contentType, got)
+			}
+		})
+	}
+}
+
+func TestHandlerShapefile(t *testing.T) {
+	data, err := os.ReadFile("../integration_tests/data/ne_10m_populated_places_simple/ne_10m_populated_places_simple.shp")
+	if err != nil {
+		t.Skipf("Skipping real shapefile test: %v", err)
+	}
+
+	rec := doRequest(t, http.MethodPost, "/bbox", string(data))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec.Body.String() != "-179.5899789 -89.9999998 179.3833036 82.4833232\n" {
+		t.Errorf("unexpected body %q", rec.Body.String())
+	}
+}
+
+func TestHandlerErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		wantCode   string
+		wantField  string
+	}{
+		{
+			name:       "Wrong method",
+			method:     http.MethodGet,
+			target:     "/bbox",
+			wantStatus: http.StatusMethodNotAllowed,
+			wantCode:   "method_not_allowed",
+		},
+		{
+			name:       "Empty body",
+			method:     http.MethodPost,
+			target:     "/bbox",
+			body:       "  ",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "no_input",
+		},
+		{
+			name:       "Unparseable input",
+			method:     http.MethodPost,
+			target:     "/bbox",
+			body:       "cats",
+			wantStatus: http.StatusUnprocessableEntity,
+			wantCode:   "invalid_input",
+		},
+		{
+			name:       "No features",
+			method:     http.MethodPost,
+			target:     "/bbox",
+			body:       `{"type": "FeatureCollection", "features": []}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantCode:   "no_features",
+		},
+		{
+			name:       "Unknown format",
+			method:     http.MethodPost,
+			target:     "/bbox?format=nope",
+			body:       "1 2 3 4",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "format_error",
+			wantField:  "format",
+		},
+		{
+			name:       "Invalid geojson indent",
+			method:     http.MethodPost,
+			target:     "/bbox?geojson_indent=x",
+			body:       "1 2 3 4",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "invalid_parameter",
+			wantField:  "geojson_indent",
+		},
+		{
+			name:       "Invalid geojson bbox",
+			method:     http.MethodPost,
+			target:     "/bbox?format=geojson&geojson_bbox=maybe",
+			body:       "1 2 3 4",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "invalid_parameter",
+			wantField:  "geojson_bbox",
+		},
+		{
+			name:       "Slice missing rows",
+			method:     http.MethodPost,
+			target:     "/slice?columns=2",
+			body:       "1 2 3 4",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "invalid_parameter",
+			wantField:  "rows",
+		},
+		{
+			name:       "Buffer missing distance",
+			method:     http.MethodPost,
+			target:     "/buffer",
+			body:       "1 2 3 4",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "invalid_parameter",
+			wantField:  "distance",
+		},
+		{
+			name:       "Buffer with invalid unit",
+			method:     http.MethodPost,
+			target:     "/buffer?distance=5yd",
+			body:       "1 2 3 4",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "invalid_parameter",
+			wantField:  "buffer",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doRequest(t, tt.method, tt.target, tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if got := rec.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("expected JSON content type, got %q", got)
+			}
+
+			var resp ErrorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("error response is not valid JSON: %v", err)
+			}
+			if resp.Error.Code != tt.wantCode {
+				t.Errorf("expected code %q, got %q", tt.wantCode, resp.Error.Code)
+			}
+			if resp.Error.Field != tt.wantField {
+				t.Errorf("expected field %q, got %q", tt.wantField, resp.Error.Field)
+			}
+			if resp.Error.Message == "" {
+				t.Errorf("expected an error message")
+			}
+		})
+	}
+}
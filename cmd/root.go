@@ -10,6 +10,7 @@ import (
 	"github.com/mikeocool/bbox/core"
 	"github.com/mikeocool/bbox/input"
 	"github.com/mikeocool/bbox/output"
+	"github.com/mikeocool/bbox/proj"
 
 	"github.com/spf13/cobra"
 )
@@ -72,20 +73,32 @@ func init() {
 	RootCmd.PersistentFlags().Float64P("right", "r", 0, "Right coordinate of bounding box")
 	RootCmd.PersistentFlags().Float64P("top", "t", 0, "Top coordinate of bounding box")
 	RootCmd.PersistentFlags().Float64SliceVar(&inputParams.Center, "center", []float64{}, "Center coordinates [x,y] of bounding box")
-	RootCmd.PersistentFlags().StringVar(&inputParams.Width, "width", "", "Width of bounding box")
-	RootCmd.PersistentFlags().StringVar(&inputParams.Height, "height", "", "Height of bounding box")
+	RootCmd.PersistentFlags().StringVar(&inputParams.Width, "width", "", "Width of bounding box, in degrees or with a unit (mi, ft, km, m)")
+	RootCmd.PersistentFlags().StringVar(&inputParams.Height, "height", "", "Height of bounding box, in degrees or with a unit (mi, ft, km, m)")
 	RootCmd.PersistentFlags().StringVar(&inputParams.Place, "place", "", "Place name for bounding box")
 	RootCmd.PersistentFlags().StringVar(&inputParams.Geocoder, "geocoder", "", "Geocoder service to use (requires --place)")
 	RootCmd.PersistentFlags().StringVar(&inputParams.GeocoderURL, "geocoder-url", "", "Custom geocoder URL with %s placeholder for place name (requires --place)")
 	RootCmd.PersistentFlags().StringSliceVar(&inputParams.GeocoderHeaders, "geocoder-header", []string{}, "HTTP headers for geocoder requests in 'Name: Value' format (can be used multiple times)")
 	RootCmd.PersistentFlags().StringSliceVarP(&inputParams.File, "file", "f", []string{}, "Path to file to load")
+	RootCmd.PersistentFlags().BoolVar(&inputParams.ScanGeometries, "scan-geometries", false, "Compute the bounds of files from every geometry, instead of the extent stored in the file's header")
+	RootCmd.PersistentFlags().BoolVar(&inputParams.GpxTracksOnly, "gpx-tracks-only", false, "Only use track points for the bounds of GPX files")
+	RootCmd.PersistentFlags().BoolVar(&inputParams.GpxWaypointsOnly, "gpx-waypoints-only", false, "Only use waypoints for the bounds of GPX files")
+	RootCmd.PersistentFlags().StringVar(&inputParams.XColumn, "x-column", "", "CSV column with the x or longitude of each point (requires --y-column)")
+	RootCmd.PersistentFlags().StringVar(&inputParams.YColumn, "y-column", "", "CSV column with the y or latitude of each point (requires --x-column)")
+	RootCmd.PersistentFlags().BoolVar(&inputParams.TrustBbox, "trust-bbox", false, "Use the bbox member of GeoJSON documents when they have one, instead of reading every coordinate")
+	RootCmd.PersistentFlags().BoolVarP(&inputParams.Verbose, "verbose", "v", false, "Log details of the input, like the zoom levels of tile archives")
+	RootCmd.PersistentFlags().StringVar(&inputParams.Layer, "layer", "", "Only use this layer for the bounds of files with several, like GeoPackages, or this object of TopoJSON")
 
-	RootCmd.PersistentFlags().Float64Var(&inputParams.Buffer, "buffer", 0, "Grow the box by the specified amount, or shrink it if the value is negative.")
+	RootCmd.PersistentFlags().StringVar(&inputParams.Buffer, "buffer", "", "Grow the box by the specified amount, or shrink it if the value is negative. Accepts degrees or a unit (mi, ft, km, m).")
+
+	RootCmd.PersistentFlags().StringVar(&inputParams.FromCrs, "from-crs", "", "EPSG code of the input coordinates (e.g. EPSG:32633), defaults to EPSG:4326")
+	RootCmd.PersistentFlags().StringVar(&inputParams.ToCrs, "to-crs", "", "EPSG code to transform the bounding box to (e.g. EPSG:3857)")
 
 	RootCmd.PersistentFlags().BoolVar(&drawFlag, "draw", false, "Start the drawing interface to create a bounding box")
 
 	RootCmd.PersistentFlags().StringP("output", "o", "space", "Output format or destination")
 	RootCmd.PersistentFlags().IntVar(&outputSettings.GeojsonIndent, "geojson-indent", 0, "Indentation level for geojson output format")
+	RootCmd.PersistentFlags().BoolVar(&outputSettings.GeojsonBbox, "geojson-bbox", false, "Write a bbox member on geojson features and collections")
 	RootCmd.PersistentFlags().StringVar(&outputSettings.GeojsonType, "geojson-type", "", "Type of geojson object to output - featurecollection, feature, geometry, or coordinates")
 }
 
@@ -99,11 +112,14 @@ func getBboxFromInput(args []string) (core.Bbox, error) {
 			return core.Bbox{}, fmt.Errorf("Error reading from stdin: %w", err)
 		}
 		inputParams.Raw = stdinBytes
+	} else if len(args) > 0 && len(inputParams.File) > 0 {
+		// a shell glob like --file tiles/*.laz expands to more files after the flag
+		inputParams.File = append(inputParams.File, args...)
 	} else if len(args) > 0 {
 		inputParams.Raw = []byte(strings.Join(args, " "))
 	}
 
-	bbox, err := inputParams.GetBbox()
+	bbox, crs, err := inputParams.GetBboxWithCrs()
 	if err != nil {
 		var noUsableBuilderError input.NoUsableBuilderError
 		if errors.As(err, &noUsableBuilderError) {
@@ -111,6 +127,11 @@ func getBboxFromInput(args []string) (core.Bbox, error) {
 			if !drawFlag {
 				return core.Bbox{}, ErrInputCouldNotCreateBbox
 			}
+			// the drawn box is output in --to-crs
+			crs, err = input.ParseCrs("to-crs", inputParams.ToCrs)
+			if err != nil {
+				return core.Bbox{}, fmt.Errorf("Error creating bounding box: %w", err)
+			}
 		} else {
 			return core.Bbox{}, fmt.Errorf("Error creating bounding box: %w", err)
 		}
@@ -118,15 +139,48 @@ func getBboxFromInput(args []string) (core.Bbox, error) {
 
 	if drawFlag {
 		// Start the drawing server
-		bbox, err = core.StartDrawServer(bbox)
+		bbox, err = drawBbox(bbox, crs)
 		if err != nil {
 			return core.Bbox{}, fmt.Errorf("Error running draw server: %w", err)
 		}
 	}
 
+	// boxes without a known CRS are assumed to be WGS84
+	outputSettings.Srid = proj.EPSGWgs84
+	if crs != nil {
+		outputSettings.Srid = crs.Code
+	}
+
 	return bbox, nil
 }
 
+// drawBbox shows the box in the drawing interface, which works in WGS84. Boxes in other
+// CRSs that can be transformed are drawn in WGS84, and the result transformed back.
+func drawBbox(bbox core.Bbox, crs *proj.CRS) (core.Bbox, error) {
+	if crs == nil {
+		return core.StartDrawServer(bbox, nil)
+	}
+	if !crs.IsSupported() || crs.IsWgs84Compatible() {
+		return core.StartDrawServer(bbox, crs)
+	}
+
+	wgs84, _ := proj.Lookup(proj.EPSGWgs84)
+	// an empty box means there's nothing to show
+	if bbox != (core.Bbox{}) {
+		var err error
+		bbox, err = proj.TransformBbox(bbox, crs, wgs84)
+		if err != nil {
+			return core.Bbox{}, err
+		}
+	}
+
+	drawn, err := core.StartDrawServer(bbox, wgs84)
+	if err != nil {
+		return core.Bbox{}, err
+	}
+	return proj.TransformBbox(drawn, wgs84, crs)
+}
+
 func runRoot(cmd *cobra.Command, args []string) error {
 	bbox, err := getBboxFromInput(args)
 	if err != nil {
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"github.com/mikeocool/bbox/api"
+	"github.com/spf13/cobra"
+)
+
+var ServeApiCmd = &cobra.Command{
+	Use:   "serve-api",
+	Short: "Serve an HTTP API for parsing input and creating bounding boxes",
+	Args:  cobra.NoArgs,
+	RunE:  runServeApi,
+}
+
+func runServeApi(cmd *cobra.Command, args []string) error {
+	host, _ := cmd.Flags().GetString("host")
+	port, _ := cmd.Flags().GetInt("port")
+
+	server := &api.Server{
+		Host: host,
+		Port: port,
+	}
+	return server.Start()
+}
+
+func init() {
+	ServeApiCmd.Flags().String("host", "localhost", "Host to listen on")
+	ServeApiCmd.Flags().Int("port", 8080, "Port to listen on")
+	RootCmd.AddCommand(ServeApiCmd)
+}
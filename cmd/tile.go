@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mikeocool/bbox/core"
+	"github.com/mikeocool/bbox/output"
+	"github.com/spf13/cobra"
+)
+
+var TileCmd = &cobra.Command{
+	Use:   "tile",
+	Short: "List the XYZ web map tiles that cover the bounding box.",
+	Args:  cobra.ArbitraryArgs,
+	RunE:  runTile,
+}
+
+func runTile(cmd *cobra.Command, args []string) error {
+	bbox, err := getBboxFromInput(args)
+	if err != nil {
+		if errors.Is(err, ErrInputCouldNotCreateBbox) {
+			cmd.Usage()
+			return err
+		} else {
+			return err
+		}
+	}
+
+	zoomFlag, _ := cmd.Flags().GetString("zoom")
+	minZoom, maxZoom, err := parseZoomRange(zoomFlag)
+	if err != nil {
+		return err
+	}
+	maxTiles, _ := cmd.Flags().GetInt64("max-tiles")
+
+	// compute the ranges first so we can check the count before building the tile list
+	var ranges []core.TileRange
+	var count int64
+	for zoom := minZoom; zoom <= maxZoom; zoom++ {
+		// boxes crossing the antimeridian are covered by tiles on both edges of the map
+		for _, part := range bbox.Split() {
+			tileRange, err := core.TileRangeForBbox(part, zoom)
+			if err != nil {
+				return err
+			}
+			ranges = append(ranges, tileRange)
+			count += tileRange.Count()
+		}
+	}
+	if maxTiles > 0 && count > maxTiles {
+		return fmt.Errorf("box covers %d tiles, which is more than --max-tiles %d", count, maxTiles)
+	}
+
+	var tiles []core.Tile
+	for _, tileRange := range ranges {
+		tiles = append(tiles, tileRange.Tiles()...)
+	}
+
+	formatted, err := output.FormatTiles(tiles, outputSettings)
+	if err != nil {
+		return fmt.Errorf("Error formatting result: %v", err)
+	}
+
+	fmt.Println(formatted)
+	return nil
+}
+
+// parseZoomRange parses a single zoom level ("12") or an inclusive range ("10-14").
+func parseZoomRange(zoom string) (int, int, error) {
+	minStr, maxStr, isRange := strings.Cut(strings.TrimSpace(zoom), "-")
+	if !isRange {
+		maxStr = minStr
+	}
+
+	minZoom, err := strconv.Atoi(strings.TrimSpace(minStr))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid zoom: %s", zoom)
+	}
+	maxZoom, err := strconv.Atoi(strings.TrimSpace(maxStr))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid zoom: %s", zoom)
+	}
+
+	if minZoom < 0 || maxZoom > core.MaxTileZoom {
+		return 0, 0, fmt.Errorf("zoom must be between 0 and %d", core.MaxTileZoom)
+	}
+	if minZoom > maxZoom {
+		return 0, 0, fmt.Errorf("invalid zoom range: %s", zoom)
+	}
+
+	return minZoom, maxZoom, nil
+}
+
+func init() {
+	TileCmd.Flags().String("zoom", "", "Zoom level, or an inclusive range of zoom levels (e.g. 10-14)")
+	TileCmd.Flags().Int64("max-tiles", 1000, "Maximum number of tiles to output, 0 for no limit")
+	TileCmd.MarkFlagRequired("zoom")
+	RootCmd.AddCommand(TileCmd)
+}
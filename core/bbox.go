@@ -10,12 +10,17 @@ type Bbox struct {
 	Bottom float64 `json:"bottom"`
 	Right  float64 `json:"right"`
 	Top    float64 `json:"top"`
+	// HasZ is set when MinZ and MaxZ are the range of heights in the box, rather than it only
+	// being two dimensional
+	HasZ bool    `json:"has_z,omitempty"`
+	MinZ float64 `json:"min_z,omitempty"`
+	MaxZ float64 `json:"max_z,omitempty"`
 }
 
 // Validate checks if the Bbox has valid coordinates.
-// A valid bounding box requires Right > Left and Top > Bottom.
+// A valid bounding box requires Right > Left and Top > Bottom, unless the box crosses the antimeridian.
 func (b Bbox) Validate() error {
-	if b.Right <= b.Left {
+	if b.Width() <= 0 {
 		return fmt.Errorf("invalid bbox: Right (%f) must be greater than Left (%f)", b.Right, b.Left)
 	}
 	if b.Top <= b.Bottom {
@@ -26,17 +31,53 @@ func (b Bbox) Validate() error {
 	return nil
 }
 
+// CrossesAntimeridian returns true if the box crosses the 180° meridian.
+// Following RFC 7946, a box with a Left (west) longitude greater than its Right (east) longitude
+// crosses the antimeridian.
+func (b Bbox) CrossesAntimeridian() bool {
+	return b.Left > b.Right && isLongitude(b.Left) && isLongitude(b.Right)
+}
+
+// Split returns the box as a list of boxes that don't cross the antimeridian.
+// Boxes that cross the antimeridian are split into an eastern part ending at 180°
+// and a western part starting at -180°, other boxes are returned as is.
+func (b Bbox) Split() []Bbox {
+	if !b.CrossesAntimeridian() {
+		return []Bbox{b}
+	}
+	return []Bbox{
+		{Left: b.Left, Bottom: b.Bottom, Right: 180, Top: b.Top, HasZ: b.HasZ, MinZ: b.MinZ, MaxZ: b.MaxZ},
+		{Left: -180, Bottom: b.Bottom, Right: b.Right, Top: b.Top, HasZ: b.HasZ, MinZ: b.MinZ, MaxZ: b.MaxZ},
+	}
+}
+
 // Polygon returns the corner points of the bounding box as a closed polygon.
 // The points are returned in counter-clockwise order starting from the bottom-left corner,
 // with the first point repeated at the end to close the polygon.
+// For boxes that cross the antimeridian the right edge is extended past 180° so the ring is continuous,
+// use Polygons to get the parts on either side of the antimeridian.
 func (b Bbox) Polygon() [][2]float64 {
+	right := b.Right
+	if b.CrossesAntimeridian() {
+		right += 360
+	}
 	return [][2]float64{
-		{b.Left, b.Bottom},  // bottom-left
-		{b.Right, b.Bottom}, // bottom-right
-		{b.Right, b.Top},    // top-right
-		{b.Left, b.Top},     // top-left
-		{b.Left, b.Bottom},  // bottom-left (close the polygon)
+		{b.Left, b.Bottom}, // bottom-left
+		{right, b.Bottom},  // bottom-right
+		{right, b.Top},     // top-right
+		{b.Left, b.Top},    // top-left
+		{b.Left, b.Bottom}, // bottom-left (close the polygon)
+	}
+}
+
+// Polygons returns a closed polygon for each of the parts of the box returned by Split.
+func (b Bbox) Polygons() [][][2]float64 {
+	parts := b.Split()
+	polygons := make([][][2]float64, len(parts))
+	for i, part := range parts {
+		polygons[i] = part.Polygon()
 	}
+	return polygons
 }
 
 // Bounds returns the bounding box as a list of the bounds
@@ -51,6 +92,12 @@ func (b Bbox) Bounds() []float64 {
 
 // Center returns the center point of the bounding box.
 func (b Bbox) Center() [2]float64 {
+	if b.CrossesAntimeridian() {
+		return [2]float64{
+			normalizeLon(b.Left + b.Width()/2),
+			(b.Bottom + b.Top) / 2,
+		}
+	}
 	return [2]float64{
 		(b.Left + b.Right) / 2,
 		(b.Bottom + b.Top) / 2,
@@ -64,6 +111,9 @@ func (b Bbox) IsZero() bool {
 
 // Width returns the width of the bounding box.
 func (b Bbox) Width() float64 {
+	if b.CrossesAntimeridian() {
+		return b.Right - b.Left + 360
+	}
 	return b.Right - b.Left
 }
 
@@ -72,12 +122,59 @@ func (b Bbox) Height() float64 {
 	return b.Top - b.Bottom
 }
 
+// Union returns the smallest box that contains both boxes.
+// If either box crosses the antimeridian, the result is the narrowest box around the globe
+// containing both, which may also cross the antimeridian. The heights of either box are kept.
 func (b Bbox) Union(other Bbox) Bbox {
+	var union Bbox
+	if b.CrossesAntimeridian() || other.CrossesAntimeridian() {
+		union = b.unionAroundGlobe(other)
+	} else {
+		union = Bbox{
+			Left:   math.Min(b.Left, other.Left),
+			Bottom: math.Min(b.Bottom, other.Bottom),
+			Right:  math.Max(b.Right, other.Right),
+			Top:    math.Max(b.Top, other.Top),
+		}
+	}
+	switch {
+	case b.HasZ && other.HasZ:
+		union.HasZ, union.MinZ, union.MaxZ = true, math.Min(b.MinZ, other.MinZ), math.Max(b.MaxZ, other.MaxZ)
+	case b.HasZ:
+		union.HasZ, union.MinZ, union.MaxZ = true, b.MinZ, b.MaxZ
+	case other.HasZ:
+		union.HasZ, union.MinZ, union.MaxZ = true, other.MinZ, other.MaxZ
+	}
+	return union
+}
+
+// unionAroundGlobe unions two boxes treating longitude as circular
+func (b Bbox) unionAroundGlobe(other Bbox) Bbox {
+	bottom := math.Min(b.Bottom, other.Bottom)
+	top := math.Max(b.Top, other.Top)
+
+	// try starting the union at the left edge of each box, and keep the narrowest
+	width := math.Inf(1)
+	left := 0.0
+	for _, pair := range [][2]Bbox{{b, other}, {other, b}} {
+		first, second := pair[0], pair[1]
+		// distance east from the left of the first box to the left of the second
+		offset := math.Mod(second.Left-first.Left+360, 360)
+		w := math.Max(first.Width(), offset+second.Width())
+		if w < width {
+			width = w
+			left = first.Left
+		}
+	}
+
+	if width >= 360 {
+		return Bbox{Left: -180, Bottom: bottom, Right: 180, Top: top}
+	}
 	return Bbox{
-		Left:   math.Min(b.Left, other.Left),
-		Bottom: math.Min(b.Bottom, other.Bottom),
-		Right:  math.Max(b.Right, other.Right),
-		Top:    math.Max(b.Top, other.Top),
+		Left:   normalizeLon(left),
+		Bottom: bottom,
+		Right:  normalizeRightLon(left + width),
+		Top:    top,
 	}
 }
 
@@ -86,24 +183,47 @@ func (b Bbox) Union(other Bbox) Bbox {
 // If the radius is negative and would result in an invalid bounding box (Right <= Left or Top <= Bottom),
 // an error is returned.
 func (b Bbox) Buffer(radius float64) (Bbox, error) {
+	return b.BufferXY(radius, radius)
+}
+
+// BufferXY returns a new Bbox that is expanded (or shrunk if negative) by xRadius
+// on the left and right, and by yRadius on the bottom and top.
+// If a radius is negative and would result in an invalid bounding box, an error is returned.
+func (b Bbox) BufferXY(xRadius, yRadius float64) (Bbox, error) {
 	width := b.Width()
 	height := b.Height()
 
 	// Check if negative radius would create an invalid bbox
-	if radius < 0 {
-		if -radius*2 >= width {
-			return Bbox{}, fmt.Errorf("cannot shrink box with width %f by %f", width, radius)
-		}
-		if -radius*2 >= height {
-			return Bbox{}, fmt.Errorf("cannot shrink box with height %f by %f", height, radius)
+	if xRadius < 0 && -xRadius*2 >= width {
+		return Bbox{}, fmt.Errorf("cannot shrink box with width %f by %f", width, xRadius)
+	}
+	if yRadius < 0 && -yRadius*2 >= height {
+		return Bbox{}, fmt.Errorf("cannot shrink box with height %f by %f", height, yRadius)
+	}
+
+	if b.CrossesAntimeridian() {
+		if width+xRadius*2 >= 360 {
+			return Bbox{Left: -180, Bottom: b.Bottom - yRadius, Right: 180, Top: b.Top + yRadius, HasZ: b.HasZ, MinZ: b.MinZ, MaxZ: b.MaxZ}, nil
 		}
+		return Bbox{
+			Left:   normalizeLon(b.Left - xRadius),
+			Bottom: b.Bottom - yRadius,
+			Right:  normalizeRightLon(b.Right + xRadius),
+			Top:    b.Top + yRadius,
+			HasZ:   b.HasZ,
+			MinZ:   b.MinZ,
+			MaxZ:   b.MaxZ,
+		}, nil
 	}
 
 	return Bbox{
-		Left:   b.Left - radius,
-		Bottom: b.Bottom - radius,
-		Right:  b.Right + radius,
-		Top:    b.Top + radius,
+		Left:   b.Left - xRadius,
+		Bottom: b.Bottom - yRadius,
+		Right:  b.Right + xRadius,
+		Top:    b.Top + yRadius,
+		HasZ:   b.HasZ,
+		MinZ:   b.MinZ,
+		MaxZ:   b.MaxZ,
 	}, nil
 }
 
@@ -115,10 +235,11 @@ func (b Bbox) Slice(columns, rows int) []Bbox {
 		return []Bbox{}
 	}
 
-	totalWidth := b.Right - b.Left
+	totalWidth := b.Width()
 	totalHeight := b.Top - b.Bottom
 	cellWidth := totalWidth / float64(columns)
 	cellHeight := totalHeight / float64(rows)
+	crosses := b.CrossesAntimeridian()
 
 	boxes := make([]Bbox, 0, columns*rows)
 
@@ -129,6 +250,12 @@ func (b Bbox) Slice(columns, rows int) []Bbox {
 			bottom := b.Bottom + float64(rows-row-1)*cellHeight
 			top := b.Bottom + float64(rows-row)*cellHeight
 
+			if crosses {
+				// wrap cells past the antimeridian back into the valid range
+				left = normalizeLon(left)
+				right = normalizeRightLon(right)
+			}
+
 			boxes = append(boxes, Bbox{
 				Left:   left,
 				Bottom: bottom,
@@ -140,3 +267,22 @@ func (b Bbox) Slice(columns, rows int) []Bbox {
 
 	return boxes
 }
+
+// isLongitude returns true if the value is in the range of valid longitudes
+func isLongitude(lon float64) bool {
+	return lon >= -180 && lon <= 180
+}
+
+// normalizeLon wraps a longitude into the range [-180, 180)
+func normalizeLon(lon float64) float64 {
+	return math.Mod(math.Mod(lon+180, 360)+360, 360) - 180
+}
+
+// normalizeRightLon wraps a longitude into the range (-180, 180], so a right edge on the antimeridian stays at 180
+func normalizeRightLon(lon float64) float64 {
+	normalized := normalizeLon(lon)
+	if normalized == -180 {
+		return 180
+	}
+	return normalized
+}
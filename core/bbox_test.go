@@ -25,9 +25,14 @@ func TestBboxValidate(t *testing.T) {
 		},
 		{
 			name:        "Negative-width bbox",
-			bbox:        Bbox{Left: 3.0, Bottom: 2.0, Right: 1.0, Top: 4.0},
+			bbox:        Bbox{Left: 300.0, Bottom: 2.0, Right: 100.0, Top: 4.0},
 			expectError: true,
-			errorMsg:    fmt.Sprintf("invalid bbox: Right (%f) must be greater than Left (%f)", 1.0, 3.0),
+			errorMsg:    fmt.Sprintf("invalid bbox: Right (%f) must be greater than Left (%f)", 100.0, 300.0),
+		},
+		{
+			name:        "Antimeridian-crossing bbox",
+			bbox:        Bbox{Left: 177.0, Bottom: -20.0, Right: -178.0, Top: -12.0},
+			expectError: false,
 		},
 		{
 			name:        "Zero-height bbox",
@@ -43,9 +48,9 @@ func TestBboxValidate(t *testing.T) {
 		},
 		{
 			name:        "Invalid width and height",
-			bbox:        Bbox{Left: 3.0, Bottom: 4.0, Right: 1.0, Top: 2.0},
+			bbox:        Bbox{Left: 300.0, Bottom: 4.0, Right: 100.0, Top: 2.0},
 			expectError: true,
-			errorMsg:    fmt.Sprintf("invalid bbox: Right (%f) must be greater than Left (%f)", 1.0, 3.0),
+			errorMsg:    fmt.Sprintf("invalid bbox: Right (%f) must be greater than Left (%f)", 100.0, 300.0),
 		},
 		{
 			name:        "Zero value bbox",
@@ -579,3 +584,198 @@ func TestBboxBufferProperties(t *testing.T) {
 		}
 	})
 }
+
+func TestBboxAntimeridian(t *testing.T) {
+	fiji := Bbox{Left: 177.0, Bottom: -20.0, Right: -178.0, Top: -12.0}
+
+	t.Run("CrossesAntimeridian", func(t *testing.T) {
+		if !fiji.CrossesAntimeridian() {
+			t.Errorf("expected %v to cross the antimeridian", fiji)
+		}
+		if (Bbox{Left: 1, Bottom: 1, Right: 2, Top: 2}).CrossesAntimeridian() {
+			t.Errorf("expected regular box not to cross the antimeridian")
+		}
+		if (Bbox{Left: 300, Bottom: 1, Right: 200, Top: 2}).CrossesAntimeridian() {
+			t.Errorf("expected box outside of longitude range not to cross the antimeridian")
+		}
+	})
+
+	t.Run("Width", func(t *testing.T) {
+		if fiji.Width() != 5 {
+			t.Errorf("expected width 5, got %f", fiji.Width())
+		}
+	})
+
+	t.Run("Center", func(t *testing.T) {
+		center := fiji.Center()
+		if center != [2]float64{179.5, -16} {
+			t.Errorf("expected center [179.5 -16], got %v", center)
+		}
+		center = Bbox{Left: 170, Bottom: 0, Right: -160, Top: 10}.Center()
+		if center != [2]float64{-175, 5} {
+			t.Errorf("expected center [-175 5], got %v", center)
+		}
+	})
+
+	t.Run("Split", func(t *testing.T) {
+		parts := fiji.Split()
+		expected := []Bbox{
+			{Left: 177, Bottom: -20, Right: 180, Top: -12},
+			{Left: -180, Bottom: -20, Right: -178, Top: -12},
+		}
+		if len(parts) != 2 || parts[0] != expected[0] || parts[1] != expected[1] {
+			t.Errorf("expected %v, got %v", expected, parts)
+		}
+
+		regular := Bbox{Left: 1, Bottom: 1, Right: 2, Top: 2}
+		if parts := regular.Split(); len(parts) != 1 || parts[0] != regular {
+			t.Errorf("expected regular box to be unchanged, got %v", parts)
+		}
+	})
+
+	t.Run("Polygon", func(t *testing.T) {
+		polygon := fiji.Polygon()
+		if polygon[1] != [2]float64{182, -20} || polygon[2] != [2]float64{182, -12} {
+			t.Errorf("expected polygon to extend past 180, got %v", polygon)
+		}
+		if polygons := fiji.Polygons(); len(polygons) != 2 {
+			t.Errorf("expected 2 polygons, got %d", len(polygons))
+		}
+	})
+
+	t.Run("Union", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			a, b     Bbox
+			expected Bbox
+		}{
+			{
+				name:     "Crossing with box to the east",
+				a:        fiji,
+				b:        Bbox{Left: -175, Bottom: -15, Right: -170, Top: -10},
+				expected: Bbox{Left: 177, Bottom: -20, Right: -170, Top: -10},
+			},
+			{
+				name:     "Crossing with box to the west",
+				a:        fiji,
+				b:        Bbox{Left: 170, Bottom: -25, Right: 175, Top: -15},
+				expected: Bbox{Left: 170, Bottom: -25, Right: -178, Top: -12},
+			},
+			{
+				name:     "Crossing with box inside",
+				a:        fiji,
+				b:        Bbox{Left: 178, Bottom: -19, Right: 179, Top: -13},
+				expected: fiji,
+			},
+			{
+				name:     "Crossing with box on the other side of the world",
+				a:        fiji,
+				b:        Bbox{Left: -10, Bottom: 0, Right: 10, Top: 10},
+				expected: Bbox{Left: -10, Bottom: -20, Right: -178, Top: 10},
+			},
+			{
+				name:     "Covers the whole world",
+				a:        Bbox{Left: 10, Bottom: 0, Right: -10, Top: 1},
+				b:        Bbox{Left: -20, Bottom: 0, Right: 20, Top: 1},
+				expected: Bbox{Left: -180, Bottom: 0, Right: 180, Top: 1},
+			},
+		}
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				if got := tc.a.Union(tc.b); got != tc.expected {
+					t.Errorf("expected %v, got %v", tc.expected, got)
+				}
+				if got := tc.b.Union(tc.a); got != tc.expected {
+					t.Errorf("expected union to be symmetric %v, got %v", tc.expected, got)
+				}
+			})
+		}
+	})
+
+	t.Run("Buffer", func(t *testing.T) {
+		got, err := fiji.Buffer(1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := Bbox{Left: 176, Bottom: -21, Right: -177, Top: -11}
+		if got != expected {
+			t.Errorf("expected %v, got %v", expected, got)
+		}
+
+		got, err = Bbox{Left: 179, Bottom: 0, Right: 179.5, Top: 1}.Buffer(1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.Right != 180.5 {
+			t.Errorf("expected regular boxes not to wrap, got %v", got)
+		}
+
+		got, err = fiji.Buffer(-2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected = Bbox{Left: 179, Bottom: -18, Right: 180, Top: -14}
+		if got != expected {
+			t.Errorf("expected %v, got %v", expected, got)
+		}
+
+		got, err = fiji.Buffer(180)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.Left != -180 || got.Right != 180 {
+			t.Errorf("expected buffer to cover the whole world, got %v", got)
+		}
+
+		if _, err := fiji.Buffer(-3); err == nil {
+			t.Errorf("expected error shrinking box by more than its width")
+		}
+	})
+
+	t.Run("Slice", func(t *testing.T) {
+		boxes := Bbox{Left: 170, Bottom: 0, Right: -170, Top: 10}.Slice(4, 1)
+		expected := []Bbox{
+			{Left: 170, Bottom: 0, Right: 175, Top: 10},
+			{Left: 175, Bottom: 0, Right: 180, Top: 10},
+			{Left: -180, Bottom: 0, Right: -175, Top: 10},
+			{Left: -175, Bottom: 0, Right: -170, Top: 10},
+		}
+		if len(boxes) != len(expected) {
+			t.Fatalf("expected %d boxes, got %d", len(expected), len(boxes))
+		}
+		for i := range expected {
+			if boxes[i] != expected[i] {
+				t.Errorf("box %d: expected %v, got %v", i, expected[i], boxes[i])
+			}
+		}
+
+		boxes = Bbox{Left: 170, Bottom: 0, Right: -170, Top: 10}.Slice(1, 1)
+		if !boxes[0].CrossesAntimeridian() {
+			t.Errorf("expected single cell to cross the antimeridian, got %v", boxes[0])
+		}
+	})
+}
+
+func TestBboxUnionHeights(t *testing.T) {
+	flat := Bbox{Left: 0, Bottom: 0, Right: 1, Top: 1}
+	low := Bbox{Left: 2, Bottom: 2, Right: 3, Top: 3, HasZ: true, MinZ: -5, MaxZ: 10}
+	high := Bbox{Left: 4, Bottom: 4, Right: 5, Top: 5, HasZ: true, MinZ: 100, MaxZ: 200}
+
+	if got := flat.Union(flat); got.HasZ {
+		t.Errorf("expected two dimensional boxes to union without heights, got %v", got)
+	}
+	if got := flat.Union(low); !got.HasZ || got.MinZ != -5 || got.MaxZ != 10 {
+		t.Errorf("expected the heights of the three dimensional box, got %v", got)
+	}
+	if got := low.Union(high); !got.HasZ || got.MinZ != -5 || got.MaxZ != 200 {
+		t.Errorf("expected heights from -5 to 200, got %v", got)
+	}
+	around := Bbox{Left: 170, Bottom: 0, Right: -170, Top: 1}
+	if got := around.Union(high); !got.HasZ || got.MinZ != 100 || got.MaxZ != 200 {
+		t.Errorf("expected heights to be kept around the antimeridian, got %v", got)
+	}
+	// boxes are compared by value, so an empty box is still the zero value after a union
+	if got := (Bbox{}).Union(Bbox{}); got != (Bbox{}) {
+		t.Errorf("expected the zero box, got %v", got)
+	}
+}
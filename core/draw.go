@@ -33,10 +33,10 @@ type TemplateContext struct {
 
 // StartDrawServer starts a web server for drawing bounding boxes.
 // It returns the received bounding box data as a Bbox struct.
-func StartDrawServer(bbox Bbox) (Bbox, error) {
-	// Ensure the box appears to be Valid Wgs84 coords
-	if !IsValidWgs84(bbox) {
-		return Bbox{}, fmt.Errorf("Box coordinates appear to be outside of the range of valid WGS84 coordinates. Cannot show non-WGS84 coordinates in --draw mode.")
+// crs is the CRS of the box, or nil if it isn't known.
+func StartDrawServer(bbox Bbox, crs CRS) (Bbox, error) {
+	if err := checkDrawable(bbox, crs); err != nil {
+		return Bbox{}, err
 	}
 
 	// Find the first available port starting from 5000
@@ -52,6 +52,23 @@ func StartDrawServer(bbox Bbox) (Bbox, error) {
 	return server.Start(bbox)
 }
 
+// checkDrawable returns an error if the box can't be shown on the WGS84 map, and warns
+// if it can be shown but will be a little off
+func checkDrawable(bbox Bbox, crs CRS) error {
+	if crs != nil && !crs.IsGeographic() {
+		return fmt.Errorf("Box is in the projected CRS %s, which cannot be shown in --draw mode.", crs)
+	}
+	if crs != nil && !crs.IsWgs84Compatible() {
+		log.Printf("Warning: box is in %s, it will be drawn as if it were WGS84 and may be off by up to a few hundred meters\n", crs)
+	}
+
+	// Ensure the box appears to be Valid Wgs84 coords
+	if !IsValidWgs84(bbox) {
+		return fmt.Errorf("Box coordinates appear to be outside of the range of valid WGS84 coordinates. Cannot show non-WGS84 coordinates in --draw mode.")
+	}
+	return nil
+}
+
 // Start starts the web server and returns the bounding box data when received
 func (s *DrawServer) Start(inputBbox Bbox) (Bbox, error) {
 	// Create a server with the UI handler
@@ -0,0 +1,39 @@
+package core
+
+import "testing"
+
+type testCRS struct {
+	geographic      bool
+	wgs84Compatible bool
+}
+
+func (c testCRS) String() string          { return "EPSG:test" }
+func (c testCRS) IsGeographic() bool      { return c.geographic }
+func (c testCRS) IsWgs84Compatible() bool { return c.wgs84Compatible }
+
+func TestCheckDrawable(t *testing.T) {
+	valid := Bbox{Left: 1, Bottom: 2, Right: 3, Top: 4}
+	projected := Bbox{Left: 500000, Bottom: 4000000, Right: 600000, Top: 4100000}
+
+	tests := []struct {
+		name    string
+		bbox    Bbox
+		crs     CRS
+		wantErr bool
+	}{
+		{name: "Unknown CRS with valid coordinates", bbox: valid, crs: nil},
+		{name: "Unknown CRS with projected coordinates", bbox: projected, crs: nil, wantErr: true},
+		{name: "WGS84", bbox: valid, crs: testCRS{geographic: true, wgs84Compatible: true}},
+		{name: "Other geographic CRS", bbox: valid, crs: testCRS{geographic: true}},
+		{name: "Projected CRS", bbox: valid, crs: testCRS{}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkDrawable(tt.bbox, tt.crs)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkDrawable() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,5 +1,15 @@
 package core
 
+// CRS is the coordinate reference system of a box, as far as the core package needs
+// to know about it. See the proj package for the implementation.
+type CRS interface {
+	String() string
+	// IsGeographic returns true if the coordinates are longitude and latitude in degrees
+	IsGeographic() bool
+	// IsWgs84Compatible returns true if the coordinates are close enough to WGS84 to use as is
+	IsWgs84Compatible() bool
+}
+
 // IsValidWgs84 checks if the bounding box coordinates are within valid WGS84 ranges.
 // Valid WGS84 coordinates: longitude [-180, 180], latitude [-90, 90]
 func IsValidWgs84(b Bbox) bool {
@@ -0,0 +1,113 @@
+package core
+
+import (
+	"fmt"
+	"math"
+)
+
+// MaxTileZoom is the highest zoom level supported when computing tiles.
+const MaxTileZoom = 30
+
+// Latitude limit of the web mercator projection used by XYZ tiles.
+const maxMercatorLat = 85.0511287798066
+
+// Tile is an XYZ web map tile.
+type Tile struct {
+	Z int
+	X int
+	Y int
+}
+
+// String returns the tile in z/x/y format.
+func (t Tile) String() string {
+	return fmt.Sprintf("%d/%d/%d", t.Z, t.X, t.Y)
+}
+
+// Bbox returns the bounds of the tile in WGS84 coordinates.
+func (t Tile) Bbox() Bbox {
+	n := math.Exp2(float64(t.Z))
+	return Bbox{
+		Left:   float64(t.X)/n*360 - 180,
+		Bottom: tileYToLat(float64(t.Y+1), n),
+		Right:  float64(t.X+1)/n*360 - 180,
+		Top:    tileYToLat(float64(t.Y), n),
+	}
+}
+
+// TileRange is the inclusive range of tile columns and rows covering a box at a single zoom.
+type TileRange struct {
+	Z    int
+	MinX int
+	MinY int
+	MaxX int
+	MaxY int
+}
+
+// Count returns the number of tiles in the range.
+func (r TileRange) Count() int64 {
+	return int64(r.MaxX-r.MinX+1) * int64(r.MaxY-r.MinY+1)
+}
+
+// Tiles returns every tile in the range in row-major order (left to right, top to bottom).
+func (r TileRange) Tiles() []Tile {
+	tiles := make([]Tile, 0, r.Count())
+	for y := r.MinY; y <= r.MaxY; y++ {
+		for x := r.MinX; x <= r.MaxX; x++ {
+			tiles = append(tiles, Tile{Z: r.Z, X: x, Y: y})
+		}
+	}
+	return tiles
+}
+
+// TileRangeForBbox returns the range of tiles at the given zoom that cover the box.
+// Tiles that only touch the edge of the box are not included.
+func TileRangeForBbox(b Bbox, zoom int) (TileRange, error) {
+	if zoom < 0 || zoom > MaxTileZoom {
+		return TileRange{}, fmt.Errorf("zoom %d is outside of the range 0-%d", zoom, MaxTileZoom)
+	}
+	if !IsValidWgs84(b) {
+		return TileRange{}, fmt.Errorf("tiles can only be computed for WGS84 coordinates")
+	}
+
+	n := math.Exp2(float64(zoom))
+	minX, maxX := tileSpan(lonToTileX(b.Left, n), lonToTileX(b.Right, n), n)
+	// tile rows count down from the top of the map
+	minY, maxY := tileSpan(latToTileY(b.Top, n), latToTileY(b.Bottom, n), n)
+
+	return TileRange{Z: zoom, MinX: minX, MinY: minY, MaxX: maxX, MaxY: maxY}, nil
+}
+
+// tileSpan converts fractional tile positions into an inclusive range of tile indexes
+func tileSpan(min, max, n float64) (int, int) {
+	first := int(math.Floor(min))
+	last := int(math.Ceil(max)) - 1
+	if last < first {
+		// the box has no extent along this axis
+		last = first
+	}
+	return clampTile(first, n), clampTile(last, n)
+}
+
+func clampTile(i int, n float64) int {
+	if i < 0 {
+		return 0
+	}
+	if i > int(n)-1 {
+		return int(n) - 1
+	}
+	return i
+}
+
+func lonToTileX(lon, n float64) float64 {
+	return (lon + 180) / 360 * n
+}
+
+func latToTileY(lat, n float64) float64 {
+	lat = math.Max(-maxMercatorLat, math.Min(maxMercatorLat, lat))
+	rad := lat * math.Pi / 180
+	return (1 - math.Log(math.Tan(rad)+1/math.Cos(rad))/math.Pi) / 2 * n
+}
+
+func tileYToLat(y, n float64) float64 {
+	return math.Atan(math.Sinh(math.Pi*(1-2*y/n))) * 180 / math.Pi
+}
@@ -0,0 +1,119 @@
+package core
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTileRangeForBbox(t *testing.T) {
+	tests := []struct {
+		name    string
+		bbox    Bbox
+		zoom    int
+		want    TileRange
+		wantErr bool
+	}{
+		{
+			name: "Whole world at zoom 0",
+			bbox: Bbox{Left: -180, Bottom: -85, Right: 180, Top: 85},
+			zoom: 0,
+			want: TileRange{Z: 0, MinX: 0, MinY: 0, MaxX: 0, MaxY: 0},
+		},
+		{
+			name: "Whole world at zoom 2",
+			bbox: Bbox{Left: -180, Bottom: -90, Right: 180, Top: 90},
+			zoom: 2,
+			want: TileRange{Z: 2, MinX: 0, MinY: 0, MaxX: 3, MaxY: 3},
+		},
+		{
+			name: "North east quadrant at zoom 1",
+			bbox: Bbox{Left: 10, Bottom: 10, Right: 20, Top: 20},
+			zoom: 1,
+			want: TileRange{Z: 1, MinX: 1, MinY: 0, MaxX: 1, MaxY: 0},
+		},
+		{
+			name: "Edges on tile boundaries do not include neighbours",
+			bbox: Bbox{Left: 0, Bottom: 0, Right: 90, Top: 66.5},
+			zoom: 2,
+			want: TileRange{Z: 2, MinX: 2, MinY: 1, MaxX: 2, MaxY: 1},
+		},
+		{
+			name: "Point",
+			bbox: Bbox{Left: -93.265, Bottom: 44.9778, Right: -93.265, Top: 44.9778},
+			zoom: 10,
+			want: TileRange{Z: 10, MinX: 246, MinY: 368, MaxX: 246, MaxY: 368},
+		},
+		{
+			name:    "Zoom too large",
+			bbox:    Bbox{Left: 0, Bottom: 0, Right: 1, Top: 1},
+			zoom:    31,
+			wantErr: true,
+		},
+		{
+			name:    "Not WGS84",
+			bbox:    Bbox{Left: 500000, Bottom: 4000000, Right: 600000, Top: 4100000},
+			zoom:    5,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TileRangeForBbox(tt.bbox, tt.zoom)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TileRangeForBbox() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("TileRangeForBbox() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTileRangeTiles(t *testing.T) {
+	r := TileRange{Z: 3, MinX: 1, MinY: 4, MaxX: 2, MaxY: 5}
+	want := []Tile{{3, 1, 4}, {3, 2, 4}, {3, 1, 5}, {3, 2, 5}}
+
+	if r.Count() != 4 {
+		t.Errorf("Count() = %d, want 4", r.Count())
+	}
+
+	got := r.Tiles()
+	if len(got) != len(want) {
+		t.Fatalf("Tiles() returned %d tiles, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Tiles()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestTileBbox(t *testing.T) {
+	const tolerance = 1e-9
+
+	t.Run("Zoom 0 covers the mercator world", func(t *testing.T) {
+		got := Tile{0, 0, 0}.Bbox()
+		if got.Left != -180 || got.Right != 180 ||
+			math.Abs(got.Top-maxMercatorLat) > tolerance || math.Abs(got.Bottom+maxMercatorLat) > tolerance {
+			t.Errorf("Bbox() = %v", got)
+		}
+	})
+
+	t.Run("Round trips through TileRangeForBbox", func(t *testing.T) {
+		tile := Tile{12, 1171, 1566}
+		r, err := TileRangeForBbox(tile.Bbox(), tile.Z)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if r.Count() != 1 || r.MinX != tile.X || r.MinY != tile.Y {
+			t.Errorf("expected only %v, got %+v", tile, r)
+		}
+	})
+
+	t.Run("String", func(t *testing.T) {
+		if got := (Tile{12, 1171, 1566}).String(); got != "12/1171/1566" {
+			t.Errorf("String() = %s", got)
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Mean radius of the earth in meters, used to convert distances to degrees.
+const earthRadiusMeters = 6371008.8
+
+// metersPerDegree is the length of one degree of latitude (or of longitude at the equator).
+const metersPerDegree = earthRadiusMeters * math.Pi / 180
+
+// Distance unit suffixes and their length in meters.
+// A value without a unit is treated as degrees.
+var distanceUnits = map[string]float64{
+	"mi": 1609.344,
+	"ft": 0.3048,
+	"km": 1000,
+	"m":  1,
+}
+
+// Distance is a length that is either in degrees or in a linear unit.
+type Distance struct {
+	Value float64
+	// Unit is one of mi, ft, km, m, or empty when the value is in degrees
+	Unit string
+}
+
+// ParseDistance parses a number with an optional distance unit suffix, e.g. "10", "2.5mi" or "-100 m".
+func ParseDistance(s string) (Distance, error) {
+	trimmed := strings.ToLower(strings.TrimSpace(s))
+	if trimmed == "" {
+		return Distance{}, fmt.Errorf("empty distance")
+	}
+
+	unit := ""
+	number := trimmed
+	// find the start of the unit suffix
+	if i := strings.LastIndexAny(trimmed, "0123456789."); i >= 0 && i < len(trimmed)-1 {
+		unit = strings.TrimSpace(trimmed[i+1:])
+		number = strings.TrimSpace(trimmed[:i+1])
+	}
+
+	if _, ok := distanceUnits[unit]; unit != "" && !ok {
+		return Distance{}, fmt.Errorf("unknown distance unit %q, expected one of mi, ft, km, m", unit)
+	}
+
+	val, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return Distance{}, fmt.Errorf("could not parse distance: %s", s)
+	}
+	if math.IsNaN(val) || math.IsInf(val, 0) {
+		return Distance{}, fmt.Errorf("distance must be a finite number: %s", s)
+	}
+
+	return Distance{Value: val, Unit: unit}, nil
+}
+
+// IsDegrees returns true if the distance has no unit and is already in degrees.
+func (d Distance) IsDegrees() bool {
+	return d.Unit == ""
+}
+
+// Meters returns the distance in meters. Distances in degrees are returned unchanged.
+func (d Distance) Meters() float64 {
+	if d.IsDegrees() {
+		return d.Value
+	}
+	return d.Value * distanceUnits[d.Unit]
+}
+
+// LatDegrees returns the distance as degrees of latitude.
+func (d Distance) LatDegrees() float64 {
+	if d.IsDegrees() {
+		return d.Value
+	}
+	return d.Meters() / metersPerDegree
+}
+
+// LonDegrees returns the distance as degrees of longitude at the given latitude.
+func (d Distance) LonDegrees(lat float64) float64 {
+	if d.IsDegrees() {
+		return d.Value
+	}
+	cosLat := math.Cos(lat * math.Pi / 180)
+	// avoid dividing by zero at the poles
+	if cosLat < 1e-12 {
+		cosLat = 1e-12
+	}
+	return d.Meters() / (metersPerDegree * cosLat)
+}
@@ -0,0 +1,86 @@
+package core
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseDistance(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Distance
+		wantErr bool
+	}{
+		{name: "Degrees", input: "10", want: Distance{Value: 10}},
+		{name: "Negative degrees", input: "-2.5", want: Distance{Value: -2.5}},
+		{name: "Miles", input: "2mi", want: Distance{Value: 2, Unit: "mi"}},
+		{name: "Feet", input: "500ft", want: Distance{Value: 500, Unit: "ft"}},
+		{name: "Kilometers", input: "5km", want: Distance{Value: 5, Unit: "km"}},
+		{name: "Meters", input: "100m", want: Distance{Value: 100, Unit: "m"}},
+		{name: "Space before unit", input: "1.5 km", want: Distance{Value: 1.5, Unit: "km"}},
+		{name: "Upper case unit", input: "3KM", want: Distance{Value: 3, Unit: "km"}},
+		{name: "Negative with unit", input: "-10m", want: Distance{Value: -10, Unit: "m"}},
+		{name: "Exponent with unit", input: "1e3m", want: Distance{Value: 1000, Unit: "m"}},
+		{name: "Unknown unit", input: "5yd", wantErr: true},
+		{name: "Empty", input: "", wantErr: true},
+		{name: "Not a number", input: "abc", wantErr: true},
+		{name: "Unit only", input: "km", wantErr: true},
+		{name: "Infinity", input: "inf", wantErr: true},
+		{name: "Negative infinity", input: "-inf", wantErr: true},
+		{name: "Not a number value", input: "NaN", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDistance(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDistance(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseDistance(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDistanceDegrees(t *testing.T) {
+	const tolerance = 1e-9
+
+	t.Run("Degrees are unchanged", func(t *testing.T) {
+		d := Distance{Value: 2}
+		if d.LatDegrees() != 2 || d.LonDegrees(60) != 2 {
+			t.Errorf("expected degrees to pass through unchanged, got %f %f", d.LatDegrees(), d.LonDegrees(60))
+		}
+	})
+
+	t.Run("One degree of latitude", func(t *testing.T) {
+		d := Distance{Value: metersPerDegree, Unit: "m"}
+		if math.Abs(d.LatDegrees()-1) > tolerance {
+			t.Errorf("expected 1 degree, got %f", d.LatDegrees())
+		}
+	})
+
+	t.Run("Longitude degrees grow with latitude", func(t *testing.T) {
+		d := Distance{Value: 10, Unit: "km"}
+		equator := d.LonDegrees(0)
+		sixty := d.LonDegrees(60)
+		if math.Abs(sixty-equator*2) > tolerance {
+			t.Errorf("expected longitude degrees at 60° to be double the equator, got %f and %f", sixty, equator)
+		}
+	})
+
+	t.Run("Miles to meters", func(t *testing.T) {
+		d := Distance{Value: 1, Unit: "mi"}
+		if math.Abs(d.Meters()-1609.344) > tolerance {
+			t.Errorf("expected 1609.344 meters, got %f", d.Meters())
+		}
+	})
+
+	t.Run("Poles do not divide by zero", func(t *testing.T) {
+		d := Distance{Value: 1, Unit: "km"}
+		if math.IsInf(d.LonDegrees(90), 0) || math.IsNaN(d.LonDegrees(90)) {
+			t.Errorf("expected finite longitude degrees at the pole, got %f", d.LonDegrees(90))
+		}
+	})
+}
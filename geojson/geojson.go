@@ -3,24 +3,45 @@ package geojson
 import "encoding/json"
 
 // GeoJSON type definitions
+//
+// Bbox is the optional bbox member from RFC 7946: the minimums of every dimension, then the
+// maximums. A box that crosses the antimeridian has a west longitude greater than its east one.
 type FeatureCollection struct {
 	Type     string    `json:"type"`
+	Bbox     []float64 `json:"bbox,omitempty"`
 	Features []Feature `json:"features"`
+	Crs      *CRS      `json:"crs,omitempty"`
 }
 
 type Feature struct {
-	Type     string   `json:"type"`
-	Geometry Geometry `json:"geometry"`
+	Type     string    `json:"type"`
+	Bbox     []float64 `json:"bbox,omitempty"`
+	Geometry Geometry  `json:"geometry"`
+	Crs      *CRS      `json:"crs,omitempty"`
 }
 
 type Geometry struct {
 	Type        string          `json:"type"`
+	Bbox        []float64       `json:"bbox,omitempty"`
 	Coordinates json.RawMessage `json:"coordinates"`
+	Crs         *CRS            `json:"crs,omitempty"`
 }
 
 type Polygon struct {
 	Type        string         `json:"type"`
+	Bbox        []float64      `json:"bbox,omitempty"`
 	Coordinates [][][2]float64 `json:"coordinates"`
+	Crs         *CRS           `json:"crs,omitempty"`
+}
+
+// CRS is the crs member from the 2008 GeoJSON spec. RFC 7946 dropped it, but older files still use it.
+type CRS struct {
+	// Type is "name", or "EPSG" in some older files
+	Type       string `json:"type"`
+	Properties struct {
+		Name string      `json:"name,omitempty"`
+		Code json.Number `json:"code,omitempty"`
+	} `json:"properties"`
 }
 
 func PolygonGeometry(coords [][][2]float64) Geometry {
@@ -31,6 +52,14 @@ func PolygonGeometry(coords [][][2]float64) Geometry {
 	}
 }
 
+func MultiPolygonGeometry(coords [][][][2]float64) Geometry {
+	coordsData, _ := json.Marshal(coords)
+	return Geometry{
+		Type:        "MultiPolygon",
+		Coordinates: json.RawMessage(coordsData),
+	}
+}
+
 func PointGeometry(x, y float64) Geometry {
 	coords := [2]float64{x, y}
 	coordsData, _ := json.Marshal(coords)
@@ -3,10 +3,16 @@ package geojson
 import (
 	"encoding/json"
 	"strings"
+
+	"github.com/mikeocool/bbox/core"
 )
 
-func Format(geoms []Geometry, outputType string, indent int) (string, error) {
+// Format formats the geometries as the GeoJSON output type. With bbox, every Feature and
+// collection has a bbox member, and so does a geometry on its own. Geometries that already have a
+// Bbox keep it, otherwise it's computed from their coordinates. Without bbox, none are written.
+func Format(geoms []Geometry, outputType string, indent int, bbox bool) (string, error) {
 	// TODO ensure outputType is a valid geojson type
+	geoms = withBboxes(geoms, bbox)
 	if outputType == "" {
 		if len(geoms) == 1 {
 			outputType = "geometry"
@@ -36,11 +42,16 @@ func Format(geoms []Geometry, outputType string, indent int) (string, error) {
 	}
 
 	features := make([]Feature, len(geoms))
+	boxes := make([][]float64, len(geoms))
 	for i, geom := range geoms {
+		// the feature's bbox is the geometry's, so it isn't repeated
 		features[i] = Feature{
 			Type:     "Feature",
+			Bbox:     geom.Bbox,
 			Geometry: geom,
 		}
+		features[i].Geometry.Bbox = nil
+		boxes[i] = geom.Bbox
 	}
 
 	if outputType == "feature" {
@@ -55,6 +66,9 @@ func Format(geoms []Geometry, outputType string, indent int) (string, error) {
 		Type:     "FeatureCollection",
 		Features: features,
 	}
+	if bbox {
+		collection.Bbox = unionBboxes(boxes)
+	}
 
 	return marshalGeojson(collection, indent)
 }
@@ -73,3 +87,96 @@ func marshalGeojson(geojson any, indent int) (string, error) {
 
 	return string(data), nil
 }
+
+// withBboxes returns a copy of the geometries with a Bbox if bbox is set, or without one if it isn't
+func withBboxes(geoms []Geometry, bbox bool) []Geometry {
+	result := make([]Geometry, len(geoms))
+	for i, geom := range geoms {
+		if !bbox {
+			geom.Bbox = nil
+		} else if geom.Bbox == nil {
+			geom.Bbox = coordinatesBbox(geom.Coordinates)
+		}
+		result[i] = geom
+	}
+	return result
+}
+
+// coordinatesBbox returns the bbox of the positions in coordinates, or nil if there aren't any.
+// It's three dimensional if any of the positions have a height.
+func coordinatesBbox(coordinates json.RawMessage) []float64 {
+	var values any
+	if err := json.Unmarshal(coordinates, &values); err != nil {
+		return nil
+	}
+
+	var box *core.Bbox
+	var visit func(value any)
+	visit = func(value any) {
+		array, ok := value.([]any)
+		if !ok {
+			return
+		}
+		if len(array) >= 2 {
+			x, xOk := array[0].(float64)
+			y, yOk := array[1].(float64)
+			if xOk && yOk {
+				position := core.Bbox{Left: x, Bottom: y, Right: x, Top: y}
+				if len(array) >= 3 {
+					if z, ok := array[2].(float64); ok {
+						position.HasZ, position.MinZ, position.MaxZ = true, z, z
+					}
+				}
+				if box == nil {
+					box = &position
+				} else {
+					*box = box.Union(position)
+				}
+				return
+			}
+		}
+		for _, element := range array {
+			visit(element)
+		}
+	}
+	visit(values)
+
+	if box == nil {
+		return nil
+	}
+	return bboxMember(*box)
+}
+
+// bboxMember returns the values of a bbox member for a box, with the heights if it has them
+func bboxMember(box core.Bbox) []float64 {
+	if box.HasZ {
+		return []float64{box.Left, box.Bottom, box.MinZ, box.Right, box.Top, box.MaxZ}
+	}
+	return []float64{box.Left, box.Bottom, box.Right, box.Top}
+}
+
+// unionBboxes returns the bbox covering the two or three dimensional bboxes, or nil if there
+// aren't any. Boxes that cross the antimeridian are unioned around it.
+func unionBboxes(boxes [][]float64) []float64 {
+	var union *core.Bbox
+	for _, b := range boxes {
+		var box core.Bbox
+		switch len(b) {
+		case 4:
+			box = core.Bbox{Left: b[0], Bottom: b[1], Right: b[2], Top: b[3]}
+		case 6:
+			box = core.Bbox{Left: b[0], Bottom: b[1], Right: b[3], Top: b[4], HasZ: true, MinZ: b[2], MaxZ: b[5]}
+		default:
+			continue
+		}
+		if union == nil {
+			union = &box
+		} else {
+			*union = union.Union(box)
+		}
+	}
+	if union == nil {
+		return nil
+	}
+	return bboxMember(*union)
+}
@@ -245,7 +245,7 @@ func TestFormat(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := Format(tt.geoms, tt.outputType, tt.indent)
+			got, err := Format(tt.geoms, tt.outputType, tt.indent, false)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Format() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -326,7 +326,7 @@ func TestFormatEdgeCases(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := Format(tt.geoms, tt.outputType, tt.indent)
+			got, err := Format(tt.geoms, tt.outputType, tt.indent, false)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Format() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -439,7 +439,7 @@ func TestFormatOutputTypeValidation(t *testing.T) {
 
 	for _, outputType := range validOutputTypes {
 		t.Run("Valid output type: "+outputType, func(t *testing.T) {
-			_, err := Format([]Geometry{pointGeom}, outputType, 0)
+			_, err := Format([]Geometry{pointGeom}, outputType, 0, false)
 			if err != nil {
 				t.Errorf("Format() with valid outputType %q should not error, got: %v", outputType, err)
 			}
@@ -457,7 +457,7 @@ func TestFormatOutputTypeValidation(t *testing.T) {
 
 	for _, outputType := range invalidOutputTypes {
 		t.Run("Invalid output type defaults to feature-collection: "+outputType, func(t *testing.T) {
-			got, err := Format([]Geometry{pointGeom}, outputType, 0)
+			got, err := Format([]Geometry{pointGeom}, outputType, 0, false)
 			if err != nil {
 				t.Errorf("Format() with invalid outputType %q should not error, got: %v", outputType, err)
 				return
@@ -546,7 +546,7 @@ func TestFormatJSONStructure(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := Format(tt.geoms, tt.outputType, 0)
+			got, err := Format(tt.geoms, tt.outputType, 0, false)
 			if err != nil {
 				t.Errorf("Format() error = %v", err)
 				return
@@ -556,3 +556,82 @@ func TestFormatJSONStructure(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatBbox(t *testing.T) {
+	antimeridian := MultiPolygonGeometry([][][][2]float64{
+		{{{170, -20}, {180, -20}, {180, -10}, {170, -10}, {170, -20}}},
+		{{{-180, -20}, {-170, -20}, {-170, -10}, {-180, -10}, {-180, -20}}},
+	})
+	antimeridian.Bbox = []float64{170, -20, -170, -10}
+	line := LineStringGeometry([][2]float64{{1, 2}, {-3, 4}})
+
+	tests := []struct {
+		name       string
+		geoms      []Geometry
+		outputType string
+		bbox       bool
+		want       string
+	}{
+		{
+			name:       "geometry computed from its coordinates",
+			geoms:      []Geometry{line},
+			outputType: "geometry",
+			bbox:       true,
+			want:       `{"type":"LineString","bbox":[-3,2,1,4],"coordinates":[[1,2],[-3,4]]}`,
+		},
+		{
+			name:       "feature has the bbox instead of its geometry",
+			geoms:      []Geometry{PointGeometry(5, 6)},
+			outputType: "feature",
+			bbox:       true,
+			want:       `{"type":"Feature","bbox":[5,6,5,6],"geometry":{"type":"Point","coordinates":[5,6]}}`,
+		},
+		{
+			name:       "collection around the antimeridian",
+			geoms:      []Geometry{antimeridian, PointGeometry(175, 0)},
+			outputType: "feature-collection",
+			bbox:       true,
+			want: `{"type":"FeatureCollection","bbox":[170,-20,-170,0],"features":[` +
+				`{"type":"Feature","bbox":[170,-20,-170,-10],"geometry":{"type":"MultiPolygon","coordinates":[[[[170,-20],[180,-20],[180,-10],[170,-10],[170,-20]]],[[[-180,-20],[-170,-20],[-170,-10],[-180,-10],[-180,-20]]]]}},` +
+				`{"type":"Feature","bbox":[175,0,175,0],"geometry":{"type":"Point","coordinates":[175,0]}}]}`,
+		},
+		{
+			name:       "geometry bbox is left out without bbox",
+			geoms:      []Geometry{antimeridian},
+			outputType: "feature",
+			want:       `{"type":"Feature","geometry":{"type":"MultiPolygon","coordinates":[[[[170,-20],[180,-20],[180,-10],[170,-10],[170,-20]]],[[[-180,-20],[-170,-20],[-170,-10],[-180,-10],[-180,-20]]]]}}`,
+		},
+		{
+			name:       "three dimensional bboxes",
+			geoms:      []Geometry{{Type: "Point", Coordinates: []byte(`[1,2,30]`)}, PointGeometry(3, 4)},
+			outputType: "feature-collection",
+			bbox:       true,
+			want: `{"type":"FeatureCollection","bbox":[1,2,30,3,4,30],"features":[` +
+				`{"type":"Feature","bbox":[1,2,30,1,2,30],"geometry":{"type":"Point","coordinates":[1,2,30]}},` +
+				`{"type":"Feature","bbox":[3,4,3,4],"geometry":{"type":"Point","coordinates":[3,4]}}]}`,
+		},
+		{
+			name:       "coordinates don't have a bbox",
+			geoms:      []Geometry{line},
+			outputType: "coordinates",
+			bbox:       true,
+			want:       `[[1,2],[-3,4]]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Format(tt.geoms, tt.outputType, 0, tt.bbox)
+			if err != nil {
+				t.Fatalf("Format() unexpected error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Format() = %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+
+	if antimeridian.Bbox == nil || line.Bbox != nil {
+		t.Errorf("Format() changed the geometries passed to it")
+	}
+}
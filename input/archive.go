@@ -0,0 +1,200 @@
+package input
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/mikeocool/bbox/core"
+	"github.com/mikeocool/bbox/proj"
+)
+
+var ErrNoSupportedArchiveMembers = errors.New("no supported files found in archive")
+
+// shpSidecarExtensions are the files that accompany a .shp, which don't have bounds of their own.
+// .shx files have the same header as a .shp, so they'd otherwise be sniffed as one. Metadata is
+// only a sidecar as .shp.xml, since other .xml files can be KML, GPX or OSM.
+var shpSidecarExtensions = []string{".shx", ".dbf", ".prj", ".cpg", ".sbn", ".sbx", ".qix", ".shp.xml"}
+
+func SniffZip(data []byte) bool {
+	// local file header, or the end of central directory record of an empty archive
+	return bytes.HasPrefix(data, []byte("PK\x03\x04")) || bytes.HasPrefix(data, []byte("PK\x05\x06"))
+}
+
+func SniffGzip(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b
+}
+
+func SniffTar(data []byte) bool {
+	// ustar magic in the first header block, for POSIX and GNU tar
+	return len(data) >= 262 && bytes.Equal(data[257:262], []byte("ustar"))
+}
+
+// ParseZip reads the bounds of every supported file in a zip archive
+func ParseZip(data []byte, opts ReadOptions) (core.Bbox, *proj.CRS, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return core.Bbox{}, nil, fmt.Errorf("failed to read zip archive: %w", err)
+	}
+
+	archive := newArchiveReader(opts)
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || skipArchiveMember(f.Name) {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return core.Bbox{}, nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		err = archive.read(f.Name, rc)
+		rc.Close()
+		if err != nil {
+			return core.Bbox{}, nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+	}
+	return archive.result()
+}
+
+// ParseTar reads the bounds of every supported file in a tar archive
+func ParseTar(r io.Reader, opts ReadOptions) (core.Bbox, *proj.CRS, error) {
+	tr := tar.NewReader(r)
+
+	archive := newArchiveReader(opts)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return core.Bbox{}, nil, fmt.Errorf("failed to read tar archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg || skipArchiveMember(header.Name) {
+			continue
+		}
+		if err := archive.read(header.Name, tr); err != nil {
+			return core.Bbox{}, nil, fmt.Errorf("%s: %w", header.Name, err)
+		}
+	}
+	return archive.result()
+}
+
+// skipArchiveMember reports whether a member can be skipped without reading it: shapefile
+// sidecars other than the .prj, and the metadata macOS adds to zip files
+func skipArchiveMember(name string) bool {
+	if strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(path.Base(name), "._") {
+		return true
+	}
+	return isShpSidecar(name) && !strings.EqualFold(path.Ext(name), ".prj")
+}
+
+// isShpSidecar reports whether a member is one of the files that accompany a .shp
+func isShpSidecar(name string) bool {
+	name = strings.ToLower(name)
+	for _, ext := range shpSidecarExtensions {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// archiveReader reads the members of an archive one at a time, so only one of them is in memory at
+// once. Only the .prj files are kept, since a shapefile's can come after it.
+type archiveReader struct {
+	opts ReadOptions
+	prjs map[string]*proj.CRS
+	// bounds are the bounds of the members read so far, in the order they're unioned
+	bounds    []archiveBounds
+	supported bool
+}
+
+// archiveBounds is the bounds of a member. Shapefiles have prjSidecar set, and use the CRS from the
+// .prj next to them once the whole archive has been read.
+type archiveBounds struct {
+	name       string
+	box        core.Bbox
+	crs        *proj.CRS
+	prjSidecar bool
+}
+
+func newArchiveReader(opts ReadOptions) *archiveReader {
+	return &archiveReader{opts: opts, prjs: make(map[string]*proj.CRS)}
+}
+
+// read reads the bounds of a member if it's supported, either by its extension or by sniffing the
+// start of its contents
+func (a *archiveReader) read(name string, r io.Reader) error {
+	if strings.EqualFold(path.Ext(name), ".prj") {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		a.prjs[archiveMemberBase(name)] = parsePrj(name, data)
+		return nil
+	}
+	if isShpSidecar(name) {
+		return nil
+	}
+
+	bounds := archiveBounds{name: name}
+	var err error
+	if format := fileFormatFor(name); format != nil {
+		bounds.box, bounds.crs, err = format.parse(r, a.opts)
+		bounds.prjSidecar = format.prjSidecar
+	} else {
+		head := make([]byte, 8192)
+		n, readErr := io.ReadFull(r, head)
+		if readErr != nil && readErr != io.EOF && readErr != io.ErrUnexpectedEOF {
+			return readErr
+		}
+		if !sniffGeodata(head[:n]) {
+			return nil
+		}
+		bounds.box, bounds.crs, err = ParseData(io.MultiReader(bytes.NewReader(head[:n]), r), a.opts)
+	}
+	a.supported = true
+
+	if errors.Is(err, ErrNoFeaturesFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	a.bounds = append(a.bounds, bounds)
+	return nil
+}
+
+// result unions the bounds of the members that were read
+func (a *archiveReader) result() (core.Bbox, *proj.CRS, error) {
+	if !a.supported {
+		return core.Bbox{}, nil, ErrNoSupportedArchiveMembers
+	}
+	var union bboxUnion
+	for _, b := range a.bounds {
+		crs := b.crs
+		if b.prjSidecar {
+			crs = a.prjs[archiveMemberBase(b.name)]
+		}
+		if err := union.add(b.box, crs); err != nil {
+			return core.Bbox{}, nil, fmt.Errorf("%s: %w", b.name, err)
+		}
+	}
+	return union.result()
+}
+
+// sniffGeodata reports whether the data looks like one of the formats with bounds. Nested
+// archives aren't included.
+func sniffGeodata(head []byte) bool {
+	return SniffGeojson(head) || SniffGeojsonSeq(head) || SniffShapefile(head) || SniffKml(head) || SniffGpx(head) ||
+		SniffOsm(head) || SniffOsmPbf(head) || SniffParquet(head) || SniffFlatgeobuf(head) ||
+		SniffGeopackage(head) || SniffLas(head) || SniffPmtiles(head) || SniffMbtiles(head)
+}
+
+// archiveMemberBase returns the member name without its extension, lowercased so
+// sidecars match regardless of case
+func archiveMemberBase(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, path.Ext(name)))
+}
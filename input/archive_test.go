@@ -0,0 +1,244 @@
+package input
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mikeocool/bbox/core"
+)
+
+const (
+	campsitesShp = "../integration_tests/data/campsites/Wilderness_Campsites.shp"
+	campsitesPrj = "../integration_tests/data/campsites/Wilderness_Campsites.prj"
+
+	pointGeojson = `{"type": "Feature", "geometry": {"type": "Point", "coordinates": [10, 20]}}`
+	lineGeojson  = `{"type": "Feature", "geometry": {"type": "LineString", "coordinates": [[-5, 0], [1, 30]]}}`
+)
+
+// archiveMember is a file to add to a test archive
+type archiveMember struct {
+	name string
+	data []byte
+}
+
+func buildZip(t *testing.T, files []archiveMember) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(f.data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func buildTarGz(t *testing.T, files []archiveMember) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Name: "data/", Typeflag: tar.TypeDir, Mode: 0o755}); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: f.name, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(f.data))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func gzipData(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestParseDataArchives(t *testing.T) {
+	want := core.Bbox{Left: -5, Bottom: 0, Right: 10, Top: 30}
+	files := []archiveMember{
+		{"data/point.geojson", []byte(pointGeojson)},
+		{"data/line.txt", []byte(lineGeojson)}, // detected by sniffing
+		{"README.md", []byte("# not geo data")},
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "zip", data: buildZip(t, files)},
+		{name: "tar.gz", data: buildTarGz(t, files)},
+		{name: "gzipped geojson", data: gzipData(t, []byte(`{"type": "Polygon", "coordinates": [[[-5, 0], [10, 0], [10, 30], [-5, 0]]]}`))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, crs, err := ParseData(bytes.NewReader(tt.data), ReadOptions{})
+			if err != nil {
+				t.Fatalf("ParseData() unexpected error = %v", err)
+			}
+			if got != want {
+				t.Errorf("ParseData() = %v, want %v", got, want)
+			}
+			if crs != nil {
+				t.Errorf("ParseData() crs = %v, want nil", crs)
+			}
+		})
+	}
+
+	t.Run("xml files", func(t *testing.T) {
+		// only .shp.xml files are shapefile metadata, and other .xml files are sniffed
+		data := buildZip(t, []archiveMember{
+			{"places.shp.xml", []byte(`<metadata><gpx><wpt lat="80" lon="80"/></gpx></metadata>`)},
+			{"track.xml", []byte(`<gpx version="1.1"><wpt lat="5" lon="-2"/></gpx>`)},
+		})
+		got, _, err := ParseZip(data, ReadOptions{})
+		if want := (core.Bbox{Left: -2, Bottom: 5, Right: -2, Top: 5}); err != nil || got != want {
+			t.Errorf("ParseZip() = %v, %v, want %v", got, err, want)
+		}
+	})
+}
+
+func TestParseZipShapefile(t *testing.T) {
+	shp, err := os.ReadFile(campsitesShp)
+	if err != nil {
+		t.Skipf("Skipping zipped shapefile test: %v", err)
+	}
+	prj, err := os.ReadFile(campsitesPrj)
+	if err != nil {
+		t.Skipf("Skipping zipped shapefile test: %v", err)
+	}
+	want, err := ParseShapefile(bytes.NewReader(shp), ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("shapefile with prj", func(t *testing.T) {
+		data := buildZip(t, []archiveMember{
+			{"campsites/Wilderness_Campsites.shp", shp},
+			{"campsites/Wilderness_Campsites.PRJ", prj},
+			// the .shx has the same header as the .shp, but shouldn't be read
+			{"campsites/Wilderness_Campsites.shx", shp[:shpHeaderSize]},
+			{"__MACOSX/campsites/._Wilderness_Campsites.shp", []byte("resource fork")},
+		})
+		got, crs, err := ParseZip(data, ReadOptions{})
+		if err != nil {
+			t.Fatalf("ParseZip() unexpected error = %v", err)
+		}
+		if got != want {
+			t.Errorf("ParseZip() = %v, want %v", got, want)
+		}
+		if crs == nil || crs.Code != 26915 {
+			t.Errorf("ParseZip() crs = %v, want EPSG:26915", crs)
+		}
+	})
+
+	t.Run("members in different CRSs", func(t *testing.T) {
+		data := buildZip(t, []archiveMember{
+			{"Wilderness_Campsites.shp", shp},
+			{"Wilderness_Campsites.prj", prj},
+			// a point in Minnesota, inside the campsites
+			{"point.geojson", []byte(`{"type": "Feature", "crs": {"type": "name", "properties": {"name": "EPSG:4326"}},
+				"geometry": {"type": "Point", "coordinates": [-91, 48]}}`)},
+		})
+		got, crs, err := ParseZip(data, ReadOptions{})
+		if err != nil {
+			t.Fatalf("ParseZip() unexpected error = %v", err)
+		}
+		if got != want {
+			t.Errorf("ParseZip() = %v, want %v", got, want)
+		}
+		if crs == nil || crs.Code != 26915 {
+			t.Errorf("ParseZip() crs = %v, want EPSG:26915", crs)
+		}
+	})
+
+	t.Run("prj after the shapefile in a tar", func(t *testing.T) {
+		data := buildTarGz(t, []archiveMember{
+			{"data/Wilderness_Campsites.shp", shp},
+			{"data/notes.txt", bytes.Repeat([]byte("not geodata "), 2000)},
+			{"data/Wilderness_Campsites.prj", prj},
+		})
+		got, crs, err := ParseData(bytes.NewReader(data), ReadOptions{})
+		if err != nil {
+			t.Fatalf("ParseData() unexpected error = %v", err)
+		}
+		if got != want {
+			t.Errorf("ParseData() = %v, want %v", got, want)
+		}
+		if crs == nil || crs.Code != 26915 {
+			t.Errorf("ParseData() crs = %v, want EPSG:26915", crs)
+		}
+	})
+
+	t.Run("zip file on disk", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "campsites.zip")
+		data := buildZip(t, []archiveMember{{"Wilderness_Campsites.shp", shp}})
+		if err := os.WriteFile(filename, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		got, _, err := LoadFile(filename, ReadOptions{})
+		if err != nil {
+			t.Fatalf("LoadFile() unexpected error = %v", err)
+		}
+		if got != want {
+			t.Errorf("LoadFile() = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestParseArchiveErrors(t *testing.T) {
+	t.Run("no supported members", func(t *testing.T) {
+		data := buildZip(t, []archiveMember{{"README.md", []byte("hello")}})
+		_, _, err := ParseData(bytes.NewReader(data), ReadOptions{})
+		if !errors.Is(err, ErrNoSupportedArchiveMembers) {
+			t.Errorf("ParseData() error = %v, want %v", err, ErrNoSupportedArchiveMembers)
+		}
+	})
+
+	t.Run("members without features", func(t *testing.T) {
+		data := buildTarGz(t, []archiveMember{{"empty.geojson", []byte(`{"type": "FeatureCollection", "features": []}`)}})
+		_, _, err := ParseData(bytes.NewReader(data), ReadOptions{})
+		if !errors.Is(err, ErrNoFeaturesFound) {
+			t.Errorf("ParseData() error = %v, want %v", err, ErrNoFeaturesFound)
+		}
+	})
+
+	t.Run("invalid member", func(t *testing.T) {
+		data := buildZip(t, []archiveMember{{"bad.geojson", []byte(`{"type": "Feature", "geometry": "nope"}`)}})
+		_, _, err := ParseData(bytes.NewReader(data), ReadOptions{})
+		if err == nil {
+			t.Errorf("ParseData() expected error for invalid member")
+		}
+	})
+}
@@ -0,0 +1,287 @@
+package input
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/mikeocool/bbox/core"
+	"github.com/mikeocool/bbox/proj"
+)
+
+// csvDelimiterChars are the delimiters that are detected in CSV headers
+const csvDelimiterChars = ",\t;|"
+
+// csvWhitespace is the delimiter of files whose header doesn't have any of csvDelimiterChars, but
+// has fields separated by spaces, like "x y". Runs of spaces are one delimiter in them.
+const csvWhitespace = " "
+
+// Column names that are detected, in order of preference
+var (
+	csvXColumns        = []string{"longitude", "lon", "lng", "long", "x"}
+	csvYColumns        = []string{"latitude", "lat", "y"}
+	csvGeometryColumns = []string{"wkt", "geometry", "geom", "the_geom", "wkb_geometry"}
+)
+
+// csvColumns are the indexes of the columns with coordinates, -1 if they aren't used
+type csvColumns struct {
+	x, y, geometry int
+}
+
+// ParseCsv reads the bounds of the points in a CSV with a header row. The coordinates come from
+// opts.XColumn and opts.YColumn, or longitude and latitude, x and y or WKT geometry columns that
+// are detected from the header. The delimiters are detected from the header too, and can be
+// commas, tabs, semicolons, pipes or a mix of them, or otherwise spaces. Rows with empty
+// coordinates are skipped.
+func ParseCsv(r io.Reader, opts ReadOptions) (core.Bbox, *proj.CRS, error) {
+	br := bufio.NewReader(r)
+	headerLine, err := readCsvHeaderLine(br)
+	if err != nil {
+		return core.Bbox{}, nil, err
+	}
+	reader := &csvReader{r: br, delimiters: csvDelimiters(headerLine), line: 1}
+	header, err := (&csvReader{r: bufio.NewReader(strings.NewReader(headerLine)), delimiters: reader.delimiters}).read()
+	if err != nil {
+		return core.Bbox{}, nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+	columns, err := findCsvColumns(header, opts)
+	if err != nil {
+		return core.Bbox{}, nil, err
+	}
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	visit := func(x, y float64) {
+		updateBounds(&minX, &minY, &maxX, &maxY, x, y)
+	}
+	for {
+		record, err := reader.read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return core.Bbox{}, nil, err
+		}
+		if err := csvRecordVertices(record, header, columns, visit); err != nil {
+			return core.Bbox{}, nil, fmt.Errorf("CSV line %d: %w", reader.line, err)
+		}
+	}
+
+	if minX > maxX {
+		return core.Bbox{}, nil, ErrNoFeaturesFound
+	}
+	return core.Bbox{Left: minX, Bottom: minY, Right: maxX, Top: maxY}, nil, nil
+}
+
+// looksLikeCsv reports whether the first line of the data is a CSV header with coordinate
+// columns, or the columns in opts
+func looksLikeCsv(data []byte, opts ReadOptions) bool {
+	if opts.XColumn != "" || opts.YColumn != "" {
+		return true
+	}
+	headerLine, err := readCsvHeaderLine(bufio.NewReader(strings.NewReader(string(data))))
+	if err != nil {
+		return false
+	}
+	header, err := (&csvReader{r: bufio.NewReader(strings.NewReader(headerLine)), delimiters: csvDelimiters(headerLine)}).read()
+	if err != nil {
+		return false
+	}
+	_, err = findCsvColumns(header, opts)
+	return err == nil
+}
+
+// readCsvHeaderLine returns the first line that isn't blank, without a byte order mark
+func readCsvHeaderLine(br *bufio.Reader) (string, error) {
+	for {
+		line, err := br.ReadString('\n')
+		if strings.TrimSpace(line) != "" {
+			return strings.TrimPrefix(line, "\ufeff"), nil
+		}
+		if err == io.EOF {
+			return "", errors.New("invalid CSV, missing header")
+		} else if err != nil {
+			return "", fmt.Errorf("failed to read CSV: %w", err)
+		}
+	}
+}
+
+// csvDelimiters returns the delimiters in the header that aren't quoted. If there aren't any, the
+// fields are separated by spaces if it has some, otherwise the file only has one column and the
+// delimiter is a comma.
+func csvDelimiters(header string) string {
+	var delimiters strings.Builder
+	quoted, spaces := false, false
+	for _, c := range strings.TrimSpace(header) {
+		if c == '"' {
+			quoted = !quoted
+		} else if !quoted && c == ' ' {
+			spaces = true
+		} else if !quoted && strings.ContainsRune(csvDelimiterChars, c) && !strings.ContainsRune(delimiters.String(), c) {
+			delimiters.WriteRune(c)
+		}
+	}
+	switch {
+	case delimiters.Len() > 0:
+		return delimiters.String()
+	case spaces:
+		return csvWhitespace
+	default:
+		return ","
+	}
+}
+
+// findCsvColumns returns the columns with the coordinates
+func findCsvColumns(header []string, opts ReadOptions) (csvColumns, error) {
+	find := func(names ...string) int {
+		for _, name := range names {
+			for i, column := range header {
+				if strings.EqualFold(column, name) {
+					return i
+				}
+			}
+		}
+		return -1
+	}
+
+	if opts.XColumn != "" || opts.YColumn != "" {
+		columns := csvColumns{x: find(opts.XColumn), y: find(opts.YColumn), geometry: -1}
+		for _, c := range []struct {
+			name  string
+			index int
+		}{{opts.XColumn, columns.x}, {opts.YColumn, columns.y}} {
+			if c.index < 0 {
+				return csvColumns{}, fmt.Errorf("column %q not found in CSV header, it has: %s", c.name, strings.Join(header, ", "))
+			}
+		}
+		return columns, nil
+	}
+
+	columns := csvColumns{x: find(csvXColumns...), y: find(csvYColumns...), geometry: -1}
+	if columns.x >= 0 && columns.y >= 0 {
+		return columns, nil
+	}
+	if geometry := find(csvGeometryColumns...); geometry >= 0 {
+		return csvColumns{x: -1, y: -1, geometry: geometry}, nil
+	}
+	return csvColumns{}, fmt.Errorf("CSV header does not have longitude and latitude, x and y or geometry columns, it has: %s", strings.Join(header, ", "))
+}
+
+// csvRecordVertices calls visit with the coordinates of a row
+func csvRecordVertices(record, header []string, columns csvColumns, visit func(x, y float64)) error {
+	value := func(index int) string {
+		if index < len(record) {
+			return record[index]
+		}
+		return ""
+	}
+
+	if columns.geometry >= 0 {
+		geometry := value(columns.geometry)
+		if geometry == "" {
+			return nil
+		}
+		// geometry columns exported from PostGIS are hex WKB
+		if data, err := hex.DecodeString(geometry); err == nil {
+			return wkbVertices(data, visit)
+		}
+		return wktVertices(geometry, visit)
+	}
+
+	xValue, yValue := value(columns.x), value(columns.y)
+	if xValue == "" || yValue == "" {
+		return nil
+	}
+	x, err := strconv.ParseFloat(xValue, 64)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q", header[columns.x], xValue)
+	}
+	y, err := strconv.ParseFloat(yValue, 64)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q", header[columns.y], yValue)
+	}
+	visit(x, y)
+	return nil
+}
+
+// csvReader reads CSV records, with fields that are split on any of the delimiters, or on runs of
+// spaces if the delimiters are csvWhitespace. Quoted fields can contain delimiters, newlines and
+// quotes escaped by doubling them.
+type csvReader struct {
+	r          *bufio.Reader
+	delimiters string
+	// line is the number of the last line read
+	line int
+}
+
+// read returns the next record that isn't blank, with the space around each field trimmed,
+// or io.EOF
+func (c *csvReader) read() ([]string, error) {
+	var record []string
+	var field strings.Builder
+	quoted := false
+	whitespace := c.delimiters == csvWhitespace
+	// started is whether the field has any characters or quotes, for whitespace delimiters which
+	// don't have empty fields
+	started := false
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		if line == "" {
+			if quoted {
+				return nil, fmt.Errorf("CSV line %d: unterminated quoted field", c.line)
+			}
+			return nil, io.EOF
+		}
+		c.line++
+		if !quoted && strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+		for i := 0; i < len(line); i++ {
+			ch := line[i]
+			switch {
+			case quoted && ch == '"':
+				if i+1 < len(line) && line[i+1] == '"' {
+					field.WriteByte('"')
+					i++
+				} else {
+					quoted = false
+				}
+			case quoted:
+				field.WriteByte(ch)
+			case whitespace && (ch == ' ' || ch == '\t'):
+				if started {
+					record = append(record, field.String())
+					field.Reset()
+					started = false
+				}
+			case ch == '"' && strings.TrimSpace(field.String()) == "":
+				field.Reset()
+				quoted, started = true, true
+			case strings.IndexByte(c.delimiters, ch) >= 0:
+				record = append(record, strings.TrimSpace(field.String()))
+				field.Reset()
+			default:
+				field.WriteByte(ch)
+				started = true
+			}
+		}
+		if quoted {
+			// the quoted field continues on the next line
+			field.WriteByte('\n')
+			continue
+		}
+		if whitespace && !started {
+			return record, nil
+		}
+		return append(record, strings.TrimSpace(field.String())), nil
+	}
+}
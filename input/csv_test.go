@@ -0,0 +1,151 @@
+package input
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mikeocool/bbox/core"
+)
+
+func TestParseCsv(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		opts     ReadOptions
+		want     core.Bbox
+		errorMsg string
+	}{
+		{
+			name:  "lat and lon columns",
+			input: "name,lat,lon\nLittle Sag,48.1,-90.9\nSeagull,48.2,-90.8\n",
+			want:  core.Bbox{Left: -90.9, Bottom: 48.1, Right: -90.8, Top: 48.2},
+		},
+		{
+			name:  "longitude is preferred to x",
+			input: "X,Y,Longitude,Latitude\r\n500000,5300000,-93,47.8\r\n500100,5300100,-92.9,47.9\r\n",
+			want:  core.Bbox{Left: -93, Bottom: 47.8, Right: -92.9, Top: 47.9},
+		},
+		{
+			name:  "tabs with a byte order mark",
+			input: "\ufeffid\tlng\tlat\n1\t-90.9\t48.1\n",
+			want:  core.Bbox{Left: -90.9, Bottom: 48.1, Right: -90.9, Top: 48.1},
+		},
+		{
+			name:  "quoted fields with delimiters, quotes and newlines",
+			input: "\"name\",\"lon\",\"lat\"\n\"Sag, \"\"Little\"\"\nLake\",\"-90.9\",\"48.1\"\n\"Seagull\", -90.8 , 48.2\n",
+			want:  core.Bbox{Left: -90.9, Bottom: 48.1, Right: -90.8, Top: 48.2},
+		},
+		{
+			name:  "mixed delimiters",
+			input: "name;lon,lat\nLittle Sag;-90.9,48.1\nSeagull,-90.8;48.2\n",
+			want:  core.Bbox{Left: -90.9, Bottom: 48.1, Right: -90.8, Top: 48.2},
+		},
+		{
+			name:  "spaces",
+			input: "x y\n1 2\n  3   4 \n",
+			want:  core.Bbox{Left: 1, Bottom: 2, Right: 3, Top: 4},
+		},
+		{
+			name:  "spaces with quoted fields",
+			input: "\"place name\"  lon  lat\n\"Little Sag\" -90.9 48.1\n\"\" -90.8 48.2\n",
+			want:  core.Bbox{Left: -90.9, Bottom: 48.1, Right: -90.8, Top: 48.2},
+		},
+		{
+			name:  "rows with empty coordinates are skipped",
+			input: "name,lon,lat\nLittle Sag,-90.9,48.1\nunknown,,\n\nshort\n",
+			want:  core.Bbox{Left: -90.9, Bottom: 48.1, Right: -90.9, Top: 48.1},
+		},
+		{
+			name:  "WKT column",
+			input: "id\tWKT\n1\tLINESTRING (-90.9 48.1, -90.8 48.2)\n2\tPOINT EMPTY\n3\tSRID=4326;POINT (-91 48)\n",
+			want:  core.Bbox{Left: -91, Bottom: 48, Right: -90.8, Top: 48.2},
+		},
+		{
+			name:  "hex WKB geometry column",
+			input: "id,geometry\n1,0101000000000000000000f03f0000000000000040\n",
+			want:  core.Bbox{Left: 1, Bottom: 2, Right: 1, Top: 2},
+		},
+		{
+			name:  "column options",
+			input: "site|easting|northing\na|500000|5300000\nb|500100|5299000\n",
+			opts:  ReadOptions{XColumn: "Easting", YColumn: "northing"},
+			want:  core.Bbox{Left: 500000, Bottom: 5299000, Right: 500100, Top: 5300000},
+		},
+		{
+			name:     "missing column option",
+			input:    "site,lon,lat\na,1,2\n",
+			opts:     ReadOptions{XColumn: "easting", YColumn: "lat"},
+			errorMsg: `column "easting" not found in CSV header, it has: site, lon, lat`,
+		},
+		{
+			name:     "no coordinate columns",
+			input:    "site,elevation\na,1\n",
+			errorMsg: "CSV header does not have longitude and latitude, x and y or geometry columns",
+		},
+		{
+			name:     "invalid coordinate",
+			input:    "lat,lon\n48.1,-90.9\nN/A,-90.8\n",
+			errorMsg: `CSV line 3: invalid lat "N/A"`,
+		},
+		{
+			name:     "unterminated quote",
+			input:    "lat,lon\n\"48.1,-90.9\n",
+			errorMsg: "unterminated quoted field",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, crs, err := ParseCsv(strings.NewReader(tt.input), tt.opts)
+			if tt.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("ParseCsv() error = %v, want it to contain %q", err, tt.errorMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseCsv() unexpected error = %v", err)
+			}
+			if got != tt.want || crs != nil {
+				t.Errorf("ParseCsv() = %v, %v, want %v", got, crs, tt.want)
+			}
+		})
+	}
+
+	t.Run("only a header", func(t *testing.T) {
+		_, _, err := ParseCsv(strings.NewReader("lat,lon\n"), ReadOptions{})
+		if !errors.Is(err, ErrNoFeaturesFound) {
+			t.Errorf("ParseCsv() error = %v, want ErrNoFeaturesFound", err)
+		}
+	})
+}
+
+func TestParseRawCsv(t *testing.T) {
+	want := core.Bbox{Left: -90.9, Bottom: 48.1, Right: -90.8, Top: 48.2}
+	got, _, err := ParseRaw([]byte("name,latitude,longitude\na,48.1,-90.9\nb,48.2,-90.8\n"), ReadOptions{})
+	if err != nil || got != want {
+		t.Errorf("ParseRaw() = %v, %v, want %v", got, err, want)
+	}
+
+	// lines of numbers aren't CSV
+	got, _, err = ParseRaw([]byte("1,2\n3,4\n"), ReadOptions{})
+	if err != nil || got != (core.Bbox{Left: 1, Bottom: 2, Right: 3, Top: 4}) {
+		t.Errorf("ParseRaw() = %v, %v", got, err)
+	}
+
+	got, _, err = ParseRaw([]byte("lon lat\n-90.9 48.1\n-90.8 48.2\n"), ReadOptions{})
+	if err != nil || got != want {
+		t.Errorf("ParseRaw() = %v, %v, want %v", got, err, want)
+	}
+
+	if !looksLikeCsv([]byte("a,b\n1,2\n"), ReadOptions{XColumn: "a", YColumn: "b"}) {
+		t.Errorf("looksLikeCsv() = false with column options")
+	}
+
+	got, _, err = ParseData(bytes.NewReader([]byte("lat,lon\n1,2\n")), ReadOptions{})
+	if !errors.Is(err, ErrUnrecognizedDataFormat) {
+		t.Errorf("ParseData() = %v, %v, CSV should only be detected in raw input", got, err)
+	}
+}
@@ -2,74 +2,310 @@ package input
 
 import (
 	"bytes"
+	"compress/gzip"
 	"errors"
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
 	"strings"
 
 	"github.com/mikeocool/bbox/core"
+	"github.com/mikeocool/bbox/proj"
 )
 
-func LoadFile(filename string) (core.Bbox, error) {
-	ext := strings.ToLower(filepath.Ext(filename))
+// ReadOptions controls how the bounds are read from files and other data
+type ReadOptions struct {
+	// ScanGeometries computes the bounds from every geometry, rather than trusting
+	// the extent stored in the file's header
+	ScanGeometries bool
+	// GpxTracksOnly restricts GPX bounds to track points
+	GpxTracksOnly bool
+	// GpxWaypointsOnly restricts GPX bounds to waypoints
+	GpxWaypointsOnly bool
+	// Layer restricts the bounds to one layer of formats that have several, like GeoPackage, or to
+	// one object of a TopoJSON topology
+	Layer string
+	// XColumn and YColumn are the CSV columns with the coordinates, rather than detecting them
+	XColumn string
+	YColumn string
+	// TrustBbox uses the bbox member of GeoJSON documents, rather than reading every coordinate
+	TrustBbox bool
+	// Verbose logs details of the files that aren't part of the bounds, like the zoom levels of tile archives
+	Verbose bool
+}
+
+// fileFormat is a format that's recognized by its extension, in both files and archive members.
+// Each has either stream, for the formats that read their data from start to end, or readAt, for
+// the ones that read from anywhere in it.
+type fileFormat struct {
+	// extensions are matched against the end of the name, so they can have several parts
+	extensions []string
+	stream     func(r io.Reader, opts ReadOptions) (core.Bbox, *proj.CRS, error)
+	readAt     func(r io.ReaderAt, size int64, opts ReadOptions) (core.Bbox, *proj.CRS, error)
+	// prjSidecar is set for shapefiles, which have their CRS in a .prj next to them
+	prjSidecar bool
+	// sqlite is set for SQLite databases, which can have changes in a journal or WAL next to them
+	sqlite bool
+}
+
+// parse reads the bounds from data that can only be streamed, like an archive member. It's read
+// into memory first for the formats that read from anywhere in it.
+func (f *fileFormat) parse(r io.Reader, opts ReadOptions) (core.Bbox, *proj.CRS, error) {
+	if f.stream != nil {
+		return f.stream(r, opts)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return core.Bbox{}, nil, fmt.Errorf("failed to read data: %w", err)
+	}
+	return f.readAt(bytes.NewReader(data), int64(len(data)), opts)
+}
+
+// fileFormats are the formats with a known extension. Other files are detected from their contents.
+var fileFormats = []fileFormat{
+	{extensions: []string{".shp"}, prjSidecar: true, stream: func(r io.Reader, opts ReadOptions) (core.Bbox, *proj.CRS, error) {
+		box, err := ParseShapefile(r, opts)
+		return box, nil, err
+	}},
+	{extensions: []string{".geojson", ".json", ".topojson"}, stream: ParseGeojson},
+	{extensions: []string{".geojsonl", ".geojsons", ".geojsonseq", ".ndjson", ".jsonl"}, stream: ParseGeojsonSeq},
+	{extensions: []string{".kml"}, stream: func(r io.Reader, _ ReadOptions) (core.Bbox, *proj.CRS, error) {
+		return ParseKml(r)
+	}},
+	{extensions: []string{".gpx"}, stream: ParseGpx},
+	{extensions: []string{".osm"}, stream: ParseOsm},
+	// other .pbf files, like vector tiles, are detected from their contents
+	{extensions: []string{".osm.pbf"}, stream: ParseOsmPbf},
+	{extensions: []string{".parquet", ".geoparquet"}, readAt: ParseGeoparquet},
+	{extensions: []string{".fgb"}, stream: ParseFlatgeobuf},
+	{extensions: []string{".gpkg"}, sqlite: true, readAt: ParseGeopackage},
+	{extensions: []string{".tif", ".tiff"}, readAt: func(r io.ReaderAt, size int64, _ ReadOptions) (core.Bbox, *proj.CRS, error) {
+		return ParseGeotiff(r, size)
+	}},
+	{extensions: []string{".las", ".laz"}, stream: func(r io.Reader, _ ReadOptions) (core.Bbox, *proj.CRS, error) {
+		return ParseLas(r)
+	}},
+	{extensions: []string{".csv", ".tsv"}, stream: ParseCsv},
+	{extensions: []string{".wkt"}, stream: func(r io.Reader, _ ReadOptions) (core.Bbox, *proj.CRS, error) {
+		return ParseWkt(r)
+	}},
+	{extensions: []string{".pmtiles"}, stream: ParsePmtiles},
+	{extensions: []string{".mbtiles"}, sqlite: true, readAt: ParseMbtiles},
+}
+
+// fileFormatFor returns the format of a file from its extension, or nil if it isn't known
+func fileFormatFor(name string) *fileFormat {
+	name = strings.ToLower(name)
+	for i, format := range fileFormats {
+		for _, ext := range format.extensions {
+			if strings.HasSuffix(name, ext) {
+				return &fileFormats[i]
+			}
+		}
+	}
+	return nil
+}
+
+// LoadFile reads the bounds of a file, and its CRS if it can be detected
+func LoadFile(filename string, opts ReadOptions) (core.Bbox, *proj.CRS, error) {
+	format := fileFormatFor(filename)
+	if format == nil {
+		// includes archives and KMZ, which are detected from their contents
+		return ParseFileData(filename, opts)
+	}
+	if format.sqlite {
+		if err := checkSqliteJournal(filename); err != nil {
+			return core.Bbox{}, nil, err
+		}
+	}
 
-	switch ext {
-	// TODO support shapefiles from zip
-	case ".shp":
-		return LoadShapefile(filename)
-	case ".geojson", ".json":
-		return LoadGeojsonFile(filename)
-	default:
-		return ParseFileData(filename)
+	file, err := os.Open(filename)
+	if err != nil {
+		return core.Bbox{}, nil, err
+	}
+	defer file.Close()
+	var box core.Bbox
+	var crs *proj.CRS
+	if format.readAt != nil {
+		info, err := file.Stat()
+		if err != nil {
+			return core.Bbox{}, nil, err
+		}
+		box, crs, err = format.readAt(file, info.Size(), opts)
+	} else {
+		box, crs, err = format.stream(file, opts)
 	}
+	if err != nil {
+		return core.Bbox{}, nil, err
+	}
+	if format.prjSidecar {
+		crs = loadPrj(filename)
+	}
+	return box, crs, nil
 }
 
-func ParseFileData(filename string) (core.Bbox, error) {
+func ParseFileData(filename string, opts ReadOptions) (core.Bbox, *proj.CRS, error) {
 	file, err := os.Open(filename)
 	if err != nil {
-		return core.Bbox{}, err
+		return core.Bbox{}, nil, err
 	}
 	defer file.Close()
-	return ParseData(file)
+	return ParseData(file, opts)
 }
 
 var ErrUnrecognizedDataFormat = fmt.Errorf("Input does not appear to be a valid format")
 
-// Attempt to auto-detect the format and parse the data
-func ParseData(r io.Reader) (core.Bbox, error) {
+// Attempt to auto-detect the format and parse the data. The CRS is nil if the data doesn't specify one.
+func ParseData(r io.Reader, opts ReadOptions) (core.Bbox, *proj.CRS, error) {
 	var buf bytes.Buffer
 	// as we read through the original reader, copy the bytes to the buffer
 	teeReader := io.TeeReader(r, &buf)
 
 	detectionBuf := make([]byte, 8192)
-	_, err := teeReader.Read(detectionBuf)
-	if err != nil && err != io.EOF {
-		return core.Bbox{}, fmt.Errorf("failed to read data: %w", err)
+	n, err := io.ReadFull(teeReader, detectionBuf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return core.Bbox{}, nil, fmt.Errorf("failed to read data: %w", err)
 	}
+	detectionBuf = detectionBuf[:n]
 
 	// reader that contains the detection buffer and the rest of the reader
 	fullReader := io.MultiReader(&buf, r)
 
+	if SniffTopojson(detectionBuf) {
+		// TopoJSON is read with GeoJSON, but isn't any of the other formats if it fails
+		return ParseGeojson(fullReader, opts)
+	}
+
+	if SniffGeojsonSeq(detectionBuf) {
+		return ParseGeojsonSeq(fullReader, opts)
+	}
+
 	if SniffGeojson(detectionBuf) {
-		box, err := ParseGeojson(fullReader)
+		box, crs, err := ParseGeojson(fullReader, opts)
 		if err == nil {
-			return box, nil
-		} else if errors.Is(ErrNoFeaturesFound, err) {
+			return box, crs, nil
+		} else if errors.Is(err, ErrNoFeaturesFound) {
 			// sucessfully parsed geojson but found not features
-			return core.Bbox{}, err
+			return core.Bbox{}, nil, err
 		}
 	}
 
-	if SniffShapefile(detectionBuf) {
-		box, err := ParseShapefile(fullReader)
+	// the other formats are still tried if the data isn't valid WKT, since WKT is only detected
+	// from a geometry type at the start
+	var wktErr error
+	if SniffWkt(detectionBuf) {
+		data, err := io.ReadAll(fullReader)
+		if err != nil {
+			return core.Bbox{}, nil, fmt.Errorf("failed to read data: %w", err)
+		}
+		box, crs, err := ParseWkt(bytes.NewReader(data))
 		if err == nil {
-			return box, nil
-		} else {
-			fmt.Printf("Error parsing shapefile: %s", err)
+			return box, crs, nil
+		}
+		wktErr = err
+		fullReader = bytes.NewReader(data)
+	}
+
+	if SniffShapefile(detectionBuf) {
+		// the shapefile has been partly read, so no other format can be tried
+		box, err := ParseShapefile(fullReader, opts)
+		return box, nil, err
+	}
+
+	if SniffFlatgeobuf(detectionBuf) {
+		return ParseFlatgeobuf(fullReader, opts)
+	}
+
+	if SniffPmtiles(detectionBuf) {
+		return ParsePmtiles(fullReader, opts)
+	}
+
+	if SniffLas(detectionBuf) {
+		return ParseLas(fullReader)
+	}
+
+	if SniffKml(detectionBuf) {
+		return ParseKml(fullReader)
+	}
+
+	if SniffGpx(detectionBuf) {
+		return ParseGpx(fullReader, opts)
+	}
+
+	if SniffOsm(detectionBuf) {
+		return ParseOsm(fullReader, opts)
+	}
+
+	if SniffOsmPbf(detectionBuf) {
+		return ParseOsmPbf(fullReader, opts)
+	}
+
+	if SniffParquet(detectionBuf) {
+		// parquet's metadata is at the end of the file, so it needs to be read into memory
+		data, err := io.ReadAll(fullReader)
+		if err != nil {
+			return core.Bbox{}, nil, fmt.Errorf("failed to read data: %w", err)
+		}
+		return ParseGeoparquet(bytes.NewReader(data), int64(len(data)), opts)
+	}
+
+	if SniffGeopackage(detectionBuf) {
+		// SQLite is read a page at a time from anywhere in the file, so it needs to be read into memory
+		data, err := io.ReadAll(fullReader)
+		if err != nil {
+			return core.Bbox{}, nil, fmt.Errorf("failed to read data: %w", err)
+		}
+		return ParseGeopackage(bytes.NewReader(data), int64(len(data)), opts)
+	}
+
+	if SniffMbtiles(detectionBuf) {
+		// SQLite is read a page at a time from anywhere in the file, so it needs to be read into memory
+		data, err := io.ReadAll(fullReader)
+		if err != nil {
+			return core.Bbox{}, nil, fmt.Errorf("failed to read data: %w", err)
+		}
+		return ParseMbtiles(bytes.NewReader(data), int64(len(data)), opts)
+	}
+
+	if SniffTiff(detectionBuf) {
+		// TIFF tags can be anywhere in the file, so it needs to be read into memory
+		data, err := io.ReadAll(fullReader)
+		if err != nil {
+			return core.Bbox{}, nil, fmt.Errorf("failed to read data: %w", err)
+		}
+		return ParseGeotiff(bytes.NewReader(data), int64(len(data)))
+	}
+
+	if SniffZip(detectionBuf) {
+		// zip's directory is at the end of the file, so it needs to be read into memory
+		data, err := io.ReadAll(fullReader)
+		if err != nil {
+			return core.Bbox{}, nil, fmt.Errorf("failed to read data: %w", err)
 		}
+		return ParseZip(data, opts)
 	}
 
-	return core.Bbox{}, ErrUnrecognizedDataFormat
+	if SniffGzip(detectionBuf) {
+		gz, err := gzip.NewReader(fullReader)
+		if err != nil {
+			return core.Bbox{}, nil, fmt.Errorf("failed to read gzip data: %w", err)
+		}
+		defer gz.Close()
+		// the decompressed data could be a tar archive or any other format
+		return ParseData(gz, opts)
+	}
+
+	if SniffTar(detectionBuf) {
+		return ParseTar(fullReader, opts)
+	}
+
+	// WKB only has a byte order and geometry type to detect, so it's checked last
+	if SniffWkb(detectionBuf) {
+		return ParseWkb(fullReader)
+	}
+
+	if wktErr != nil {
+		return core.Bbox{}, nil, wktErr
+	}
+	return core.Bbox{}, nil, ErrUnrecognizedDataFormat
 }
@@ -3,6 +3,7 @@ package input
 import (
 	"bytes"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -144,7 +145,7 @@ func TestParseData(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			reader := strings.NewReader(tt.input)
-			got, err := ParseData(reader)
+			got, _, err := ParseData(reader, ReadOptions{})
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ParseData() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -168,7 +169,7 @@ func TestParseDataWithRealFiles(t *testing.T) {
 		}
 		defer file.Close()
 
-		got, err := ParseData(file)
+		got, _, err := ParseData(file, ReadOptions{})
 		if err != nil {
 			t.Errorf("ParseData() unexpected error = %v", err)
 			return
@@ -188,8 +189,7 @@ func TestParseDataWithRealFiles(t *testing.T) {
 		}
 		defer file.Close()
 
-		got, err := ParseData(file)
-		// ParseShapefile requires file length information that's not available from io.Reader
+		got, _, err := ParseData(file, ReadOptions{})
 		if err != nil {
 			t.Errorf("ParseData() unexpected error = %v", err)
 			return
@@ -214,7 +214,7 @@ func TestParseDataWithRealFiles(t *testing.T) {
 		}
 		defer file.Close()
 
-		_, err = ParseData(file)
+		_, _, err = ParseData(file, ReadOptions{})
 		if err == nil {
 			t.Errorf("ParseData() expected error for empty GeoJSON file, got nil")
 		}
@@ -225,7 +225,7 @@ func TestParseDataEdgeCases(t *testing.T) {
 	t.Run("Input smaller than detection buffer", func(t *testing.T) {
 		input := `{"type":"Feature","geometry":{"type":"Point","coordinates":[5,10]}}`
 
-		got, err := ParseData(strings.NewReader(input))
+		got, _, err := ParseData(strings.NewReader(input), ReadOptions{})
 		if err != nil {
 			t.Errorf("ParseData() unexpected error = %v", err)
 			return
@@ -256,7 +256,7 @@ func TestParseDataEdgeCases(t *testing.T) {
 			header[i] = 0xFF
 		}
 
-		_, err := ParseData(bytes.NewReader(header))
+		_, _, err := ParseData(bytes.NewReader(header), ReadOptions{})
 		if err == nil {
 			t.Errorf("ParseData() expected error for fake shapefile, got nil")
 		}
@@ -264,7 +264,7 @@ func TestParseDataEdgeCases(t *testing.T) {
 
 	t.Run("Reader that returns error on first read", func(t *testing.T) {
 		errorReader := &erroringReader{}
-		_, err := ParseData(errorReader)
+		_, _, err := ParseData(errorReader, ReadOptions{})
 		if err == nil {
 			t.Errorf("ParseData() expected error from failing reader, got nil")
 		}
@@ -276,7 +276,7 @@ func TestParseDataDetectionFallback(t *testing.T) {
 		// Create input that looks like GeoJSON but is malformed
 		input := `{"type": "FeatureCollection", "features": [{"invalid": "feature"}]}`
 
-		_, err := ParseData(strings.NewReader(input))
+		_, _, err := ParseData(strings.NewReader(input), ReadOptions{})
 		if err == nil {
 			t.Errorf("ParseData() expected error for malformed GeoJSON that can't fallback to shapefile")
 		}
@@ -285,7 +285,7 @@ func TestParseDataDetectionFallback(t *testing.T) {
 	t.Run("Both detection methods fail", func(t *testing.T) {
 		input := `This is definitely not a geo format`
 
-		_, err := ParseData(strings.NewReader(input))
+		_, _, err := ParseData(strings.NewReader(input), ReadOptions{})
 		if err == nil {
 			t.Errorf("ParseData() expected error when no format can be detected")
 		}
@@ -302,7 +302,7 @@ func TestParseDataWithDifferentReaderTypes(t *testing.T) {
 	want := core.Bbox{Left: 42, Bottom: 24, Right: 42, Top: 24}
 
 	t.Run("strings.Reader", func(t *testing.T) {
-		got, err := ParseData(strings.NewReader(geoJSON))
+		got, _, err := ParseData(strings.NewReader(geoJSON), ReadOptions{})
 		if err != nil {
 			t.Errorf("ParseData() unexpected error = %v", err)
 			return
@@ -313,7 +313,7 @@ func TestParseDataWithDifferentReaderTypes(t *testing.T) {
 	})
 
 	t.Run("bytes.Reader", func(t *testing.T) {
-		got, err := ParseData(bytes.NewReader([]byte(geoJSON)))
+		got, _, err := ParseData(bytes.NewReader([]byte(geoJSON)), ReadOptions{})
 		if err != nil {
 			t.Errorf("ParseData() unexpected error = %v", err)
 			return
@@ -325,7 +325,7 @@ func TestParseDataWithDifferentReaderTypes(t *testing.T) {
 
 	t.Run("bytes.Buffer", func(t *testing.T) {
 		buffer := bytes.NewBufferString(geoJSON)
-		got, err := ParseData(buffer)
+		got, _, err := ParseData(buffer, ReadOptions{})
 		if err != nil {
 			t.Errorf("ParseData() unexpected error = %v", err)
 			return
@@ -343,7 +343,7 @@ func TestParseDataDetectionBufferEdgeCases(t *testing.T) {
 		geoJSON := `{"type":"Feature","geometry":{"type":"Point","coordinates":[42,24]}}`
 		input := padding + geoJSON
 
-		got, err := ParseData(strings.NewReader(input))
+		got, _, err := ParseData(strings.NewReader(input), ReadOptions{})
 		if err != nil {
 			t.Errorf("ParseData() unexpected error = %v", err)
 			return
@@ -365,7 +365,7 @@ func TestParseDataDetectionBufferEdgeCases(t *testing.T) {
 		// Simple point that should work
 		input := `{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]}}`
 
-		got, err := ParseData(strings.NewReader(input))
+		got, _, err := ParseData(strings.NewReader(input), ReadOptions{})
 		if err != nil {
 			t.Errorf("ParseData() unexpected error = %v", err)
 			return
@@ -386,7 +386,7 @@ func TestParseDataDetectionBufferEdgeCases(t *testing.T) {
 			}
 		}`
 
-		got, err := ParseData(strings.NewReader(input))
+		got, _, err := ParseData(strings.NewReader(input), ReadOptions{})
 		if err != nil {
 			t.Errorf("ParseData() unexpected error = %v", err)
 			return
@@ -459,7 +459,7 @@ func TestParseDataComplexGeoJSON(t *testing.T) {
 			}
 		}`
 
-		got, err := ParseData(strings.NewReader(input))
+		got, _, err := ParseData(strings.NewReader(input), ReadOptions{})
 		if err != nil {
 			t.Errorf("ParseData() unexpected error = %v", err)
 			return
@@ -505,7 +505,7 @@ func TestParseDataComplexGeoJSON(t *testing.T) {
 			]
 		}`
 
-		got, err := ParseData(strings.NewReader(input))
+		got, _, err := ParseData(strings.NewReader(input), ReadOptions{})
 		if err != nil {
 			t.Errorf("ParseData() unexpected error = %v", err)
 			return
@@ -523,3 +523,66 @@ func TestParseDataComplexGeoJSON(t *testing.T) {
 		}
 	})
 }
+
+func TestLoadFileCrs(t *testing.T) {
+	const placesShp = "../integration_tests/data/ne_10m_populated_places_simple/ne_10m_populated_places_simple.shp"
+	shpData, err := os.ReadFile(placesShp)
+	if err != nil {
+		t.Skipf("Skipping real shapefile test: %v", err)
+	}
+
+	t.Run("Shapefile with ESRI geographic prj", func(t *testing.T) {
+		_, crs, err := LoadFile(placesShp, ReadOptions{})
+		if err != nil {
+			t.Fatalf("LoadFile() unexpected error = %v", err)
+		}
+		if crs == nil || crs.Code != 4326 {
+			t.Errorf("LoadFile() crs = %v, want EPSG:4326", crs)
+		}
+	})
+
+	t.Run("Shapefile with ESRI UTM prj", func(t *testing.T) {
+		_, crs, err := LoadFile("../integration_tests/data/campsites/Wilderness_Campsites.shp", ReadOptions{})
+		if err != nil {
+			t.Fatalf("LoadFile() unexpected error = %v", err)
+		}
+		if crs == nil || crs.Code != 26915 {
+			t.Errorf("LoadFile() crs = %v, want EPSG:26915", crs)
+		}
+	})
+
+	t.Run("Shapefile without prj", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "places.shp")
+		if err := os.WriteFile(filename, shpData, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, crs, err := LoadFile(filename, ReadOptions{})
+		if err != nil {
+			t.Fatalf("LoadFile() unexpected error = %v", err)
+		}
+		if crs != nil {
+			t.Errorf("LoadFile() crs = %v, want nil", crs)
+		}
+	})
+
+	t.Run("Shapefile with invalid prj", func(t *testing.T) {
+		dir := t.TempDir()
+		filename := filepath.Join(dir, "places.shp")
+		if err := os.WriteFile(filename, shpData, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "places.PRJ"), []byte("not a projection"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		got, crs, err := LoadFile(filename, ReadOptions{})
+		if err != nil {
+			t.Fatalf("LoadFile() unexpected error = %v", err)
+		}
+		if crs != nil {
+			t.Errorf("LoadFile() crs = %v, want nil", crs)
+		}
+		if got.Left != -179.5899789 {
+			t.Errorf("LoadFile() got unexpected bounds: %v", got)
+		}
+	})
+}
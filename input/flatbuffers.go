@@ -0,0 +1,168 @@
+package input
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+var errFlatbufferInvalid = errors.New("invalid flatbuffer")
+
+// flatbufferTable reads the fields of a flatbuffers table, which FlatGeobuf uses for its header and
+// features, without generated code. Every offset is checked against the buffer, so corrupt data
+// returns errFlatbufferInvalid rather than panicking.
+type flatbufferTable struct {
+	buf []byte
+	pos int
+}
+
+// flatbufferRoot returns the root table of a flatbuffer
+func flatbufferRoot(buf []byte) (flatbufferTable, error) {
+	return flatbufferTable{buf: buf}.indirect(0)
+}
+
+// indirect follows the unsigned offset at pos to a table
+func (t flatbufferTable) indirect(pos int) (flatbufferTable, error) {
+	offset, ok := t.uint32At(pos)
+	if !ok || uint64(pos)+uint64(offset) > uint64(len(t.buf)) {
+		return flatbufferTable{}, errFlatbufferInvalid
+	}
+	return flatbufferTable{buf: t.buf, pos: pos + int(offset)}, nil
+}
+
+func (t flatbufferTable) uint32At(pos int) (uint32, bool) {
+	if pos < 0 || pos+4 > len(t.buf) {
+		return 0, false
+	}
+	return binary.LittleEndian.Uint32(t.buf[pos:]), true
+}
+
+// field returns the position of a field's value, by its index in the schema, or false if the field
+// isn't set
+func (t flatbufferTable) field(index int) (int, bool, error) {
+	soffset, ok := t.uint32At(t.pos)
+	if !ok {
+		return 0, false, errFlatbufferInvalid
+	}
+	vtable := t.pos - int(int32(soffset))
+	if vtable < 0 || vtable+4 > len(t.buf) {
+		return 0, false, errFlatbufferInvalid
+	}
+	vtableSize := int(binary.LittleEndian.Uint16(t.buf[vtable:]))
+	entry := 4 + index*2
+	if entry+2 > vtableSize {
+		// fields added after the table was written
+		return 0, false, nil
+	}
+	if vtable+entry+2 > len(t.buf) {
+		return 0, false, errFlatbufferInvalid
+	}
+	offset := int(binary.LittleEndian.Uint16(t.buf[vtable+entry:]))
+	if offset == 0 {
+		return 0, false, nil
+	}
+	return t.pos + offset, true, nil
+}
+
+// uint16 returns a ushort field, or def if it isn't set
+func (t flatbufferTable) uint16(index int, def uint16) (uint16, error) {
+	pos, ok, err := t.field(index)
+	if err != nil || !ok {
+		return def, err
+	}
+	if pos+2 > len(t.buf) {
+		return 0, errFlatbufferInvalid
+	}
+	return binary.LittleEndian.Uint16(t.buf[pos:]), nil
+}
+
+// int32 returns an int field, or def if it isn't set
+func (t flatbufferTable) int32(index int, def int32) (int32, error) {
+	pos, ok, err := t.field(index)
+	if err != nil || !ok {
+		return def, err
+	}
+	v, ok := t.uint32At(pos)
+	if !ok {
+		return 0, errFlatbufferInvalid
+	}
+	return int32(v), nil
+}
+
+// uint64 returns a ulong field, or def if it isn't set
+func (t flatbufferTable) uint64(index int, def uint64) (uint64, error) {
+	pos, ok, err := t.field(index)
+	if err != nil || !ok {
+		return def, err
+	}
+	if pos+8 > len(t.buf) {
+		return 0, errFlatbufferInvalid
+	}
+	return binary.LittleEndian.Uint64(t.buf[pos:]), nil
+}
+
+// vector returns the position of the first element of a vector field and its length, which is 0
+// if the field isn't set. elemSize is used to check the elements are inside the buffer.
+func (t flatbufferTable) vector(index int, elemSize int) (int, int, error) {
+	pos, ok, err := t.field(index)
+	if err != nil || !ok {
+		return 0, 0, err
+	}
+	vector, err := t.indirect(pos)
+	if err != nil {
+		return 0, 0, err
+	}
+	length, ok := t.uint32At(vector.pos)
+	start := vector.pos + 4
+	if !ok || uint64(length)*uint64(elemSize) > uint64(len(t.buf)-start) {
+		return 0, 0, errFlatbufferInvalid
+	}
+	return start, int(length), nil
+}
+
+// float64s returns a [double] field
+func (t flatbufferTable) float64s(index int) ([]float64, error) {
+	start, length, err := t.vector(index, 8)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]float64, length)
+	for i := range values {
+		values[i] = math.Float64frombits(binary.LittleEndian.Uint64(t.buf[start+i*8:]))
+	}
+	return values, nil
+}
+
+// string returns a string field, or "" if it isn't set
+func (t flatbufferTable) string(index int) (string, error) {
+	start, length, err := t.vector(index, 1)
+	if err != nil {
+		return "", err
+	}
+	return string(t.buf[start : start+length]), nil
+}
+
+// table returns a table field, or false if it isn't set
+func (t flatbufferTable) table(index int) (flatbufferTable, bool, error) {
+	pos, ok, err := t.field(index)
+	if err != nil || !ok {
+		return flatbufferTable{}, false, err
+	}
+	table, err := t.indirect(pos)
+	return table, err == nil, err
+}
+
+// tables returns a vector of tables field
+func (t flatbufferTable) tables(index int) ([]flatbufferTable, error) {
+	start, length, err := t.vector(index, 4)
+	if err != nil {
+		return nil, err
+	}
+	tables := make([]flatbufferTable, length)
+	for i := range tables {
+		if tables[i], err = t.indirect(start + i*4); err != nil {
+			return nil, err
+		}
+	}
+	return tables, nil
+}
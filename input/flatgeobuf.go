@@ -0,0 +1,267 @@
+package input
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"strings"
+
+	"github.com/mikeocool/bbox/core"
+	"github.com/mikeocool/bbox/proj"
+)
+
+var fgbMagic = []byte("fgb\x03fgb")
+
+// fgbMaxSize limits the size of the header or a feature of a corrupt file
+const fgbMaxSize = 1 << 30
+
+// fgbNodeSize is the size of a packed R-tree node: minX, minY, maxX and maxY doubles and an offset
+const fgbNodeSize = 40
+
+// Fields of the FlatGeobuf header table
+const (
+	fgbHeaderEnvelope      = 1
+	fgbHeaderFeaturesCount = 8
+	fgbHeaderIndexNodeSize = 9
+	fgbHeaderCrs           = 10
+)
+
+// Fields of the FlatGeobuf Crs, Feature and Geometry tables
+const (
+	fgbCrsOrg          = 0
+	fgbCrsCode         = 1
+	fgbCrsName         = 2
+	fgbCrsWkt          = 4
+	fgbFeatureGeometry = 0
+	fgbGeometryXy      = 1
+	fgbGeometryParts   = 7
+)
+
+// SniffFlatgeobuf checks for the FlatGeobuf magic bytes, "fgb", the major version 3, "fgb" and the patch version
+func SniffFlatgeobuf(data []byte) bool {
+	return bytes.HasPrefix(data, fgbMagic) && len(data) >= 8
+}
+
+// ParseFlatgeobuf reads the bounds of a FlatGeobuf file from the envelope in its header, or if it
+// doesn't have one, from the root node of its spatial index. Files with neither, or when
+// opts.ScanGeometries is set, have the bounds computed from the features.
+func ParseFlatgeobuf(r io.Reader, opts ReadOptions) (core.Bbox, *proj.CRS, error) {
+	magic := make([]byte, 8)
+	if _, err := io.ReadFull(r, magic); err != nil || !SniffFlatgeobuf(magic) {
+		return core.Bbox{}, nil, errors.New("invalid FlatGeobuf file, missing magic bytes")
+	}
+
+	headerData, err := readFgbSizePrefixed(r)
+	if err != nil {
+		return core.Bbox{}, nil, fmt.Errorf("failed to read FlatGeobuf header: %w", err)
+	}
+	header, err := flatbufferRoot(headerData)
+	if err != nil {
+		return core.Bbox{}, nil, fmt.Errorf("invalid FlatGeobuf header: %w", err)
+	}
+
+	envelope, err := header.float64s(fgbHeaderEnvelope)
+	if err != nil {
+		return core.Bbox{}, nil, fmt.Errorf("invalid FlatGeobuf header: %w", err)
+	}
+	featuresCount, err := header.uint64(fgbHeaderFeaturesCount, 0)
+	if err != nil {
+		return core.Bbox{}, nil, fmt.Errorf("invalid FlatGeobuf header: %w", err)
+	}
+	indexNodeSize, err := header.uint16(fgbHeaderIndexNodeSize, 16)
+	if err != nil {
+		return core.Bbox{}, nil, fmt.Errorf("invalid FlatGeobuf header: %w", err)
+	}
+	crs, err := fgbCrs(header)
+	if err != nil {
+		return core.Bbox{}, nil, fmt.Errorf("invalid FlatGeobuf header: %w", err)
+	}
+
+	// the envelope can have more than 4 values for higher dimensions, but the first 4 are
+	// always minX, minY, maxX and maxY
+	if !opts.ScanGeometries && len(envelope) >= 4 && !boundsInvalid(envelope[0], envelope[1], envelope[2], envelope[3]) {
+		return core.Bbox{Left: envelope[0], Bottom: envelope[1], Right: envelope[2], Top: envelope[3]}, crs, nil
+	}
+
+	indexSize, err := fgbIndexSize(featuresCount, indexNodeSize)
+	if err != nil {
+		return core.Bbox{}, nil, err
+	}
+	if indexSize > 0 {
+		// the root node is first, and its box covers every feature
+		root := make([]byte, fgbNodeSize)
+		if _, err := io.ReadFull(r, root); err != nil {
+			return core.Bbox{}, nil, fmt.Errorf("failed to read FlatGeobuf index: %w", err)
+		}
+		var node [4]float64
+		for i := range node {
+			node[i] = math.Float64frombits(binary.LittleEndian.Uint64(root[i*8:]))
+		}
+		if !opts.ScanGeometries && !boundsInvalid(node[0], node[1], node[2], node[3]) {
+			return core.Bbox{Left: node[0], Bottom: node[1], Right: node[2], Top: node[3]}, crs, nil
+		}
+		if _, err := io.CopyN(io.Discard, r, int64(indexSize-fgbNodeSize)); err != nil {
+			return core.Bbox{}, nil, fmt.Errorf("failed to read FlatGeobuf index: %w", err)
+		}
+	}
+
+	box, err := scanFgbFeatures(r)
+	if err != nil {
+		return core.Bbox{}, nil, err
+	}
+	return box, crs, nil
+}
+
+// readFgbSizePrefixed reads a flatbuffer that's prefixed with its size, like the header and the features
+func readFgbSizePrefixed(r io.Reader) ([]byte, error) {
+	prefix := make([]byte, 4)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		return nil, err
+	}
+	size := binary.LittleEndian.Uint32(prefix)
+	if size > fgbMaxSize {
+		return nil, fmt.Errorf("size %d is too large", size)
+	}
+	// read without allocating the whole size up front, in case it's wrong
+	data, err := io.ReadAll(io.LimitReader(r, int64(size)))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < int(size) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return data, nil
+}
+
+// fgbIndexSize returns the size of the packed Hilbert R-tree that follows the header, which is
+// 0 if the file doesn't have one
+func fgbIndexSize(featuresCount uint64, nodeSize uint16) (uint64, error) {
+	if nodeSize == 0 || featuresCount == 0 {
+		return 0, nil
+	}
+	if featuresCount > fgbMaxSize {
+		return 0, fmt.Errorf("invalid FlatGeobuf feature count %d", featuresCount)
+	}
+	// like the reference implementation, the smallest node size is 2
+	nodeSize = max(nodeSize, 2)
+	n := featuresCount
+	numNodes := n
+	for {
+		n = (n + uint64(nodeSize) - 1) / uint64(nodeSize)
+		numNodes += n
+		if n == 1 {
+			break
+		}
+	}
+	return numNodes * fgbNodeSize, nil
+}
+
+// fgbCrs returns the CRS from the header, or nil if it doesn't have one
+func fgbCrs(header flatbufferTable) (*proj.CRS, error) {
+	crsTable, ok, err := header.table(fgbHeaderCrs)
+	if err != nil || !ok {
+		return nil, err
+	}
+	org, err := crsTable.string(fgbCrsOrg)
+	if err != nil {
+		return nil, err
+	}
+	code, err := crsTable.int32(fgbCrsCode, 0)
+	if err != nil {
+		return nil, err
+	}
+	name, err := crsTable.string(fgbCrsName)
+	if err != nil {
+		return nil, err
+	}
+	wkt, err := crsTable.string(fgbCrsWkt)
+	if err != nil {
+		return nil, err
+	}
+
+	// the organization defaults to EPSG
+	epsg := org == "" || strings.EqualFold(org, "EPSG")
+	if epsg && code != 0 {
+		if crs, err := proj.Lookup(int(code)); err == nil {
+			return crs, nil
+		}
+	}
+	if wkt != "" {
+		crs, err := proj.ParseWkt(wkt)
+		if err == nil {
+			return crs, nil
+		}
+		log.Printf("Could not parse FlatGeobuf CRS: %v\n", err)
+	}
+	if epsg && code != 0 {
+		return proj.Unsupported(int(code), name, false), nil
+	}
+	return nil, nil
+}
+
+// scanFgbFeatures computes the bounds from the geometries of the features that follow the header and index
+func scanFgbFeatures(r io.Reader) (core.Bbox, error) {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	visit := func(x, y float64) {
+		updateBounds(&minX, &minY, &maxX, &maxY, x, y)
+	}
+
+	for i := 0; ; i++ {
+		data, err := readFgbSizePrefixed(r)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return core.Bbox{}, fmt.Errorf("failed to read FlatGeobuf feature %d: %w", i, err)
+		}
+		feature, err := flatbufferRoot(data)
+		if err != nil {
+			return core.Bbox{}, fmt.Errorf("invalid FlatGeobuf feature %d: %w", i, err)
+		}
+		geometry, ok, err := feature.table(fgbFeatureGeometry)
+		if err == nil && ok {
+			err = fgbGeometryVertices(geometry, visit, 0)
+		}
+		if err != nil {
+			return core.Bbox{}, fmt.Errorf("invalid FlatGeobuf feature %d: %w", i, err)
+		}
+	}
+
+	if minX > maxX {
+		return core.Bbox{}, ErrNoFeaturesFound
+	}
+	return core.Bbox{Left: minX, Bottom: minY, Right: maxX, Top: maxY}, nil
+}
+
+// fgbGeometryVertices calls visit with the vertices of a geometry, including the geometries in its
+// parts, which are used for multi polygons and geometry collections
+func fgbGeometryVertices(geometry flatbufferTable, visit func(x, y float64), depth int) error {
+	if depth > wkbMaxDepth {
+		return errors.New("geometry nested too deeply")
+	}
+	xy, err := geometry.float64s(fgbGeometryXy)
+	if err != nil {
+		return err
+	}
+	for i := 0; i+1 < len(xy); i += 2 {
+		if math.IsNaN(xy[i]) || math.IsNaN(xy[i+1]) {
+			continue
+		}
+		visit(xy[i], xy[i+1])
+	}
+
+	parts, err := geometry.tables(fgbGeometryParts)
+	if err != nil {
+		return err
+	}
+	for _, part := range parts {
+		if err := fgbGeometryVertices(part, visit, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
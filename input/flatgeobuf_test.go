@@ -0,0 +1,360 @@
+package input
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mikeocool/bbox/core"
+)
+
+// fbField is a field of a flatbuffers table for buildFlatbuffer. Scalars are stored inline, and
+// everything else is written after the table by child, which returns its position. The zero
+// value is an unset field.
+type fbField struct {
+	scalar []byte
+	child  func(b *fbBuilder) int
+}
+
+type fbBuilder struct {
+	buf []byte
+}
+
+// table writes a vtable followed by its table, then the table's children, and returns the table's position
+func (b *fbBuilder) table(fields []fbField) int {
+	offsets := make([]uint16, len(fields))
+	tableSize := 4
+	for i, f := range fields {
+		switch {
+		case f.scalar != nil:
+			offsets[i] = uint16(tableSize)
+			tableSize += len(f.scalar)
+		case f.child != nil:
+			offsets[i] = uint16(tableSize)
+			tableSize += 4
+		}
+	}
+
+	vtable := len(b.buf)
+	b.buf = binary.LittleEndian.AppendUint16(b.buf, uint16(4+2*len(fields)))
+	b.buf = binary.LittleEndian.AppendUint16(b.buf, uint16(tableSize))
+	for _, offset := range offsets {
+		b.buf = binary.LittleEndian.AppendUint16(b.buf, offset)
+	}
+
+	table := len(b.buf)
+	b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(table-vtable))
+	for _, f := range fields {
+		if f.scalar != nil {
+			b.buf = append(b.buf, f.scalar...)
+		} else if f.child != nil {
+			b.buf = append(b.buf, 0, 0, 0, 0)
+		}
+	}
+	for i, f := range fields {
+		if f.child != nil {
+			b.offset(table+int(offsets[i]), f.child(b))
+		}
+	}
+	return table
+}
+
+// offset sets the unsigned offset at pos to point at target
+func (b *fbBuilder) offset(pos int, target int) {
+	binary.LittleEndian.PutUint32(b.buf[pos:], uint32(target-pos))
+}
+
+func buildFlatbuffer(fields ...fbField) []byte {
+	b := &fbBuilder{buf: make([]byte, 4)}
+	b.offset(0, b.table(fields))
+	return b.buf
+}
+
+func fbScalar(v any) fbField {
+	scalar, err := binary.Append(nil, binary.LittleEndian, v)
+	if err != nil {
+		panic(err)
+	}
+	return fbField{scalar: scalar}
+}
+
+func fbFloat64s(values ...float64) fbField {
+	return fbField{child: func(b *fbBuilder) int {
+		pos := len(b.buf)
+		b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(len(values)))
+		for _, v := range values {
+			b.buf = binary.LittleEndian.AppendUint64(b.buf, math.Float64bits(v))
+		}
+		return pos
+	}}
+}
+
+func fbString(s string) fbField {
+	return fbField{child: func(b *fbBuilder) int {
+		pos := len(b.buf)
+		b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(len(s)))
+		b.buf = append(b.buf, s...)
+		b.buf = append(b.buf, 0)
+		return pos
+	}}
+}
+
+func fbTable(fields ...fbField) fbField {
+	return fbField{child: func(b *fbBuilder) int {
+		return b.table(fields)
+	}}
+}
+
+func fbTables(tables ...[]fbField) fbField {
+	return fbField{child: func(b *fbBuilder) int {
+		pos := len(b.buf)
+		b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(len(tables)))
+		for range tables {
+			b.buf = append(b.buf, 0, 0, 0, 0)
+		}
+		for i, fields := range tables {
+			b.offset(pos+4+i*4, b.table(fields))
+		}
+		return pos
+	}}
+}
+
+// fgbHeader returns the fields of a FlatGeobuf header, with fields in schema order
+func fgbHeader(envelope []float64, featuresCount uint64, indexNodeSize uint16, crs ...fbField) []fbField {
+	fields := []fbField{fbString("test"), {}, fbScalar(uint8(0)), {}, {}, {}, {}, {},
+		fbScalar(featuresCount), fbScalar(indexNodeSize), {}}
+	if envelope != nil {
+		fields[fgbHeaderEnvelope] = fbFloat64s(envelope...)
+	}
+	if len(crs) > 0 {
+		fields[fgbHeaderCrs] = fbTable(crs...)
+	}
+	return fields
+}
+
+// fgbGeometry returns the fields of a FlatGeobuf geometry with the coordinates and parts
+func fgbGeometry(xy []float64, parts ...[]fbField) []fbField {
+	fields := []fbField{{}, {}, {}, {}, {}, {}, fbScalar(uint8(0)), {}}
+	if xy != nil {
+		fields[fgbGeometryXy] = fbFloat64s(xy...)
+	}
+	if len(parts) > 0 {
+		fields[fgbGeometryParts] = fbTables(parts...)
+	}
+	return fields
+}
+
+// buildFlatgeobuf writes a FlatGeobuf file. If root is set, an index with that root node is
+// written, and the rest of the index is zeros.
+func buildFlatgeobuf(t *testing.T, header []fbField, root *core.Bbox, geometries ...[]fbField) []byte {
+	t.Helper()
+	data := []byte("fgb\x03fgb\x01")
+	appendSizePrefixed := func(buf []byte) {
+		data = binary.LittleEndian.AppendUint32(data, uint32(len(buf)))
+		data = append(data, buf...)
+	}
+	appendSizePrefixed(buildFlatbuffer(header...))
+
+	if root != nil {
+		size, err := fgbIndexSize(uint64(len(geometries)), 16)
+		if err != nil {
+			t.Fatal(err)
+		}
+		index := make([]byte, size)
+		for i, v := range []float64{root.Left, root.Bottom, root.Right, root.Top} {
+			binary.LittleEndian.PutUint64(index[i*8:], math.Float64bits(v))
+		}
+		data = append(data, index...)
+	}
+
+	for _, geometry := range geometries {
+		feature := []fbField{{}}
+		if geometry != nil {
+			feature[fgbFeatureGeometry] = fbTable(geometry...)
+		}
+		appendSizePrefixed(buildFlatbuffer(feature...))
+	}
+	return data
+}
+
+func TestParseFlatgeobuf(t *testing.T) {
+	wgs84 := []fbField{fbString("EPSG"), fbScalar(int32(4326))}
+	envelope := []float64{-93.3, 44.9, -93.1, 45.2}
+	root := &core.Bbox{Left: -10, Bottom: -10, Right: 10, Top: 10}
+	features := [][]fbField{
+		fgbGeometry([]float64{1, 2, 3, 4}),
+		nil,
+		// a multi polygon with two parts
+		fgbGeometry(nil, fgbGeometry([]float64{-1, 0, 0, 1, -1, 0}), fgbGeometry([]float64{5, 5, 6, 6, 5, 5})),
+		fgbGeometry([]float64{math.NaN(), math.NaN()}),
+	}
+	scanned := core.Bbox{Left: -1, Bottom: 0, Right: 6, Top: 6}
+	noIndex := buildFlatgeobuf(t, fgbHeader(nil, 0, 0), nil, features...)
+
+	tests := []struct {
+		name     string
+		data     []byte
+		opts     ReadOptions
+		want     core.Bbox
+		wantCrs  int
+		wantErr  error
+		errorMsg string
+	}{
+		{
+			name:    "header envelope",
+			data:    buildFlatgeobuf(t, fgbHeader(envelope, 4, 16, wgs84...), root, features...),
+			want:    core.Bbox{Left: -93.3, Bottom: 44.9, Right: -93.1, Top: 45.2},
+			wantCrs: 4326,
+		},
+		{
+			name:    "index root node",
+			data:    buildFlatgeobuf(t, fgbHeader(nil, 4, 16, wgs84...), root, features...),
+			want:    *root,
+			wantCrs: 4326,
+		},
+		{
+			name:    "invalid envelope uses index",
+			data:    buildFlatgeobuf(t, fgbHeader([]float64{math.NaN(), 0, 0, 0}, 4, 16), root, features...),
+			want:    *root,
+			wantCrs: 0,
+		},
+		{
+			name: "no index",
+			data: buildFlatgeobuf(t, fgbHeader(nil, 4, 0, fbString("EPSG"), fbScalar(int32(32615)), fbString("WGS 84 / UTM zone 15N")),
+				nil, features...),
+			want:    scanned,
+			wantCrs: 32615,
+		},
+		{
+			name:    "scan geometries skips the index",
+			data:    buildFlatgeobuf(t, fgbHeader(envelope, 4, 16, wgs84...), root, features...),
+			opts:    ReadOptions{ScanGeometries: true},
+			want:    scanned,
+			wantCrs: 4326,
+		},
+		{
+			name:    "unknown feature count",
+			data:    buildFlatgeobuf(t, fgbHeader(nil, 0, 16), nil, features...),
+			want:    scanned,
+			wantCrs: 0,
+		},
+		{
+			name: "WKT CRS",
+			data: buildFlatgeobuf(t, fgbHeader(envelope, 4, 16, fbField{}, fbField{}, fbField{}, fbField{},
+				fbString(`GEOGCS["WGS 84",DATUM["WGS_1984",SPHEROID["WGS 84",6378137,298.257223563]],AUTHORITY["EPSG","4326"]]`)), root, features...),
+			want:    core.Bbox{Left: -93.3, Bottom: 44.9, Right: -93.1, Top: 45.2},
+			wantCrs: 4326,
+		},
+		{
+			name:    "no features",
+			data:    buildFlatgeobuf(t, fgbHeader(nil, 0, 16), nil),
+			wantErr: ErrNoFeaturesFound,
+		},
+		{
+			name:     "truncated feature",
+			data:     noIndex[:len(noIndex)-10],
+			errorMsg: "failed to read FlatGeobuf feature",
+		},
+		{
+			name:     "corrupt header",
+			data:     []byte("fgb\x03fgb\x01\x04\x00\x00\x00\xff\xff\xff\xff"),
+			errorMsg: "invalid FlatGeobuf header",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, crs, err := ParseFlatgeobuf(bytes.NewReader(tt.data), tt.opts)
+			if tt.wantErr != nil || tt.errorMsg != "" {
+				if err == nil {
+					t.Fatalf("ParseFlatgeobuf() expected an error")
+				}
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Errorf("ParseFlatgeobuf() error = %v, want %v", err, tt.wantErr)
+				}
+				if !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("ParseFlatgeobuf() error = %v, want it to contain %q", err, tt.errorMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseFlatgeobuf() unexpected error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseFlatgeobuf() = %v, want %v", got, tt.want)
+			}
+			if tt.wantCrs == 0 {
+				if crs != nil {
+					t.Errorf("ParseFlatgeobuf() crs = %v, want nil", crs)
+				}
+			} else if crs == nil || crs.Code != tt.wantCrs {
+				t.Errorf("ParseFlatgeobuf() crs = %v, want EPSG:%d", crs, tt.wantCrs)
+			}
+		})
+	}
+}
+
+func TestFgbIndexSize(t *testing.T) {
+	tests := []struct {
+		featuresCount uint64
+		nodeSize      uint16
+		want          uint64
+	}{
+		{featuresCount: 0, nodeSize: 16, want: 0},
+		{featuresCount: 10, nodeSize: 0, want: 0},
+		{featuresCount: 1, nodeSize: 16, want: 2 * 40},
+		{featuresCount: 16, nodeSize: 16, want: 17 * 40},
+		{featuresCount: 17, nodeSize: 16, want: (17 + 2 + 1) * 40},
+		{featuresCount: 300, nodeSize: 16, want: (300 + 19 + 2 + 1) * 40},
+	}
+
+	for _, tt := range tests {
+		got, err := fgbIndexSize(tt.featuresCount, tt.nodeSize)
+		if err != nil {
+			t.Fatalf("fgbIndexSize(%d, %d) unexpected error = %v", tt.featuresCount, tt.nodeSize, err)
+		}
+		if got != tt.want {
+			t.Errorf("fgbIndexSize(%d, %d) = %d, want %d", tt.featuresCount, tt.nodeSize, got, tt.want)
+		}
+	}
+}
+
+func TestLoadFileFlatgeobuf(t *testing.T) {
+	data := buildFlatgeobuf(t, fgbHeader(nil, 1, 16, fbString("EPSG"), fbScalar(int32(4326))),
+		&core.Bbox{Left: 1, Bottom: 2, Right: 3, Top: 4}, fgbGeometry([]float64{1, 2, 3, 4}))
+	want := core.Bbox{Left: 1, Bottom: 2, Right: 3, Top: 4}
+
+	filename := filepath.Join(t.TempDir(), "lines.fgb")
+	if err := os.WriteFile(filename, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, crs, err := LoadFile(filename, ReadOptions{})
+	if err != nil {
+		t.Fatalf("LoadFile() unexpected error = %v", err)
+	}
+	if got != want || crs == nil || crs.Code != 4326 {
+		t.Errorf("LoadFile() = %v %v, want %v EPSG:4326", got, crs, want)
+	}
+
+	// detected from its contents
+	got, _, err = ParseData(bytes.NewReader(data), ReadOptions{})
+	if err != nil {
+		t.Fatalf("ParseData() unexpected error = %v", err)
+	}
+	if got != want {
+		t.Errorf("ParseData() = %v, want %v", got, want)
+	}
+
+	got, _, err = ParseZip(buildZip(t, []archiveMember{{"lines.fgb", data}}), ReadOptions{})
+	if err != nil {
+		t.Fatalf("ParseZip() unexpected error = %v", err)
+	}
+	if got != want {
+		t.Errorf("ParseZip() = %v, want %v", got, want)
+	}
+}
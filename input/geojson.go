@@ -6,25 +6,22 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"math"
-	"os"
 	"strings"
 
 	"github.com/mikeocool/bbox/core"
 	"github.com/mikeocool/bbox/geojson"
+	"github.com/mikeocool/bbox/proj"
 )
 
 var ErrCouldNotParseGeoJSON = errors.New("unable to parse input as valid GeoJSON format")
 var ErrNoFeaturesFound = errors.New("no features found")
+var errNoValidCoordinates = errors.New("no valid coordinates found")
 
-func LoadGeojsonFile(filename string) (core.Bbox, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return core.Bbox{}, err
-	}
-	defer file.Close()
-	return ParseGeojson(file)
-}
+// errNullGeometries is returned when every feature has a null geometry, which is a valid document
+// without any features to bound
+var errNullGeometries = fmt.Errorf("%w: every feature has a null geometry", ErrNoFeaturesFound)
 
 // Check if a fragment of the file looks like GeoJSON
 func SniffGeojson(data []byte) bool {
@@ -43,7 +40,8 @@ func SniffGeojson(data []byte) bool {
 	if strings.Contains(dataStr, `"type"`) ||
 		strings.Contains(dataStr, `"geometry"`) ||
 		strings.Contains(dataStr, `"coordinates"`) ||
-		strings.Contains(dataStr, `"features"`) {
+		strings.Contains(dataStr, `"features"`) ||
+		SniffTopojson(data) {
 		return true
 	}
 
@@ -63,273 +61,565 @@ func SniffGeojson(data []byte) bool {
 // - FeatureCollection containing one or more features
 // - JSON list of Features
 // - Single Feature
-// - Single Polygon
+// - Single geometry, including nested GeometryCollections
 // - 3D coordinate array (polygon with rings): [[[0,0],[0,1],[1,1],[1,0],[0,0]]]
 // - 2D coordinate array (single ring): [[0,0],[0,1],[1,1],[1,0],[0,0]]
-func ParseGeojson(r io.Reader) (core.Bbox, error) {
-	var bbox core.Bbox
+// - TopoJSON topology
+//
+// The CRS is read from the legacy crs member if there is one, otherwise it's nil.
+//
+// A topology's bbox member is used if it has one, otherwise its arcs are decoded. If opts.Layer
+// is set, only the object with that name is read, from its arcs.
+//
+// Positions can have any number of dimensions. If they have a height, the box has the range of
+// heights as its Z. Features with a null geometry are skipped.
+//
+// The document is streamed, so only the coordinates are kept, and several GeoJSON texts one
+// after another are read as a GeoJSON text sequence. If opts.TrustBbox is set, the top-level
+// bbox member is used when there is one, and nothing after it is read.
+func ParseGeojson(r io.Reader, opts ReadOptions) (core.Bbox, *proj.CRS, error) {
+	return readGeojsonTexts(newJSONScanner(r), opts, false)
+}
 
-	input, err := io.ReadAll(r)
-	if err != nil {
-		return core.Bbox{}, fmt.Errorf("failed to read GeoJSON data: %w", err)
-	}
+// errGeojsonBboxFound stops reading an object once its bbox member has been read
+var errGeojsonBboxFound = errors.New("bbox member found")
+
+// geojsonPositionDepths is how deeply the positions of each geometry type are nested in its coordinates
+var geojsonPositionDepths = map[string]int{
+	"Point":           1,
+	"MultiPoint":      2,
+	"LineString":      2,
+	"Polygon":         3,
+	"MultiLineString": 3,
+	"MultiPolygon":    4,
+}
 
-	// Try parsing as FeatureCollection
-	var featureCollection geojson.FeatureCollection
-	if err := json.Unmarshal(input, &featureCollection); err == nil && featureCollection.Type == "FeatureCollection" {
-		return calculateBboxFromFeatures(featureCollection.Features)
+// readGeojsonTexts reads the bounds of a GeoJSON text, or of a sequence of them. In a sequence, texts
+// without coordinates are skipped, and every text is transformed to the CRS of the first. Features
+// with a null geometry are skipped too, and counted when opts.Verbose is set.
+func readGeojsonTexts(s *jsonScanner, opts ReadOptions, sequence bool) (core.Bbox, *proj.CRS, error) {
+	r := &geojsonReader{s: s, opts: opts}
+	box, crs, err := r.texts(sequence)
+	if opts.Verbose && r.nullGeometries > 0 {
+		log.Printf("Skipped %d GeoJSON features with a null geometry\n", r.nullGeometries)
 	}
+	return box, crs, err
+}
+
+// geojsonReader reads the bounds of GeoJSON from a scanner
+type geojsonReader struct {
+	s    *jsonScanner
+	opts ReadOptions
+	// nullGeometries is the number of features with a null geometry
+	nullGeometries int
+}
 
-	// Try parsing as array of Features
-	var features []geojson.Feature
-	if err := json.Unmarshal(input, &features); err == nil && len(features) > 0 {
-		// Verify it's actually an array of features
-		if isValidFeatureArray(features) {
-			return calculateBboxFromFeatures(features)
+func (r *geojsonReader) texts(sequence bool) (core.Bbox, *proj.CRS, error) {
+	s := r.s
+	var union bboxUnion
+	for n := 1; ; n++ {
+		if more, err := s.more(); err != nil {
+			return core.Bbox{}, nil, err
+		} else if !more {
+			if n == 1 && !sequence {
+				return core.Bbox{}, nil, ErrCouldNotParseGeoJSON
+			}
+			break
 		}
-	}
 
-	// Try parsing as single Feature
-	var feature geojson.Feature
-	if err := json.Unmarshal(input, &feature); err == nil && feature.Type == "Feature" {
-		return calculateBboxFromFeatures([]geojson.Feature{feature})
-	}
+		// only a single text can stop at its bbox, since the rest of a text in a sequence has to be read
+		box, crs, stopped, err := r.text(!sequence)
+		if errors.Is(err, errInvalidJSON) {
+			err = fmt.Errorf("%w: %w", ErrCouldNotParseGeoJSON, err)
+			if sequence {
+				return core.Bbox{}, nil, fmt.Errorf("GeoJSON text %d: %w", n, err)
+			}
+			return core.Bbox{}, nil, err
+		}
 
-	// Try parsing as Polygon
-	var polygon geojson.Polygon
-	if err := json.Unmarshal(input, &polygon); err == nil && polygon.Type == "Polygon" {
-		return calculateBboxFromCoordinates(polygon.Coordinates)
-	}
+		if !sequence {
+			more, moreErr := s.more()
+			if stopped || moreErr != nil || !more {
+				return box, crs, err
+			}
+			// there's another text after the first, so it's a sequence
+			sequence = true
+		}
 
-	// Try parsing as raw coordinates (3D array for polygon)
-	if coords, err := parseRaw3DCoordinates(input); err == nil {
-		return calculateBboxFromCoordinates(coords)
+		if errors.Is(err, ErrNoFeaturesFound) || errors.Is(err, errNoValidCoordinates) {
+			continue
+		} else if err != nil {
+			return core.Bbox{}, nil, fmt.Errorf("GeoJSON text %d: %w", n, err)
+		}
+		if err := union.add(box, crs); err != nil {
+			return core.Bbox{}, nil, err
+		}
 	}
+	return union.result()
+}
 
-	// Try parsing as 2D array (single ring)
-	if coords, err := parseRaw2DCoordinates(input); err == nil {
-		// Wrap in an additional array to make it a 3D array
-		return calculateBboxFromCoordinates([][][2]float64{coords})
+// text reads the bounds of the next GeoJSON text. If it's a single text, it can be a TopoJSON
+// topology, and the bool is true if it used a bbox member and stopped reading.
+func (r *geojsonReader) text(single bool) (core.Bbox, *proj.CRS, bool, error) {
+	s := r.s
+	next, err := s.peek()
+	if err != nil {
+		return core.Bbox{}, nil, false, err
 	}
+	switch next {
+	case '{':
+		object, err := r.object(single)
+		if errors.Is(err, errGeojsonBboxFound) {
+			return *object.bbox, detectGeojsonCrs(object.crs), true, nil
+		} else if err != nil {
+			return core.Bbox{}, nil, false, err
+		}
+		if object.bbox != nil && r.usesBbox(object.typ) {
+			return *object.bbox, detectGeojsonCrs(object.crs), false, nil
+		}
 
-	return bbox, ErrCouldNotParseGeoJSON
+		var extent geojsonExtent
+		switch object.typ {
+		case "Topology":
+			if object.topology == nil {
+				return core.Bbox{}, nil, false, ErrNoFeaturesFound
+			}
+			box, err := object.topology.extent(r.opts.Layer)
+			return box, detectGeojsonCrs(object.crs), false, err
+		case "FeatureCollection":
+			if object.features == 0 {
+				return core.Bbox{}, nil, false, ErrNoFeaturesFound
+			}
+			if object.nullFeatures == object.features {
+				return core.Bbox{}, nil, false, errNullGeometries
+			}
+			extent = object.featuresExtent
+		case "Feature":
+			if object.nullGeometry {
+				return core.Bbox{}, nil, false, errNullGeometries
+			}
+			extent = object.geometryExtent
+		default:
+			var ok bool
+			if extent, ok = object.coordinatesExtent(); !ok {
+				return core.Bbox{}, nil, false, ErrCouldNotParseGeoJSON
+			}
+		}
+		box, err := extent.result()
+		return box, detectGeojsonCrs(object.crs), false, err
+	case '[':
+		box, crs, err := r.array()
+		return box, crs, false, err
+	default:
+		if err := s.skip(); err != nil {
+			return core.Bbox{}, nil, false, err
+		}
+		return core.Bbox{}, nil, false, ErrCouldNotParseGeoJSON
+	}
 }
 
-// isValidFeatureArray checks if the array contains at least one valid feature
-func isValidFeatureArray(features []geojson.Feature) bool {
-	for _, f := range features {
-		if f.Type == "Feature" {
-			return true
+// array reads the bounds of an array of Features, or of raw coordinates. Its CRS is the crs member
+// of the first feature.
+func (r *geojsonReader) array() (core.Bbox, *proj.CRS, error) {
+	s := r.s
+	var extent geojsonExtent
+	var crs *geojson.CRS
+	var coordinates geojsonCoordinates
+	elements, features, nullFeatures := 0, 0, 0
+	objects, mixed := false, false
+
+	err := s.array(func() error {
+		next, err := s.peek()
+		if err != nil {
+			return err
+		}
+		elements++
+		if elements == 1 {
+			objects = next == '{'
 		}
+
+		switch {
+		case objects && next == '{':
+			object, err := r.object(false)
+			if err != nil {
+				return err
+			}
+			if elements == 1 {
+				crs = object.crs
+			}
+			if object.typ == "Feature" {
+				features++
+				extent.union(object.geometryExtent)
+				if object.nullGeometry {
+					nullFeatures++
+				}
+			}
+			return nil
+		case !objects && next == '[':
+			return coordinates.read(s, 2)
+		default:
+			mixed = true
+			return s.skip()
+		}
+	})
+	if err != nil {
+		return core.Bbox{}, nil, err
 	}
-	return false
-}
 
-// calculateBboxFromFeatures calculates bounding box from an array of features
-func calculateBboxFromFeatures(features []geojson.Feature) (core.Bbox, error) {
-	if len(features) == 0 {
-		return core.Bbox{}, ErrNoFeaturesFound
+	if mixed || elements == 0 {
+		return core.Bbox{}, nil, ErrCouldNotParseGeoJSON
+	}
+	if objects {
+		if features == 0 {
+			return core.Bbox{}, nil, ErrCouldNotParseGeoJSON
+		}
+		if nullFeatures == features {
+			return core.Bbox{}, nil, errNullGeometries
+		}
+		box, err := extent.result()
+		return box, detectGeojsonCrs(crs), err
 	}
 
-	minLon := math.Inf(1)
-	minLat := math.Inf(1)
-	maxLon := math.Inf(-1)
-	maxLat := math.Inf(-1)
-	hasValidCoordinates := false
+	// raw coordinates are a single ring, or a polygon's rings
+	if coordinates.depth < 0 || coordinates.depth > 3 || coordinates.short > 0 {
+		return core.Bbox{}, nil, ErrCouldNotParseGeoJSON
+	}
+	box, err := coordinates.extent.result()
+	return box, nil, err
+}
 
-	for _, feature := range features {
-		// Skip non-Feature objects
-		if feature.Type != "Feature" {
-			continue
-		}
+// geojsonObject is what's been read of a GeoJSON object. Its members can be in any order, so what
+// kind of object it is isn't known until it ends.
+type geojsonObject struct {
+	typ  string
+	crs  *geojson.CRS
+	bbox *core.Bbox
+	// features is the number of elements in a features member, nullFeatures how many of them are
+	// Features with a null geometry, and featuresExtent the extent of the Features among them
+	features       int
+	nullFeatures   int
+	featuresExtent geojsonExtent
+	// geometryExtent is the extent of a geometry member, if it's a valid geometry, and
+	// nullGeometry is true if the geometry member is null
+	geometryExtent geojsonExtent
+	nullGeometry   bool
+	// geometriesExtent is the extent of the valid geometries in a geometries member
+	geometriesExtent geojsonExtent
+	coordinates      geojsonCoordinates
+	// topology is what's been read of the members of a TopoJSON topology
+	topology *topojsonTopology
+}
 
-		// Skip features with missing or empty geometry
-		if feature.Geometry.Type == "" || len(feature.Geometry.Coordinates) == 0 {
-			continue
+// usesBbox reports whether an object's bbox member is used rather than its coordinates. A
+// topology's bbox is always used, unless the bounds are of one of its objects.
+func (r *geojsonReader) usesBbox(typ string) bool {
+	if r.opts.Layer != "" {
+		return false
+	}
+	return r.opts.TrustBbox || typ == "Topology"
+}
+
+// object reads an object, and the features, geometries and coordinates in it. A top object can
+// be a TopoJSON topology, and returns errGeojsonBboxFound as soon as it's read a bbox member that's
+// used.
+func (r *geojsonReader) object(top bool) (*geojsonObject, error) {
+	s := r.s
+	object := &geojsonObject{}
+	err := s.object(func(key string) error {
+		next, err := s.peek()
+		if err != nil {
+			return err
 		}
 
-		switch feature.Geometry.Type {
-		case "Point":
-			var coords []float64
-			if err := json.Unmarshal(feature.Geometry.Coordinates, &coords); err != nil {
-				continue
+		switch key {
+		case "type":
+			if next != '"' {
+				return s.skip()
 			}
-			if len(coords) >= 2 {
-				updateBounds(&minLon, &minLat, &maxLon, &maxLat, coords[0], coords[1])
-				hasValidCoordinates = true
+			object.typ, err = s.string()
+			return err
+		case "crs":
+			raw, err := s.raw()
+			if err != nil {
+				return err
 			}
-
-		case "LineString":
-			var coords [][]float64
-			if err := json.Unmarshal(feature.Geometry.Coordinates, &coords); err != nil {
-				continue
+			var crs *geojson.CRS
+			if json.Unmarshal(raw, &crs) == nil {
+				object.crs = crs
 			}
-			for _, coord := range coords {
-				if len(coord) >= 2 {
-					updateBounds(&minLon, &minLat, &maxLon, &maxLat, coord[0], coord[1])
-					hasValidCoordinates = true
-				}
+			return nil
+		case "bbox":
+			raw, err := s.raw()
+			if err != nil {
+				return err
 			}
-
-		case "Polygon":
-			var coords [][][]float64
-			if err := json.Unmarshal(feature.Geometry.Coordinates, &coords); err != nil {
-				continue
+			object.bbox = parseGeojsonBbox(raw)
+			if top && object.bbox != nil && r.usesBbox(object.typ) {
+				return errGeojsonBboxFound
 			}
-			for _, ring := range coords {
-				for _, coord := range ring {
-					if len(coord) >= 2 {
-						updateBounds(&minLon, &minLat, &maxLon, &maxLat, coord[0], coord[1])
-						hasValidCoordinates = true
-					}
-				}
+			return nil
+		case "arcs", "objects", "transform":
+			if !top {
+				return s.skip()
 			}
-
-		case "MultiPoint":
-			var coords [][]float64
-			if err := json.Unmarshal(feature.Geometry.Coordinates, &coords); err != nil {
-				continue
+			if object.topology == nil {
+				object.topology = &topojsonTopology{}
 			}
-			for _, coord := range coords {
-				if len(coord) >= 2 {
-					updateBounds(&minLon, &minLat, &maxLon, &maxLat, coord[0], coord[1])
-					hasValidCoordinates = true
-				}
+			switch {
+			case key == "arcs" && next == '[':
+				return object.topology.readArcs(s)
+			case key == "objects" && next == '{':
+				return object.topology.readObjects(s, r.opts.Layer)
+			case key == "transform":
+				return object.topology.readTransform(s)
+			default:
+				return s.skip()
 			}
-
-		case "MultiLineString":
-			var coords [][][]float64
-			if err := json.Unmarshal(feature.Geometry.Coordinates, &coords); err != nil {
-				continue
+		case "features":
+			if next != '[' {
+				return s.skip()
 			}
-			for _, line := range coords {
-				for _, coord := range line {
-					if len(coord) >= 2 {
-						updateBounds(&minLon, &minLat, &maxLon, &maxLat, coord[0], coord[1])
-						hasValidCoordinates = true
+			return s.array(func() error {
+				object.features++
+				if next, err := s.peek(); err != nil {
+					return err
+				} else if next != '{' {
+					return s.skip()
+				}
+				feature, err := r.object(false)
+				if err != nil {
+					return err
+				}
+				if feature.typ == "Feature" {
+					object.featuresExtent.union(feature.geometryExtent)
+					if feature.nullGeometry {
+						object.nullFeatures++
 					}
 				}
+				return nil
+			})
+		case "geometry":
+			if next != '{' {
+				object.nullGeometry = next == 'n'
+				return s.skip()
 			}
-
-		case "MultiPolygon":
-			var coords [][][][]float64
-			if err := json.Unmarshal(feature.Geometry.Coordinates, &coords); err != nil {
-				continue
+			geometry, err := r.object(false)
+			if err != nil {
+				return err
 			}
-			for _, polygon := range coords {
-				for _, ring := range polygon {
-					for _, coord := range ring {
-						if len(coord) >= 2 {
-							updateBounds(&minLon, &minLat, &maxLon, &maxLat, coord[0], coord[1])
-							hasValidCoordinates = true
-						}
-					}
-				}
+			if extent, ok := geometry.coordinatesExtent(); ok {
+				object.geometryExtent = extent
 			}
+			return nil
+		case "geometries":
+			if next != '[' {
+				return s.skip()
+			}
+			return s.array(func() error {
+				if next, err := s.peek(); err != nil {
+					return err
+				} else if next != '{' {
+					return s.skip()
+				}
+				geometry, err := r.object(false)
+				if err != nil {
+					return err
+				}
+				if extent, ok := geometry.coordinatesExtent(); ok {
+					object.geometriesExtent.union(extent)
+				}
+				return nil
+			})
+		case "coordinates":
+			return object.coordinates.read(s, 1)
+		default:
+			return s.skip()
 		}
+	})
+	if err == nil && object.typ == "Feature" && object.nullGeometry {
+		r.nullGeometries++
 	}
+	return object, err
+}
 
-	if !hasValidCoordinates || math.IsInf(minLon, 0) || math.IsInf(minLat, 0) || math.IsInf(maxLon, 0) || math.IsInf(maxLat, 0) {
-		return core.Bbox{}, fmt.Errorf("no valid coordinates found")
+// coordinatesExtent returns the extent of a geometry's coordinates, or of the geometries in a
+// GeometryCollection, and false if it isn't a geometry or its positions aren't nested as its type needs
+func (o *geojsonObject) coordinatesExtent() (geojsonExtent, bool) {
+	if o.typ == "GeometryCollection" {
+		return o.geometriesExtent, true
 	}
-
-	return core.Bbox{
-		Left:   minLon,
-		Bottom: minLat,
-		Right:  maxLon,
-		Top:    maxLat,
-	}, nil
+	depth, ok := geojsonPositionDepths[o.typ]
+	if !ok || o.coordinates.depth < 0 || (o.coordinates.depth != 0 && o.coordinates.depth != depth) {
+		return geojsonExtent{}, false
+	}
+	return o.coordinates.extent, true
 }
 
-// calculateBboxFromCoordinates calculates bounding box from polygon coordinates
-func calculateBboxFromCoordinates(coords [][][2]float64) (core.Bbox, error) {
-	if len(coords) == 0 {
-		return core.Bbox{}, fmt.Errorf("no coordinates found")
+// parseGeojsonBbox parses a bbox member, or returns nil if it isn't valid. The bbox has the
+// minimums of every dimension, then the maximums -- except for boxes that cross the antimeridian,
+// which have a west longitude greater than their east one.
+func parseGeojsonBbox(raw []byte) *core.Bbox {
+	var values []float64
+	if err := json.Unmarshal(raw, &values); err != nil || len(values) < 4 || len(values)%2 != 0 {
+		return nil
 	}
+	dimensions := len(values) / 2
+	box := core.Bbox{Left: values[0], Bottom: values[1], Right: values[dimensions], Top: values[dimensions+1]}
+	if dimensions == 3 {
+		box.HasZ, box.MinZ, box.MaxZ = true, values[2], values[5]
+	}
+	if boundsInvalid(min(box.Left, box.Right), box.Bottom, max(box.Left, box.Right), box.Top) ||
+		(box.Left > box.Right && !box.CrossesAntimeridian()) {
+		return nil
+	}
+	return &box
+}
 
-	minLon := math.Inf(1)
-	minLat := math.Inf(1)
-	maxLon := math.Inf(-1)
-	maxLat := math.Inf(-1)
-	hasValidCoordinates := false
+// geojsonCoordinates is what's been read of a coordinates member
+type geojsonCoordinates struct {
+	extent geojsonExtent
+	// depth is how deeply the positions are nested, 0 if there aren't any, or -1 if it's not
+	// consistent or there are values that aren't positions
+	depth int
+	// short is the number of positions with less than two values, which are left out of the extent
+	short int
+}
+
+// read reads an array of coordinates, which is nested depth arrays deep
+func (c *geojsonCoordinates) read(s *jsonScanner, depth int) error {
+	if next, err := s.peek(); err != nil {
+		return err
+	} else if next != '[' {
+		c.depth = -1
+		return s.skip()
+	}
 
-	for _, ring := range coords {
-		for _, coord := range ring {
-			if len(coord) >= 2 {
-				updateBounds(&minLon, &minLat, &maxLon, &maxLat, coord[0], coord[1])
-				hasValidCoordinates = true
+	values := 0
+	arrays := false
+	var x, y, z float64
+	err := s.array(func() error {
+		next, err := s.peek()
+		if err != nil {
+			return err
+		}
+		switch {
+		case next == '[':
+			arrays = true
+			return c.read(s, depth+1)
+		case next == '-' || (next >= '0' && next <= '9'):
+			value, err := s.float()
+			switch values {
+			case 0:
+				x = value
+			case 1:
+				y = value
+			case 2:
+				z = value
 			}
+			values++
+			return err
+		default:
+			c.depth = -1
+			return s.skip()
 		}
+	})
+	if err != nil || values == 0 {
+		return err
 	}
 
-	if !hasValidCoordinates || math.IsInf(minLon, 0) || math.IsInf(minLat, 0) || math.IsInf(maxLon, 0) || math.IsInf(maxLat, 0) {
-		return core.Bbox{}, fmt.Errorf("no valid coordinates found")
+	// the array is a position
+	if arrays || (c.depth != 0 && c.depth != depth) {
+		c.depth = -1
+	} else {
+		c.depth = depth
+	}
+	if values < 2 {
+		c.short++
+		return nil
 	}
+	c.extent.add(x, y)
+	if values > 2 {
+		c.extent.addZ(z)
+	}
+	return nil
+}
 
-	return core.Bbox{
-		Left:   minLon,
-		Bottom: minLat,
-		Right:  maxLon,
-		Top:    maxLat,
-	}, nil
+// geojsonExtent is the extent of the positions that have been added to it, and the range of
+// their heights if any of them had one
+type geojsonExtent struct {
+	minX, minY, maxX, maxY float64
+	found                  bool
+	minZ, maxZ             float64
+	foundZ                 bool
 }
 
-// parseRaw3DCoordinates validates and parses 3D coordinate arrays like [[[0,0],[0,1],[1,1],[1,0],[0,0]]]
-func parseRaw3DCoordinates(input []byte) ([][][2]float64, error) {
-	// First unmarshal into a flexible structure to validate dimensions
-	var rawCoords [][][]interface{}
-	if err := json.Unmarshal(input, &rawCoords); err != nil {
-		return nil, err
-	}
-	
-	if len(rawCoords) == 0 {
-		return nil, fmt.Errorf("empty coordinate array")
-	}
-	
-	// Validate that each coordinate has at least 2 dimensions
-	for _, ring := range rawCoords {
-		for _, coord := range ring {
-			if len(coord) < 2 {
-				return nil, fmt.Errorf("coordinate has insufficient dimensions: %v", coord)
-			}
-		}
+func (e *geojsonExtent) add(x, y float64) {
+	if !e.found {
+		e.minX, e.minY, e.maxX, e.maxY = x, y, x, y
+		e.found = true
+		return
+	}
+	updateBounds(&e.minX, &e.minY, &e.maxX, &e.maxY, x, y)
+}
+
+func (e *geojsonExtent) addZ(z float64) {
+	if !e.foundZ {
+		e.minZ, e.maxZ = z, z
+		e.foundZ = true
+		return
 	}
-	
-	// Now unmarshal into the typed structure
-	var coordinates [][][2]float64
-	if err := json.Unmarshal(input, &coordinates); err != nil {
-		return nil, err
+	e.minZ, e.maxZ = math.Min(e.minZ, z), math.Max(e.maxZ, z)
+}
+
+func (e *geojsonExtent) union(other geojsonExtent) {
+	if other.found {
+		e.add(other.minX, other.minY)
+		e.add(other.maxX, other.maxY)
+	}
+	if other.foundZ {
+		e.addZ(other.minZ)
+		e.addZ(other.maxZ)
 	}
-	
-	return coordinates, nil
 }
 
-// parseRaw2DCoordinates validates and parses 2D coordinate arrays like [[0,0],[0,1],[1,1],[1,0],[0,0]]
-func parseRaw2DCoordinates(input []byte) ([][2]float64, error) {
-	// First unmarshal into a flexible structure to validate dimensions
-	var rawCoords [][]interface{}
-	if err := json.Unmarshal(input, &rawCoords); err != nil {
-		return nil, err
-	}
-	
-	if len(rawCoords) == 0 {
-		return nil, fmt.Errorf("empty coordinate array")
-	}
-	
-	// Validate that each coordinate has at least 2 dimensions
-	for _, coord := range rawCoords {
-		if len(coord) < 2 {
-			return nil, fmt.Errorf("coordinate has insufficient dimensions: %v", coord)
-		}
+func (e geojsonExtent) result() (core.Bbox, error) {
+	if !e.found {
+		return core.Bbox{}, errNoValidCoordinates
 	}
-	
-	// Now unmarshal into the typed structure
-	var coordinates [][2]float64
-	if err := json.Unmarshal(input, &coordinates); err != nil {
-		return nil, err
+	box := core.Bbox{Left: e.minX, Bottom: e.minY, Right: e.maxX, Top: e.maxY}
+	if e.foundZ {
+		box.HasZ, box.MinZ, box.MaxZ = true, e.minZ, e.maxZ
 	}
-	
-	return coordinates, nil
+	return box, nil
+}
+
+// detectGeojsonCrs returns the CRS named by a legacy GeoJSON crs member, or nil if there isn't one.
+// CRSs we can't transform are returned as unsupported, and since we can't tell whether
+// they're geographic they're treated as projected.
+func detectGeojsonCrs(crs *geojson.CRS) *proj.CRS {
+	if crs == nil {
+		return nil
+	}
+
+	var name string
+	switch strings.ToLower(crs.Type) {
+	case "name":
+		name = crs.Properties.Name
+	case "epsg":
+		name = "EPSG:" + crs.Properties.Code.String()
+	default:
+		// linked CRSs aren't followed
+		return nil
+	}
+
+	code, err := proj.ParseCode(name)
+	if err != nil {
+		return proj.Unsupported(0, name, false)
+	}
+	if detected, err := proj.Lookup(code); err == nil {
+		return detected
+	}
+	return proj.Unsupported(code, fmt.Sprintf("EPSG:%d", code), false)
 }
 
 // updateBounds updates the min/max bounds with the given coordinate
@@ -347,3 +637,15 @@ func updateBounds(minLon, minLat, maxLon, maxLat *float64, lon, lat float64) {
 		*maxLat = lat
 	}
 }
+
+// boundsInvalid reports whether bounds stored in a file, like a header or metadata extent, can't be
+// trusted: values that aren't finite, a no data value (anything less than -10^38, as shapefiles
+// use), or a min greater than the max
+func boundsInvalid(minX, minY, maxX, maxY float64) bool {
+	for _, v := range []float64{minX, minY, maxX, maxY} {
+		if math.IsNaN(v) || math.IsInf(v, 0) || v <= -1e38 {
+			return true
+		}
+	}
+	return minX > maxX || minY > maxY
+}
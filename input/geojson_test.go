@@ -2,7 +2,13 @@ package input
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/mikeocool/bbox/core"
 )
@@ -541,7 +547,7 @@ func TestParseGeojson(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := ParseGeojson(bytes.NewReader([]byte(tt.input)))
+			got, _, err := ParseGeojson(bytes.NewReader([]byte(tt.input)), ReadOptions{})
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ParseGeojson() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -577,7 +583,7 @@ func TestParseGeojsonNegativeCoordinates(t *testing.T) {
 		Top:    90,
 	}
 
-	got, err := ParseGeojson(bytes.NewReader([]byte(input)))
+	got, _, err := ParseGeojson(bytes.NewReader([]byte(input)), ReadOptions{})
 	if err != nil {
 		t.Errorf("ParseGeojson() unexpected error = %v", err)
 		return
@@ -610,7 +616,7 @@ func TestParseGeojsonInvalidByteInput(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := ParseGeojson(bytes.NewReader(tt.input))
+			_, _, err := ParseGeojson(bytes.NewReader(tt.input), ReadOptions{})
 			if err == nil {
 				t.Errorf("ParseGeojson() expected error for invalid byte input, got nil")
 			}
@@ -628,7 +634,7 @@ func TestParseGeojsonLargeCoordinates(t *testing.T) {
 		}
 	}`
 
-	got, err := ParseGeojson(bytes.NewReader([]byte(input)))
+	got, _, err := ParseGeojson(bytes.NewReader([]byte(input)), ReadOptions{})
 	if err != nil {
 		t.Errorf("ParseGeojson() unexpected error = %v", err)
 		return
@@ -649,8 +655,244 @@ func TestParseGeojsonEmptyGeometryCollection(t *testing.T) {
 		}
 	}`
 
-	_, err := ParseGeojson(bytes.NewReader([]byte(input)))
+	_, _, err := ParseGeojson(bytes.NewReader([]byte(input)), ReadOptions{})
 	if err == nil {
 		t.Errorf("ParseGeojson() expected error for empty GeometryCollection, got nil")
 	}
 }
+
+func TestParseGeojsonCrs(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		wantCode      int
+		wantNil       bool
+		wantSupported bool
+	}{
+		{
+			name:    "No crs member",
+			input:   `{"type":"FeatureCollection","features":[{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]}}]}`,
+			wantNil: true,
+		},
+		{
+			name:          "FeatureCollection with EPSG URN",
+			input:         `{"type":"FeatureCollection","crs":{"type":"name","properties":{"name":"urn:ogc:def:crs:EPSG::27700"}},"features":[{"type":"Feature","geometry":{"type":"Point","coordinates":[530000,180000]}}]}`,
+			wantCode:      27700,
+			wantSupported: true,
+		},
+		{
+			name:          "Feature with CRS84",
+			input:         `{"type":"Feature","crs":{"type":"name","properties":{"name":"urn:ogc:def:crs:OGC:1.3:CRS84"}},"geometry":{"type":"Point","coordinates":[1,2]}}`,
+			wantCode:      4326,
+			wantSupported: true,
+		},
+		{
+			name:          "Polygon with 2008 spec EPSG type",
+			input:         `{"type":"Polygon","crs":{"type":"EPSG","properties":{"code":3857}},"coordinates":[[[0,0],[1,0],[1,1],[0,0]]]}`,
+			wantCode:      3857,
+			wantSupported: true,
+		},
+		{
+			name:          "Unsupported EPSG code",
+			input:         `{"type":"Feature","crs":{"type":"name","properties":{"name":"EPSG:2263"}},"geometry":{"type":"Point","coordinates":[1,2]}}`,
+			wantCode:      2263,
+			wantSupported: false,
+		},
+		{
+			name:    "Linked crs is ignored",
+			input:   `{"type":"Feature","crs":{"type":"link","properties":{"href":"http://example.com/crs/42","type":"proj4"}},"geometry":{"type":"Point","coordinates":[1,2]}}`,
+			wantNil: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, crs, err := ParseGeojson(bytes.NewReader([]byte(tt.input)), ReadOptions{})
+			if err != nil {
+				t.Fatalf("ParseGeojson() unexpected error = %v", err)
+			}
+			if tt.wantNil {
+				if crs != nil {
+					t.Errorf("ParseGeojson() crs = %v, want nil", crs)
+				}
+				return
+			}
+			if crs == nil {
+				t.Fatalf("ParseGeojson() crs = nil, want EPSG:%d", tt.wantCode)
+			}
+			if crs.Code != tt.wantCode || crs.IsSupported() != tt.wantSupported {
+				t.Errorf("ParseGeojson() crs = %v supported %v, want EPSG:%d supported %v", crs, crs.IsSupported(), tt.wantCode, tt.wantSupported)
+			}
+		})
+	}
+}
+
+func TestParseGeojsonStreaming(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		opts     ReadOptions
+		want     core.Bbox
+		errorMsg string
+	}{
+		{
+			name:  "members in any order",
+			input: `{"features":[{"geometry":{"coordinates":[[1,2],[3,4]],"type":"LineString"},"properties":{"note":"a \"quoted\" [1,2]"},"type":"Feature"}],"type":"FeatureCollection"}`,
+			want:  core.Bbox{Left: 1, Bottom: 2, Right: 3, Top: 4},
+		},
+		{
+			name:  "bbox member isn't trusted by default",
+			input: `{"type":"FeatureCollection","bbox":[-180,-90,180,90],"features":[{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]}}]}`,
+			want:  core.Bbox{Left: 1, Bottom: 2, Right: 1, Top: 2},
+		},
+		{
+			name:  "trusted 3D bbox member",
+			input: `{"type":"Feature","bbox":[-1,-2,0,3,4,100],"geometry":{"type":"Point","coordinates":[1,2]}}`,
+			opts:  ReadOptions{TrustBbox: true},
+			want:  core.Bbox{Left: -1, Bottom: -2, Right: 3, Top: 4, HasZ: true, MinZ: 0, MaxZ: 100},
+		},
+		{
+			name:  "trusted bbox member that crosses the antimeridian",
+			input: `{"type":"FeatureCollection","bbox":[170,-20,-170,-10],"features":[]}`,
+			opts:  ReadOptions{TrustBbox: true},
+			want:  core.Bbox{Left: 170, Bottom: -20, Right: -170, Top: -10},
+		},
+		{
+			name:  "invalid bbox member is ignored",
+			input: `{"type":"Feature","bbox":[3,4,1,2,5],"geometry":{"type":"Point","coordinates":[1,2]}}`,
+			opts:  ReadOptions{TrustBbox: true},
+			want:  core.Bbox{Left: 1, Bottom: 2, Right: 1, Top: 2},
+		},
+		{
+			name:     "syntax error",
+			input:    `{"type":"Feature","geometry":{"type":"Point","coordinates":[1 2]}}`,
+			errorMsg: "invalid JSON at offset 62: invalid character '2', expected ',' or ']'",
+		},
+		{
+			name:     "nested too deeply",
+			input:    strings.Repeat("[", 2000) + strings.Repeat("]", 2000),
+			errorMsg: "JSON is nested too deeply",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := ParseGeojson(strings.NewReader(tt.input), tt.opts)
+			if tt.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("ParseGeojson() error = %v, want it to contain %q", err, tt.errorMsg)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ParseGeojson() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+
+	t.Run("nothing after a trusted bbox is read", func(t *testing.T) {
+		head := `{"type":"FeatureCollection","crs":{"type":"name","properties":{"name":"EPSG:3857"}},"bbox":[1,2,3,4],"features":[`
+		r := io.MultiReader(strings.NewReader(head), iotest.ErrReader(errors.New("read past the bbox")))
+		got, crs, err := ParseGeojson(r, ReadOptions{TrustBbox: true})
+		if err != nil || got != (core.Bbox{Left: 1, Bottom: 2, Right: 3, Top: 4}) || crs == nil || crs.Code != 3857 {
+			t.Errorf("ParseGeojson() = %v, %v, %v", got, crs, err)
+		}
+	})
+
+	t.Run("many features", func(t *testing.T) {
+		var b strings.Builder
+		b.WriteString(`{"type":"FeatureCollection","features":[`)
+		for i := 0; i < 10000; i++ {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			fmt.Fprintf(&b, `{"type":"Feature","properties":{"id":%d},"geometry":{"type":"Point","coordinates":[%d,%d.5]}}`, i, i, -i)
+		}
+		b.WriteString(`]}`)
+		got, _, err := ParseGeojson(strings.NewReader(b.String()), ReadOptions{})
+		want := core.Bbox{Left: 0, Bottom: -9999.5, Right: 9999, Top: 0.5}
+		if err != nil || got != want {
+			t.Errorf("ParseGeojson() = %v, %v, want %v", got, err, want)
+		}
+	})
+}
+
+func TestParseGeojsonRFC7946(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  core.Bbox
+	}{
+		{
+			name: "nested geometry collections",
+			input: `{"type":"Feature","geometry":{"type":"GeometryCollection","geometries":[` +
+				`{"type":"Point","coordinates":[1,2]},` +
+				`{"type":"GeometryCollection","geometries":[{"type":"LineString","coordinates":[[-3,4],[5,-6]]},{"type":"GeometryCollection","geometries":[]}]},` +
+				`{"type":"Polygon","coordinates":[[1,2]]}]}}`,
+			want: core.Bbox{Left: -3, Bottom: -6, Right: 5, Top: 4},
+		},
+		{
+			name:  "positions with heights",
+			input: `{"type":"MultiPoint","coordinates":[[1,2,300.5],[3,4,-10],[5,6]]}`,
+			want:  core.Bbox{Left: 1, Bottom: 2, Right: 5, Top: 6, HasZ: true, MinZ: -10, MaxZ: 300.5},
+		},
+		{
+			name:  "positions with measures",
+			input: `{"type":"LineString","coordinates":[[1,2,3,1700000000],[4,5,6,1700000060]]}`,
+			want:  core.Bbox{Left: 1, Bottom: 2, Right: 4, Top: 5, HasZ: true, MinZ: 3, MaxZ: 6},
+		},
+		{
+			name: "heights in geometry collections",
+			input: `{"type":"FeatureCollection","features":[` +
+				`{"type":"Feature","geometry":{"type":"GeometryCollection","geometries":[{"type":"Point","coordinates":[1,2,50]}]}},` +
+				`{"type":"Feature","geometry":{"type":"Point","coordinates":[3,4,-50]}}]}`,
+			want: core.Bbox{Left: 1, Bottom: 2, Right: 3, Top: 4, HasZ: true, MinZ: -50, MaxZ: 50},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := ParseGeojson(strings.NewReader(tt.input), ReadOptions{})
+			if err != nil || got != tt.want {
+				t.Errorf("ParseGeojson() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+
+	t.Run("null geometries are counted", func(t *testing.T) {
+		var buf bytes.Buffer
+		previous := log.Writer()
+		log.SetOutput(&buf)
+		defer log.SetOutput(previous)
+
+		input := `{"type":"FeatureCollection","features":[` +
+			`{"type":"Feature","geometry":null},` +
+			`{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]}},` +
+			`{"type":"Feature","properties":{"geometry":null},"geometry":null}]}`
+		got, _, err := ParseGeojson(strings.NewReader(input), ReadOptions{Verbose: true})
+		if err != nil || got != (core.Bbox{Left: 1, Bottom: 2, Right: 1, Top: 2}) {
+			t.Errorf("ParseGeojson() = %v, %v", got, err)
+		}
+		if !strings.Contains(buf.String(), "Skipped 2 GeoJSON features with a null geometry") {
+			t.Errorf("expected the null geometries to be logged, got %q", buf.String())
+		}
+
+		buf.Reset()
+		if _, _, err := ParseGeojson(strings.NewReader(input), ReadOptions{}); err != nil || buf.Len() > 0 {
+			t.Errorf("expected nothing to be logged without Verbose, got %q, %v", buf.String(), err)
+		}
+	})
+
+	t.Run("only null geometries", func(t *testing.T) {
+		for _, input := range []string{
+			`{"type":"FeatureCollection","features":[{"type":"Feature","geometry":null},{"type":"Feature","geometry":null}]}`,
+			`{"type":"Feature","geometry":null}`,
+			`[{"type":"Feature","geometry":null}]`,
+		} {
+			_, _, err := ParseData(strings.NewReader(input), ReadOptions{})
+			if !errors.Is(err, ErrNoFeaturesFound) || !strings.Contains(err.Error(), "every feature has a null geometry") {
+				t.Errorf("ParseData(%s) error = %v, want %v", input, err, errNullGeometries)
+			}
+		}
+	})
+}
@@ -0,0 +1,47 @@
+package input
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/mikeocool/bbox/core"
+	"github.com/mikeocool/bbox/proj"
+)
+
+// geojsonRecordSeparator starts each text of an RFC 8142 GeoJSON text sequence
+const geojsonRecordSeparator = 0x1e
+
+// SniffGeojsonSeq checks whether the data is a GeoJSON text sequence -- either RFC 8142, where
+// each text starts with a record separator, or newline delimited GeoJSONL. Newline delimited
+// sequences are only recognized when the first text ends within the data.
+func SniffGeojsonSeq(data []byte) bool {
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	if len(trimmed) > 0 && trimmed[0] == geojsonRecordSeparator {
+		return true
+	}
+	if len(trimmed) == 0 || trimmed[0] != '{' || !SniffGeojson(trimmed) {
+		return false
+	}
+
+	// the first object has to end on its line, and another one has to start on the next
+	dec := json.NewDecoder(bytes.NewReader(trimmed))
+	var first json.RawMessage
+	if err := dec.Decode(&first); err != nil {
+		return false
+	}
+	rest := bytes.TrimLeft(trimmed[dec.InputOffset():], " \t\r")
+	if len(rest) == 0 || rest[0] != '\n' {
+		return false
+	}
+	rest = bytes.TrimLeft(rest, " \t\r\n")
+	return len(rest) > 0 && rest[0] == '{'
+}
+
+// ParseGeojsonSeq reads the bounds of a sequence of GeoJSON texts, either one per line or each
+// starting with a record separator. The texts are streamed, and each can be any of the formats
+// supported by ParseGeojson. Texts without coordinates, like features with a null geometry, are
+// skipped, and every text is transformed to the CRS of the first.
+func ParseGeojsonSeq(r io.Reader, opts ReadOptions) (core.Bbox, *proj.CRS, error) {
+	return readGeojsonTexts(newJSONScanner(r), opts, true)
+}
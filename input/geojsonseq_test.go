@@ -0,0 +1,119 @@
+package input
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mikeocool/bbox/core"
+)
+
+func TestParseGeojsonSeq(t *testing.T) {
+	point := func(x, y string) string {
+		return `{"type":"Feature","properties":{},"geometry":{"type":"Point","coordinates":[` + x + `,` + y + `]}}`
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		want     core.Bbox
+		wantCode int
+		errorMsg string
+	}{
+		{
+			name:  "newline delimited features",
+			input: point("-90.9", "48.1") + "\n" + point("-90.8", "48.2") + "\n",
+			want:  core.Bbox{Left: -90.9, Bottom: 48.1, Right: -90.8, Top: 48.2},
+		},
+		{
+			name:  "RFC 8142 record separators",
+			input: "\x1e" + point("1", "2") + "\n\x1e" + point("3", "4") + "\n",
+			want:  core.Bbox{Left: 1, Bottom: 2, Right: 3, Top: 4},
+		},
+		{
+			name:  "null geometries and blank lines are skipped",
+			input: `{"type":"Feature","properties":{},"geometry":null}` + "\r\n\r\n" + point("1", "2") + "\r\n",
+			want:  core.Bbox{Left: 1, Bottom: 2, Right: 1, Top: 2},
+		},
+		{
+			name: "the first CRS is used",
+			input: `{"type":"Feature","crs":{"type":"name","properties":{"name":"EPSG:4326"}},"geometry":{"type":"Point","coordinates":[0,0]}}` + "\n" +
+				`{"type":"Feature","crs":{"type":"name","properties":{"name":"EPSG:3857"}},"geometry":{"type":"Point","coordinates":[111319.49079327357,0]}}`,
+			want:     core.Bbox{Left: 0, Bottom: 0, Right: 1, Top: 0},
+			wantCode: 4326,
+		},
+		{
+			name:     "only null geometries",
+			input:    `{"type":"Feature","geometry":null}` + "\n" + `{"type":"Feature","geometry":null}`,
+			errorMsg: "no features found",
+		},
+		{
+			name:     "truncated text",
+			input:    point("1", "2") + "\n" + `{"type":"Feature","geometry":{"type":"Point"`,
+			errorMsg: "GeoJSON text 2: " + ErrCouldNotParseGeoJSON.Error() + ": invalid JSON",
+		},
+		{
+			name:     "text that isn't GeoJSON",
+			input:    point("1", "2") + "\n" + `{"name":"Little Sag"}`,
+			errorMsg: "GeoJSON text 2: " + ErrCouldNotParseGeoJSON.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !SniffGeojsonSeq([]byte(tt.input)) {
+				t.Fatalf("SniffGeojsonSeq() = false")
+			}
+			got, crs, err := ParseData(strings.NewReader(tt.input), ReadOptions{})
+			if tt.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("ParseData() error = %v, want it to contain %q", err, tt.errorMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseData() unexpected error = %v", err)
+			}
+			if !bboxWithin(got, tt.want, 1e-9) {
+				t.Errorf("ParseData() = %v, want %v", got, tt.want)
+			}
+			if tt.wantCode == 0 && crs != nil {
+				t.Errorf("ParseData() crs = %v, want nil", crs)
+			} else if tt.wantCode != 0 && (crs == nil || crs.Code != tt.wantCode) {
+				t.Errorf("ParseData() crs = %v, want EPSG:%d", crs, tt.wantCode)
+			}
+		})
+	}
+
+	t.Run("first line longer than the sniffed data", func(t *testing.T) {
+		coords := strings.Repeat("[1,2],", 2000) + "[5,6]"
+		long := `{"type":"Feature","geometry":{"type":"LineString","coordinates":[` + coords + `]}}`
+		input := []byte(long + "\n" + `{"type":"Feature","geometry":{"type":"Point","coordinates":[-1,-2]}}` + "\n")
+		if SniffGeojsonSeq(input[:8192]) {
+			t.Fatalf("SniffGeojsonSeq() = true for a truncated first line")
+		}
+		got, _, err := ParseRaw(input, ReadOptions{})
+		want := core.Bbox{Left: -1, Bottom: -2, Right: 5, Top: 6}
+		if err != nil || got != want {
+			t.Errorf("ParseRaw() = %v, %v, want %v", got, err, want)
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		_, _, err := ParseGeojsonSeq(bytes.NewReader([]byte("\x1e\n")), ReadOptions{})
+		if !errors.Is(err, ErrNoFeaturesFound) {
+			t.Errorf("ParseGeojsonSeq() error = %v, want ErrNoFeaturesFound", err)
+		}
+	})
+
+	for _, input := range []string{
+		point("1", "2"),
+		`{"type":"FeatureCollection","features":[` + "\n" + point("1", "2") + "\n]}",
+		"[[0,0],[1,1]]\n[[2,2],[3,3]]",
+	} {
+		if SniffGeojsonSeq([]byte(input)) {
+			t.Errorf("SniffGeojsonSeq(%q) = true", input)
+		}
+	}
+}
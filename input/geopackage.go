@@ -0,0 +1,331 @@
+package input
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"strings"
+
+	"github.com/mikeocool/bbox/core"
+	"github.com/mikeocool/bbox/proj"
+)
+
+// SniffGeopackage checks for a SQLite database with a GeoPackage application id
+func SniffGeopackage(data []byte) bool {
+	if len(data) < 72 || !bytes.HasPrefix(data, sqliteMagic) {
+		return false
+	}
+	switch string(data[68:72]) {
+	case "GPKG", "GP10", "GP11":
+		return true
+	}
+	return false
+}
+
+// gpkgLayer is a row of gpkg_contents
+type gpkgLayer struct {
+	name     string
+	dataType string
+	extent   *core.Bbox
+	srsId    int64
+}
+
+// ParseGeopackage reads the bounds of the feature and tile layers of a GeoPackage, or just
+// opts.Layer if it's set. Each layer's extent comes from gpkg_contents, unless it's missing or
+// opts.ScanGeometries is set, when feature layers use the envelopes of their geometries and tile
+// layers use their tile matrix set. Layers in a CRS that can be transformed are converted to WGS84.
+func ParseGeopackage(r io.ReaderAt, size int64, opts ReadOptions) (core.Bbox, *proj.CRS, error) {
+	db, err := openSqlite(r, size)
+	if err != nil {
+		return core.Bbox{}, nil, fmt.Errorf("invalid GeoPackage: %w", err)
+	}
+
+	layers, err := readGpkgContents(db)
+	if err != nil {
+		return core.Bbox{}, nil, err
+	}
+	if opts.Layer != "" {
+		var names []string
+		var selected []gpkgLayer
+		for _, layer := range layers {
+			names = append(names, layer.name)
+			if strings.EqualFold(layer.name, opts.Layer) {
+				selected = append(selected, layer)
+			}
+		}
+		if len(selected) == 0 {
+			return core.Bbox{}, nil, fmt.Errorf("layer %q not found in GeoPackage, it has: %s", opts.Layer, strings.Join(names, ", "))
+		}
+		layers = selected
+	}
+
+	srs, err := readGpkgSpatialRefSys(db)
+	if err != nil {
+		return core.Bbox{}, nil, err
+	}
+	wgs84, err := proj.Lookup(proj.EPSGWgs84)
+	if err != nil {
+		return core.Bbox{}, nil, err
+	}
+
+	var union bboxUnion
+	for _, layer := range layers {
+		var box core.Bbox
+		switch layer.dataType {
+		case "features":
+			if layer.extent != nil && !opts.ScanGeometries {
+				box = *layer.extent
+			} else {
+				box, err = scanGpkgGeometries(db, layer.name)
+			}
+		case "tiles", "2d-gridded-coverage":
+			if layer.extent != nil && !opts.ScanGeometries {
+				box = *layer.extent
+			} else {
+				box, err = readGpkgTileMatrixSet(db, layer.name)
+			}
+		default:
+			// attributes and extensions without geometries
+			if opts.Layer != "" {
+				return core.Bbox{}, nil, fmt.Errorf("GeoPackage layer %s has %s rather than features or tiles", layer.name, layer.dataType)
+			}
+			continue
+		}
+		if errors.Is(err, ErrNoFeaturesFound) {
+			continue
+		} else if err != nil {
+			return core.Bbox{}, nil, fmt.Errorf("GeoPackage layer %s: %w", layer.name, err)
+		}
+
+		crs := srs[layer.srsId]
+		if crs != nil && crs.IsSupported() && !crs.Equal(wgs84) {
+			if box, err = proj.TransformBbox(box, crs, wgs84); err != nil {
+				return core.Bbox{}, nil, fmt.Errorf("GeoPackage layer %s: %w", layer.name, err)
+			}
+			crs = wgs84
+		}
+		if err := union.add(box, crs); err != nil {
+			return core.Bbox{}, nil, fmt.Errorf("GeoPackage layer %s: %w", layer.name, err)
+		}
+	}
+	return union.result()
+}
+
+// requireGpkgTable returns the table, or an error if the GeoPackage doesn't have it
+func requireGpkgTable(db *sqliteDB, name string, columns ...string) (*sqliteTable, []int, error) {
+	table, err := db.table(name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid GeoPackage: %w", err)
+	}
+	if table == nil {
+		return nil, nil, fmt.Errorf("invalid GeoPackage, missing the %s table", name)
+	}
+	indexes := make([]int, len(columns))
+	for i, column := range columns {
+		if indexes[i] = table.column(column); indexes[i] < 0 {
+			return nil, nil, fmt.Errorf("invalid GeoPackage, %s does not have a %s column", name, column)
+		}
+	}
+	return table, indexes, nil
+}
+
+func readGpkgContents(db *sqliteDB) ([]gpkgLayer, error) {
+	table, columns, err := requireGpkgTable(db, "gpkg_contents", "table_name", "data_type", "min_x", "min_y", "max_x", "max_y", "srs_id")
+	if err != nil {
+		return nil, err
+	}
+
+	var layers []gpkgLayer
+	err = table.scan(func(row sqliteRow) error {
+		layer := gpkgLayer{}
+		layer.name, _ = row.value(columns[0]).(string)
+		layer.dataType, _ = row.value(columns[1]).(string)
+		layer.srsId, _ = row.value(columns[6]).(int64)
+		layer.extent = gpkgExtent(row.value(columns[2]), row.value(columns[3]), row.value(columns[4]), row.value(columns[5]))
+		layers = append(layers, layer)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid GeoPackage: %w", err)
+	}
+	return layers, nil
+}
+
+// gpkgExtent returns the extent from its min and max columns, or nil if any of them are NULL.
+// Extents of all zeros are written by some tools when the extent isn't known.
+func gpkgExtent(minX, minY, maxX, maxY any) *core.Bbox {
+	var bounds [4]float64
+	for i, value := range []any{minX, minY, maxX, maxY} {
+		var ok bool
+		if bounds[i], ok = sqliteFloat(value); !ok {
+			return nil
+		}
+	}
+	if bounds == [4]float64{} || boundsInvalid(bounds[0], bounds[1], bounds[2], bounds[3]) {
+		return nil
+	}
+	return &core.Bbox{Left: bounds[0], Bottom: bounds[1], Right: bounds[2], Top: bounds[3]}
+}
+
+// readGpkgSpatialRefSys returns the CRSs by srs_id. The undefined CRSs, -1 and 0, are nil.
+func readGpkgSpatialRefSys(db *sqliteDB) (map[int64]*proj.CRS, error) {
+	table, columns, err := requireGpkgTable(db, "gpkg_spatial_ref_sys", "srs_name", "srs_id", "organization", "organization_coordsys_id", "definition")
+	if err != nil {
+		return nil, err
+	}
+
+	srs := make(map[int64]*proj.CRS)
+	err = table.scan(func(row sqliteRow) error {
+		name, _ := row.value(columns[0]).(string)
+		srsId, _ := row.value(columns[1]).(int64)
+		organization, _ := row.value(columns[2]).(string)
+		code, _ := row.value(columns[3]).(int64)
+		definition, _ := row.value(columns[4]).(string)
+		if srsId == -1 || srsId == 0 {
+			return nil
+		}
+
+		if strings.EqualFold(organization, "EPSG") {
+			if crs, err := proj.Lookup(int(code)); err == nil {
+				srs[srsId] = crs
+				return nil
+			}
+		}
+		if definition != "" && !strings.EqualFold(definition, "undefined") {
+			crs, err := proj.ParseWkt(definition)
+			if err == nil {
+				srs[srsId] = crs
+				return nil
+			}
+			log.Printf("Could not parse GeoPackage CRS %s: %v\n", name, err)
+		}
+		if strings.EqualFold(organization, "EPSG") {
+			srs[srsId] = proj.Unsupported(int(code), name, false)
+		} else {
+			srs[srsId] = proj.Unsupported(0, name, false)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid GeoPackage: %w", err)
+	}
+	return srs, nil
+}
+
+// scanGpkgGeometries computes the bounds of a feature table from the envelopes in the headers of
+// its geometries, or from the geometries themselves when they don't have an envelope, like points
+func scanGpkgGeometries(db *sqliteDB, tableName string) (core.Bbox, error) {
+	geometryColumns, columns, err := requireGpkgTable(db, "gpkg_geometry_columns", "table_name", "column_name")
+	if err != nil {
+		return core.Bbox{}, err
+	}
+	var columnName string
+	err = geometryColumns.scan(func(row sqliteRow) error {
+		if name, _ := row.value(columns[0]).(string); strings.EqualFold(name, tableName) {
+			columnName, _ = row.value(columns[1]).(string)
+		}
+		return nil
+	})
+	if err != nil {
+		return core.Bbox{}, fmt.Errorf("invalid GeoPackage: %w", err)
+	}
+	if columnName == "" {
+		return core.Bbox{}, errors.New("missing from gpkg_geometry_columns")
+	}
+
+	table, column, err := requireGpkgTable(db, tableName, columnName)
+	if err != nil {
+		return core.Bbox{}, err
+	}
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	visit := func(x, y float64) {
+		updateBounds(&minX, &minY, &maxX, &maxY, x, y)
+	}
+	err = table.scan(func(row sqliteRow) error {
+		blob, ok := row.value(column[0]).([]byte)
+		if !ok {
+			// NULL geometry
+			return nil
+		}
+		return gpkgGeometryVertices(blob, visit)
+	})
+	if err != nil {
+		return core.Bbox{}, err
+	}
+	if minX > maxX {
+		return core.Bbox{}, ErrNoFeaturesFound
+	}
+	return core.Bbox{Left: minX, Bottom: minY, Right: maxX, Top: maxY}, nil
+}
+
+// gpkgEnvelopeSizes are the sizes of the envelope in a geometry's header, by the indicator in its flags
+var gpkgEnvelopeSizes = []int{0, 32, 48, 48, 64}
+
+// gpkgGeometryVertices calls visit with the corners of the envelope of a GeoPackage geometry, or
+// with its vertices if it doesn't have an envelope. Empty geometries are skipped.
+func gpkgGeometryVertices(blob []byte, visit func(x, y float64)) error {
+	if len(blob) < 8 || blob[0] != 'G' || blob[1] != 'P' {
+		return errors.New("invalid GeoPackage geometry")
+	}
+	flags := blob[3]
+	if flags&0x10 != 0 {
+		// empty geometry
+		return nil
+	}
+	indicator := int(flags >> 1 & 7)
+	if indicator >= len(gpkgEnvelopeSizes) {
+		return fmt.Errorf("invalid GeoPackage geometry envelope %d", indicator)
+	}
+	envelopeSize := gpkgEnvelopeSizes[indicator]
+	if len(blob) < 8+envelopeSize {
+		return errors.New("truncated GeoPackage geometry")
+	}
+
+	if envelopeSize == 0 {
+		return wkbVertices(blob[8:], visit)
+	}
+
+	var order binary.ByteOrder = binary.BigEndian
+	if flags&1 != 0 {
+		order = binary.LittleEndian
+	}
+	// minx, maxx, miny, maxy, then z and m ranges that aren't needed
+	var envelope [4]float64
+	for i := range envelope {
+		envelope[i] = math.Float64frombits(order.Uint64(blob[8+i*8:]))
+	}
+	if math.IsNaN(envelope[0]) || math.IsNaN(envelope[2]) {
+		return nil
+	}
+	visit(envelope[0], envelope[2])
+	visit(envelope[1], envelope[3])
+	return nil
+}
+
+// readGpkgTileMatrixSet returns the bounds of a tile table's tile matrix set
+func readGpkgTileMatrixSet(db *sqliteDB, tableName string) (core.Bbox, error) {
+	table, columns, err := requireGpkgTable(db, "gpkg_tile_matrix_set", "table_name", "min_x", "min_y", "max_x", "max_y")
+	if err != nil {
+		return core.Bbox{}, err
+	}
+	var extent *core.Bbox
+	err = table.scan(func(row sqliteRow) error {
+		if name, _ := row.value(columns[0]).(string); strings.EqualFold(name, tableName) {
+			extent = gpkgExtent(row.value(columns[1]), row.value(columns[2]), row.value(columns[3]), row.value(columns[4]))
+		}
+		return nil
+	})
+	if err != nil {
+		return core.Bbox{}, fmt.Errorf("invalid GeoPackage: %w", err)
+	}
+	if extent == nil {
+		return core.Bbox{}, ErrNoFeaturesFound
+	}
+	return *extent, nil
+}
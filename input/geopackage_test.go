@@ -0,0 +1,200 @@
+package input
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mikeocool/bbox/core"
+)
+
+// campsitesGpkg has the campsites shapefile's points in a campsites layer, with a stale extent
+// in gpkg_contents, a portage trails layer with a line that overflows its page, an empty tile
+// layer in web mercator and an attributes table
+const campsitesGpkg = "../integration_tests/data/campsites.gpkg"
+
+func TestParseGeopackage(t *testing.T) {
+	data, err := os.ReadFile(campsitesGpkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	campsites := core.Bbox{Left: -92.434258058, Bottom: 47.751725279, Right: -90.021911900, Top: 48.356627624}
+	tests := []struct {
+		name     string
+		opts     ReadOptions
+		want     core.Bbox
+		errorMsg string
+	}{
+		{
+			// the basemap tile matrix set covers the other layers
+			name: "all layers",
+			want: core.Bbox{Left: -92.526474264, Bottom: 47.353704702, Right: -89.831528412, Top: 48.556850522},
+		},
+		{
+			name: "stale contents extent",
+			opts: ReadOptions{Layer: "campsites"},
+			want: core.Bbox{Left: -91.663409025, Bottom: 47.822235617, Right: -90.304583435, Top: 48.295291442},
+		},
+		{
+			name: "scan geometries without envelopes",
+			opts: ReadOptions{Layer: "Campsites", ScanGeometries: true},
+			want: campsites,
+		},
+		{
+			name: "missing contents extent uses envelopes",
+			opts: ReadOptions{Layer: "portage trails"},
+			want: core.Bbox{Left: -92.024278468, Bottom: 47.759526226, Right: -90.167085175, Top: 48.223537946},
+		},
+		{
+			name: "tile matrix set",
+			opts: ReadOptions{Layer: "basemap"},
+			want: core.Bbox{Left: -92.526474264, Bottom: 47.353704702, Right: -89.831528412, Top: 48.556850522},
+		},
+		{
+			name:     "attributes layer",
+			opts:     ReadOptions{Layer: "visits"},
+			errorMsg: "has attributes rather than features or tiles",
+		},
+		{
+			name:     "missing layer",
+			opts:     ReadOptions{Layer: "lakes"},
+			errorMsg: `layer "lakes" not found in GeoPackage, it has: campsites, portage trails, basemap, visits`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, crs, err := ParseGeopackage(bytes.NewReader(data), int64(len(data)), tt.opts)
+			if tt.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("ParseGeopackage() error = %v, want it to contain %q", err, tt.errorMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseGeopackage() unexpected error = %v", err)
+			}
+			if !bboxWithin(got, tt.want, 1e-8) {
+				t.Errorf("ParseGeopackage() = %v, want %v", got, tt.want)
+			}
+			if crs == nil || crs.Code != 4326 {
+				t.Errorf("ParseGeopackage() crs = %v, want EPSG:4326", crs)
+			}
+		})
+	}
+
+	t.Run("truncated", func(t *testing.T) {
+		truncated := data[:len(data)/2]
+		_, _, err := ParseGeopackage(bytes.NewReader(truncated), int64(len(truncated)), ReadOptions{ScanGeometries: true})
+		if err == nil {
+			t.Errorf("ParseGeopackage() expected an error")
+		}
+	})
+
+	t.Run("LoadFile and ParseData", func(t *testing.T) {
+		opts := ReadOptions{Layer: "campsites", ScanGeometries: true}
+		got, _, err := LoadFile(campsitesGpkg, opts)
+		if err != nil || !bboxWithin(got, campsites, 1e-8) {
+			t.Errorf("LoadFile() = %v, %v, want %v", got, err, campsites)
+		}
+
+		got, _, err = ParseData(bytes.NewReader(data), opts)
+		if err != nil || !bboxWithin(got, campsites, 1e-8) {
+			t.Errorf("ParseData() = %v, %v, want %v", got, err, campsites)
+		}
+	})
+
+	t.Run("journal or WAL next to the file", func(t *testing.T) {
+		journalHeader := append(append([]byte{}, sqliteJournalMagic...), make([]byte, 20)...)
+		tests := []struct {
+			suffix   string
+			data     []byte
+			errorMsg string
+		}{
+			{suffix: "-wal", data: []byte("WAL frames"), errorMsg: "has changes in campsites.gpkg-wal that can't be read"},
+			{suffix: "-wal", data: nil},
+			{suffix: "-journal", data: journalHeader, errorMsg: "has an unfinished write in campsites.gpkg-journal"},
+			// a persistent journal that's done with has its header zeroed
+			{suffix: "-journal", data: make([]byte, 28)},
+		}
+		for _, tt := range tests {
+			filename := filepath.Join(t.TempDir(), "campsites.gpkg")
+			if err := os.WriteFile(filename, data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filename+tt.suffix, tt.data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, _, err := LoadFile(filename, ReadOptions{})
+			if tt.errorMsg == "" && err != nil {
+				t.Errorf("LoadFile() with %d bytes in %s unexpected error = %v", len(tt.data), tt.suffix, err)
+			}
+			if tt.errorMsg != "" && (err == nil || !strings.Contains(err.Error(), tt.errorMsg)) {
+				t.Errorf("LoadFile() error = %v, want it to contain %q", err, tt.errorMsg)
+			}
+		}
+	})
+}
+
+// bboxWithin reports whether every side of two boxes is within the tolerance
+func bboxWithin(a, b core.Bbox, tolerance float64) bool {
+	return math.Abs(a.Left-b.Left) < tolerance && math.Abs(a.Bottom-b.Bottom) < tolerance &&
+		math.Abs(a.Right-b.Right) < tolerance && math.Abs(a.Top-b.Top) < tolerance
+}
+
+func TestGpkgGeometryVertices(t *testing.T) {
+	header := func(flags byte) []byte {
+		return append([]byte{'G', 'P', 0, flags}, 0, 0, 0x10, 0xe6)
+	}
+	envelope := header(0b0000_0010)
+	for _, v := range []float64{1, 3, -2, 4} {
+		envelope = binary.BigEndian.AppendUint64(envelope, math.Float64bits(v))
+	}
+	// the WKB isn't read when there's an envelope
+	envelope = append(envelope, 0xff)
+
+	tests := []struct {
+		name    string
+		blob    []byte
+		want    [][2]float64
+		wantErr bool
+	}{
+		{name: "big endian envelope", blob: envelope, want: [][2]float64{{1, -2}, {3, 4}}},
+		{name: "no envelope", blob: append(header(0b0000_0001), wkbPointData(5, 6)...), want: [][2]float64{{5, 6}}},
+		{name: "empty", blob: append(header(0b0001_0001), wkbPointData(math.NaN(), math.NaN())...)},
+		{name: "invalid envelope indicator", blob: append(header(0b0000_1110), make([]byte, 64)...), wantErr: true},
+		{name: "truncated envelope", blob: header(0b0000_0100), wantErr: true},
+		{name: "not a geometry", blob: wkbPointData(1, 2), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got [][2]float64
+			err := gpkgGeometryVertices(tt.blob, func(x, y float64) {
+				got = append(got, [2]float64{x, y})
+			})
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("gpkgGeometryVertices() expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("gpkgGeometryVertices() unexpected error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("gpkgGeometryVertices() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("gpkgGeometryVertices() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,898 @@
+package input
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+	"sort"
+	"strings"
+
+	"github.com/mikeocool/bbox/core"
+	"github.com/mikeocool/bbox/proj"
+)
+
+var parquetMagic = []byte("PAR1")
+
+// parquetMaxSize limits how much is read for the footer or a column chunk of a corrupt file
+const parquetMaxSize = 1 << 30
+
+// Parquet physical types
+const (
+	parquetFloat     = 4
+	parquetDouble    = 5
+	parquetByteArray = 6
+)
+
+// Parquet repetition types
+const (
+	parquetRequired = 0
+	parquetRepeated = 2
+)
+
+// Parquet encodings
+const (
+	parquetPlain           = 0
+	parquetPlainDictionary = 2
+	parquetRle             = 3
+	parquetRleDictionary   = 8
+	parquetByteStreamSplit = 9
+)
+
+// Parquet page types
+const (
+	parquetDataPage       = 0
+	parquetDictionaryPage = 2
+	parquetDataPageV2     = 3
+)
+
+// Parquet compression codecs
+const (
+	parquetUncompressed = 0
+	parquetSnappy       = 1
+	parquetGzip         = 2
+)
+
+var parquetCodecNames = map[int32]string{3: "LZO", 4: "BROTLI", 5: "LZ4", 6: "ZSTD", 7: "LZ4_RAW"}
+
+// ErrUnsupportedParquetCompression is returned when the geometries have to be read from pages that
+// are compressed with a codec other than snappy or gzip, like ZSTD. Files with a bbox in their geo
+// metadata or a covering column with statistics don't need their pages to be read.
+var ErrUnsupportedParquetCompression = errors.New("unsupported parquet compression")
+
+func SniffParquet(data []byte) bool {
+	return bytes.HasPrefix(data, parquetMagic)
+}
+
+// geoparquetMetadata is the geo key in the file's metadata, from the GeoParquet spec
+type geoparquetMetadata struct {
+	PrimaryColumn string                      `json:"primary_column"`
+	Columns       map[string]geoparquetColumn `json:"columns"`
+}
+
+type geoparquetColumn struct {
+	Encoding string    `json:"encoding"`
+	Bbox     []float64 `json:"bbox"`
+	// Crs is PROJJSON, null for an unknown CRS, or missing for OGC:CRS84
+	Crs      json.RawMessage `json:"crs"`
+	Covering *struct {
+		// Bbox has the paths of the xmin, ymin, xmax and ymax columns, like ["bbox", "xmin"]
+		Bbox map[string][]string `json:"bbox"`
+	} `json:"covering"`
+}
+
+// ParseGeoparquet reads the bounds of the geometry columns of a GeoParquet file. For each column, the
+// bbox in the geo metadata is used if it has one, otherwise the statistics of the covering bbox
+// columns, and otherwise the bounds are computed from the WKB geometries. opts.ScanGeometries skips
+// straight to reading the geometries. Only the footer and the column chunks that are needed are read.
+// Pages compressed with anything but snappy or gzip return ErrUnsupportedParquetCompression.
+func ParseGeoparquet(r io.ReaderAt, size int64, opts ReadOptions) (core.Bbox, *proj.CRS, error) {
+	meta, err := readParquetMetadata(r, size)
+	if err != nil {
+		return core.Bbox{}, nil, err
+	}
+
+	geoJson, ok := meta.keyValues["geo"]
+	if !ok {
+		return core.Bbox{}, nil, errors.New("parquet file does not have GeoParquet metadata")
+	}
+	var geo geoparquetMetadata
+	if err := json.Unmarshal([]byte(geoJson), &geo); err != nil {
+		return core.Bbox{}, nil, fmt.Errorf("invalid GeoParquet metadata: %w", err)
+	}
+
+	// the primary column first, so its CRS is used for the union
+	var names []string
+	for name := range geo.Columns {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == geo.PrimaryColumn) != (names[j] == geo.PrimaryColumn) {
+			return names[i] == geo.PrimaryColumn
+		}
+		return names[i] < names[j]
+	})
+
+	var union bboxUnion
+	for _, name := range names {
+		column := geo.Columns[name]
+		box, err := geoparquetColumnBounds(r, meta, name, column, opts)
+		if errors.Is(err, ErrNoFeaturesFound) {
+			continue
+		} else if err != nil {
+			return core.Bbox{}, nil, fmt.Errorf("GeoParquet column %s: %w", name, err)
+		}
+		if err := union.add(box, geoparquetCrs(column.Crs)); err != nil {
+			return core.Bbox{}, nil, fmt.Errorf("GeoParquet column %s: %w", name, err)
+		}
+	}
+	return union.result()
+}
+
+func geoparquetColumnBounds(r io.ReaderAt, meta *parquetMetadata, name string, column geoparquetColumn, opts ReadOptions) (core.Bbox, error) {
+	if !opts.ScanGeometries {
+		switch len(column.Bbox) {
+		case 4:
+			return core.Bbox{Left: column.Bbox[0], Bottom: column.Bbox[1], Right: column.Bbox[2], Top: column.Bbox[3]}, nil
+		case 6:
+			// xmin, ymin, zmin, xmax, ymax, zmax
+			return core.Bbox{Left: column.Bbox[0], Bottom: column.Bbox[1], Right: column.Bbox[3], Top: column.Bbox[4],
+				HasZ: true, MinZ: column.Bbox[2], MaxZ: column.Bbox[5]}, nil
+		}
+
+		if column.Covering != nil && len(column.Covering.Bbox) > 0 {
+			return geoparquetCoveringBounds(r, meta, column.Covering.Bbox)
+		}
+	}
+
+	if !strings.EqualFold(column.Encoding, "WKB") {
+		return core.Bbox{}, fmt.Errorf("unsupported geometry encoding %q without a bbox, only WKB is supported", column.Encoding)
+	}
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	visit := func(x, y float64) {
+		updateBounds(&minX, &minY, &maxX, &maxY, x, y)
+	}
+	err := readParquetColumn(r, meta, []string{name}, func(value []byte) error {
+		return wkbVertices(value, visit)
+	})
+	if err != nil {
+		return core.Bbox{}, err
+	}
+	if minX > maxX {
+		return core.Bbox{}, ErrNoFeaturesFound
+	}
+	return core.Bbox{Left: minX, Bottom: minY, Right: maxX, Top: maxY}, nil
+}
+
+// geoparquetCoveringBounds computes the bounds from the xmin, ymin, xmax and ymax columns of a
+// covering bbox, using the min and max statistics of each column chunk where they're available
+func geoparquetCoveringBounds(r io.ReaderAt, meta *parquetMetadata, covering map[string][]string) (core.Bbox, error) {
+	var bounds [4]float64
+	for i, key := range []string{"xmin", "ymin", "xmax", "ymax"} {
+		path, ok := covering[key]
+		if !ok {
+			return core.Bbox{}, fmt.Errorf("covering bbox does not have %s", key)
+		}
+		useMin := strings.HasSuffix(key, "min")
+		if useMin {
+			bounds[i] = math.Inf(1)
+		} else {
+			bounds[i] = math.Inf(-1)
+		}
+		update := func(v float64) {
+			if useMin {
+				bounds[i] = math.Min(bounds[i], v)
+			} else {
+				bounds[i] = math.Max(bounds[i], v)
+			}
+		}
+
+		for _, rowGroup := range meta.rowGroups {
+			chunk := rowGroup.column(path)
+			if chunk == nil {
+				return core.Bbox{}, fmt.Errorf("covering column %s not found", strings.Join(path, "."))
+			}
+			if stat, ok := chunk.statistic(useMin); ok {
+				update(stat)
+				continue
+			}
+
+			// no statistics, so read the values of the chunk
+			err := readParquetChunk(r, meta, chunk, func(value []byte) error {
+				v, ok := parquetFloatValue(chunk.physicalType, value)
+				if !ok {
+					return fmt.Errorf("covering column %s is not a FLOAT or DOUBLE", strings.Join(path, "."))
+				}
+				update(v)
+				return nil
+			})
+			if err != nil {
+				return core.Bbox{}, err
+			}
+		}
+	}
+
+	if math.IsInf(bounds[0], 0) || math.IsInf(bounds[1], 0) {
+		return core.Bbox{}, ErrNoFeaturesFound
+	}
+	return core.Bbox{Left: bounds[0], Bottom: bounds[1], Right: bounds[2], Top: bounds[3]}, nil
+}
+
+// geoparquetCrs returns the CRS of a geometry column from its PROJJSON. A missing CRS is OGC:CRS84,
+// and a null CRS is unknown, which is returned as nil.
+func geoparquetCrs(raw json.RawMessage) *proj.CRS {
+	if len(raw) == 0 {
+		crs, _ := proj.Lookup(proj.EPSGWgs84)
+		return crs
+	}
+
+	var projjson struct {
+		Type string `json:"type"`
+		Name string `json:"name"`
+		Id   *struct {
+			Authority string          `json:"authority"`
+			Code      json.RawMessage `json:"code"`
+		} `json:"id"`
+	}
+	if err := json.Unmarshal(raw, &projjson); err != nil || string(raw) == "null" {
+		return nil
+	}
+
+	if projjson.Id != nil {
+		code := strings.Trim(string(projjson.Id.Code), `"`)
+		if parsed, err := proj.ParseCode(projjson.Id.Authority + ":" + code); err == nil {
+			if crs, err := proj.Lookup(parsed); err == nil {
+				return crs
+			}
+			return proj.Unsupported(parsed, projjson.Name, projjson.Type == "GeographicCRS")
+		}
+	}
+	return proj.Unsupported(0, projjson.Name, projjson.Type == "GeographicCRS")
+}
+
+// parquetMetadata is the part of a parquet file's FileMetaData needed to read columns
+type parquetMetadata struct {
+	schema    []parquetSchemaElement
+	rowGroups []parquetRowGroup
+	keyValues map[string]string
+}
+
+type parquetSchemaElement struct {
+	name         string
+	physicalType int32
+	repetition   int32
+	numChildren  int32
+}
+
+type parquetRowGroup struct {
+	columns []parquetColumnChunk
+}
+
+type parquetColumnChunk struct {
+	path                 []string
+	physicalType         int32
+	codec                int32
+	numValues            int64
+	dataPageOffset       int64
+	dictionaryPageOffset int64
+	totalCompressedSize  int64
+	min, max             []byte
+}
+
+// column returns the chunk for the column at the path, or nil if there isn't one
+func (g parquetRowGroup) column(path []string) *parquetColumnChunk {
+	for i := range g.columns {
+		if strings.Join(g.columns[i].path, ".") == strings.Join(path, ".") {
+			return &g.columns[i]
+		}
+	}
+	return nil
+}
+
+// statistic returns the min or max statistic of a FLOAT or DOUBLE column chunk
+func (c *parquetColumnChunk) statistic(min bool) (float64, bool) {
+	value := c.max
+	if min {
+		value = c.min
+	}
+	v, ok := parquetFloatValue(c.physicalType, value)
+	return v, ok && !math.IsNaN(v)
+}
+
+// parquetFloatValue decodes a PLAIN encoded FLOAT or DOUBLE value
+func parquetFloatValue(physicalType int32, b []byte) (float64, bool) {
+	switch {
+	case physicalType == parquetDouble && len(b) == 8:
+		return math.Float64frombits(binary.LittleEndian.Uint64(b)), true
+	case physicalType == parquetFloat && len(b) == 4:
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(b))), true
+	}
+	return 0, false
+}
+
+// readParquetMetadata reads the FileMetaData from the footer of a parquet file
+func readParquetMetadata(r io.ReaderAt, size int64) (*parquetMetadata, error) {
+	if size < 12 {
+		return nil, errors.New("parquet file is too short")
+	}
+	tail := make([]byte, 8)
+	if _, err := r.ReadAt(tail, size-8); err != nil {
+		return nil, fmt.Errorf("failed to read parquet footer: %w", err)
+	}
+	if !bytes.Equal(tail[4:], parquetMagic) {
+		return nil, errors.New("invalid parquet file, missing PAR1 footer")
+	}
+	footerSize := int64(binary.LittleEndian.Uint32(tail[:4]))
+	if footerSize > size-12 || footerSize > parquetMaxSize {
+		return nil, fmt.Errorf("invalid parquet footer size %d", footerSize)
+	}
+	footer := make([]byte, footerSize)
+	if _, err := r.ReadAt(footer, size-8-footerSize); err != nil {
+		return nil, fmt.Errorf("failed to read parquet footer: %w", err)
+	}
+
+	meta := &parquetMetadata{keyValues: make(map[string]string)}
+	tr := newThriftReader(footer)
+	err := tr.readStruct(func(field int16, fieldType byte) error {
+		switch {
+		case field == 2 && fieldType == thriftList:
+			return tr.readList(func(byte) error {
+				element, err := readParquetSchemaElement(tr)
+				meta.schema = append(meta.schema, element)
+				return err
+			})
+		case field == 4 && fieldType == thriftList:
+			return tr.readList(func(byte) error {
+				rowGroup, err := readParquetRowGroup(tr)
+				meta.rowGroups = append(meta.rowGroups, rowGroup)
+				return err
+			})
+		case field == 5 && fieldType == thriftList:
+			return tr.readList(func(byte) error {
+				var key, value string
+				err := tr.readStruct(func(field int16, fieldType byte) error {
+					var err error
+					switch {
+					case field == 1 && fieldType == thriftBinary:
+						key, err = tr.string()
+					case field == 2 && fieldType == thriftBinary:
+						value, err = tr.string()
+					default:
+						err = tr.skip(fieldType)
+					}
+					return err
+				})
+				meta.keyValues[key] = value
+				return err
+			})
+		default:
+			return tr.skip(fieldType)
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid parquet metadata: %w", err)
+	}
+	return meta, nil
+}
+
+func readParquetSchemaElement(tr *thriftReader) (parquetSchemaElement, error) {
+	element := parquetSchemaElement{physicalType: -1}
+	err := tr.readStruct(func(field int16, fieldType byte) error {
+		var err error
+		switch {
+		case field == 1 && fieldType == thriftI32:
+			element.physicalType, err = tr.i32()
+		case field == 3 && fieldType == thriftI32:
+			element.repetition, err = tr.i32()
+		case field == 4 && fieldType == thriftBinary:
+			element.name, err = tr.string()
+		case field == 5 && fieldType == thriftI32:
+			element.numChildren, err = tr.i32()
+		default:
+			err = tr.skip(fieldType)
+		}
+		return err
+	})
+	return element, err
+}
+
+func readParquetRowGroup(tr *thriftReader) (parquetRowGroup, error) {
+	var rowGroup parquetRowGroup
+	err := tr.readStruct(func(field int16, fieldType byte) error {
+		if field != 1 || fieldType != thriftList {
+			return tr.skip(fieldType)
+		}
+		return tr.readList(func(byte) error {
+			chunk, err := readParquetColumnChunk(tr)
+			rowGroup.columns = append(rowGroup.columns, chunk)
+			return err
+		})
+	})
+	return rowGroup, err
+}
+
+func readParquetColumnChunk(tr *thriftReader) (parquetColumnChunk, error) {
+	var chunk parquetColumnChunk
+	hasMetadata := false
+	err := tr.readStruct(func(field int16, fieldType byte) error {
+		if field != 3 || fieldType != thriftStruct {
+			return tr.skip(fieldType)
+		}
+		hasMetadata = true
+		return tr.readStruct(func(field int16, fieldType byte) error {
+			var err error
+			switch {
+			case field == 1 && fieldType == thriftI32:
+				chunk.physicalType, err = tr.i32()
+			case field == 3 && fieldType == thriftList:
+				err = tr.readList(func(byte) error {
+					name, err := tr.string()
+					chunk.path = append(chunk.path, name)
+					return err
+				})
+			case field == 4 && fieldType == thriftI32:
+				chunk.codec, err = tr.i32()
+			case field == 5 && fieldType == thriftI64:
+				chunk.numValues, err = tr.i64()
+			case field == 7 && fieldType == thriftI64:
+				chunk.totalCompressedSize, err = tr.i64()
+			case field == 9 && fieldType == thriftI64:
+				chunk.dataPageOffset, err = tr.i64()
+			case field == 11 && fieldType == thriftI64:
+				chunk.dictionaryPageOffset, err = tr.i64()
+			case field == 12 && fieldType == thriftStruct:
+				err = readParquetStatistics(tr, &chunk)
+			default:
+				err = tr.skip(fieldType)
+			}
+			return err
+		})
+	})
+	if err == nil && !hasMetadata {
+		err = errors.New("column chunks in other files are not supported")
+	}
+	return chunk, err
+}
+
+func readParquetStatistics(tr *thriftReader, chunk *parquetColumnChunk) error {
+	var min, max, minValue, maxValue []byte
+	err := tr.readStruct(func(field int16, fieldType byte) error {
+		if fieldType != thriftBinary || field < 1 || field > 6 || field == 3 || field == 4 {
+			return tr.skip(fieldType)
+		}
+		value, err := tr.binary()
+		switch field {
+		case 1:
+			max = value
+		case 2:
+			min = value
+		case 5:
+			maxValue = value
+		case 6:
+			minValue = value
+		}
+		return err
+	})
+	// min_value and max_value replaced the deprecated min and max
+	chunk.min, chunk.max = min, max
+	if minValue != nil && maxValue != nil {
+		chunk.min, chunk.max = minValue, maxValue
+	}
+	return err
+}
+
+// parquetLevels returns the max definition and repetition levels of the column at the path
+func (meta *parquetMetadata) parquetLevels(path []string) (int, int, error) {
+	if len(meta.schema) == 0 {
+		return 0, 0, errors.New("parquet file has no schema")
+	}
+
+	// the schema is a depth first list, where groups have the number of children that follow
+	var walk func(index int, depth int, maxDef int, maxRep int) (int, error)
+	var found bool
+	var foundDef, foundRep int
+	walk = func(index int, depth int, maxDef int, maxRep int) (int, error) {
+		if index >= len(meta.schema) {
+			return 0, errors.New("invalid parquet schema")
+		}
+		element := meta.schema[index]
+		if depth > 0 {
+			if element.repetition != parquetRequired {
+				maxDef++
+			}
+			if element.repetition == parquetRepeated {
+				maxRep++
+			}
+		}
+		matches := depth > 0 && depth <= len(path) && element.name == path[depth-1]
+
+		next := index + 1
+		for i := int32(0); i < element.numChildren; i++ {
+			childDepth := depth + 1
+			if depth > 0 && !matches {
+				// keep walking to find where the group ends, without matching
+				childDepth = len(path) + 2
+			}
+			var err error
+			next, err = walk(next, childDepth, maxDef, maxRep)
+			if err != nil {
+				return 0, err
+			}
+		}
+		if matches && depth == len(path) && element.numChildren == 0 {
+			found, foundDef, foundRep = true, maxDef, maxRep
+		}
+		return next, nil
+	}
+	if _, err := walk(0, 0, 0, 0); err != nil {
+		return 0, 0, err
+	}
+	if !found {
+		return 0, 0, fmt.Errorf("column %s not found in parquet schema", strings.Join(path, "."))
+	}
+	return foundDef, foundRep, nil
+}
+
+// readParquetColumn calls fn with every non-null value of the column at the path, in every row group
+func readParquetColumn(r io.ReaderAt, meta *parquetMetadata, path []string, fn func(value []byte) error) error {
+	for _, rowGroup := range meta.rowGroups {
+		chunk := rowGroup.column(path)
+		if chunk == nil {
+			return fmt.Errorf("column %s not found", strings.Join(path, "."))
+		}
+		if err := readParquetChunk(r, meta, chunk, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parquetPageHeader is the part of a PageHeader needed to read values
+type parquetPageHeader struct {
+	pageType         int32
+	uncompressedSize int32
+	compressedSize   int32
+	numValues        int32
+	encoding         int32
+	// data page v2 only
+	defLevelsLength int32
+	repLevelsLength int32
+	isCompressed    bool
+}
+
+// readParquetChunk calls fn with every non-null value of a column chunk. Values are PLAIN encoded,
+// like in statistics, regardless of how they're encoded in the pages.
+func readParquetChunk(r io.ReaderAt, meta *parquetMetadata, chunk *parquetColumnChunk, fn func(value []byte) error) error {
+	maxDef, maxRep, err := meta.parquetLevels(chunk.path)
+	if err != nil {
+		return err
+	}
+
+	start := chunk.dataPageOffset
+	if chunk.dictionaryPageOffset > 0 && chunk.dictionaryPageOffset < start {
+		start = chunk.dictionaryPageOffset
+	}
+	if chunk.totalCompressedSize < 0 || chunk.totalCompressedSize > parquetMaxSize || start < 0 {
+		return fmt.Errorf("invalid parquet column chunk size %d", chunk.totalCompressedSize)
+	}
+	data := make([]byte, chunk.totalCompressedSize)
+	if _, err := r.ReadAt(data, start); err != nil {
+		return fmt.Errorf("failed to read parquet column chunk: %w", err)
+	}
+
+	var dictionary [][]byte
+	var valuesRead int64
+	pos := 0
+	for valuesRead < chunk.numValues && pos < len(data) {
+		tr := newThriftReader(data[pos:])
+		header, err := readParquetPageHeader(tr)
+		if err != nil {
+			return fmt.Errorf("invalid parquet page header: %w", err)
+		}
+		pos += tr.pos
+		if header.compressedSize < 0 || int(header.compressedSize) > len(data)-pos {
+			return errors.New("truncated parquet page")
+		}
+		page := data[pos : pos+int(header.compressedSize)]
+		pos += int(header.compressedSize)
+
+		switch header.pageType {
+		case parquetDictionaryPage:
+			body, err := decompressParquetPage(chunk.codec, page, header.uncompressedSize)
+			if err != nil {
+				return err
+			}
+			dictionary = nil
+			err = decodeParquetValues(body, parquetPlain, chunk.physicalType, int(header.numValues), nil, func(value []byte) error {
+				dictionary = append(dictionary, value)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		case parquetDataPage, parquetDataPageV2:
+			var levels, values []byte
+			if header.pageType == parquetDataPage {
+				body, err := decompressParquetPage(chunk.codec, page, header.uncompressedSize)
+				if err != nil {
+					return err
+				}
+				levels, values, err = splitParquetLevelsV1(body, maxDef, maxRep)
+				if err != nil {
+					return err
+				}
+			} else {
+				levelsLength := int(header.repLevelsLength) + int(header.defLevelsLength)
+				if header.repLevelsLength < 0 || header.defLevelsLength < 0 || levelsLength > len(page) {
+					return errors.New("invalid parquet page levels")
+				}
+				levels = page[header.repLevelsLength:levelsLength]
+				values = page[levelsLength:]
+				if header.isCompressed {
+					values, err = decompressParquetPage(chunk.codec, values, header.uncompressedSize-int32(levelsLength))
+					if err != nil {
+						return err
+					}
+				}
+			}
+
+			nonNull := int(header.numValues)
+			if maxDef > 0 {
+				defLevels, err := decodeParquetRle(levels, bits.Len(uint(maxDef)), int(header.numValues))
+				if err != nil {
+					return fmt.Errorf("invalid parquet definition levels: %w", err)
+				}
+				nonNull = 0
+				for _, level := range defLevels {
+					if int(level) == maxDef {
+						nonNull++
+					}
+				}
+			}
+
+			if err := decodeParquetValues(values, header.encoding, chunk.physicalType, nonNull, dictionary, fn); err != nil {
+				return err
+			}
+			valuesRead += int64(header.numValues)
+		}
+		// index pages are skipped
+	}
+	return nil
+}
+
+func readParquetPageHeader(tr *thriftReader) (parquetPageHeader, error) {
+	header := parquetPageHeader{isCompressed: true}
+	err := tr.readStruct(func(field int16, fieldType byte) error {
+		var err error
+		switch {
+		case field == 1 && fieldType == thriftI32:
+			header.pageType, err = tr.i32()
+		case field == 2 && fieldType == thriftI32:
+			header.uncompressedSize, err = tr.i32()
+		case field == 3 && fieldType == thriftI32:
+			header.compressedSize, err = tr.i32()
+		case (field == 5 || field == 7 || field == 8) && fieldType == thriftStruct:
+			// data page, dictionary page and data page v2 headers
+			v2 := field == 8
+			err = tr.readStruct(func(field int16, fieldType byte) error {
+				var err error
+				switch {
+				case field == 1 && fieldType == thriftI32:
+					header.numValues, err = tr.i32()
+				case field == 2 && fieldType == thriftI32 && !v2:
+					header.encoding, err = tr.i32()
+				case field == 4 && fieldType == thriftI32 && v2:
+					header.encoding, err = tr.i32()
+				case field == 5 && fieldType == thriftI32 && v2:
+					header.defLevelsLength, err = tr.i32()
+				case field == 6 && fieldType == thriftI32 && v2:
+					header.repLevelsLength, err = tr.i32()
+				case field == 7 && (fieldType == thriftTrue || fieldType == thriftFalse) && v2:
+					header.isCompressed = fieldType == thriftTrue
+				default:
+					err = tr.skip(fieldType)
+				}
+				return err
+			})
+		default:
+			err = tr.skip(fieldType)
+		}
+		return err
+	})
+	return header, err
+}
+
+func decompressParquetPage(codec int32, page []byte, uncompressedSize int32) ([]byte, error) {
+	if uncompressedSize < 0 || uncompressedSize > parquetMaxSize {
+		return nil, fmt.Errorf("invalid parquet page size %d", uncompressedSize)
+	}
+	switch codec {
+	case parquetUncompressed:
+		return page, nil
+	case parquetSnappy:
+		return snappyDecode(page, int(uncompressedSize))
+	case parquetGzip:
+		gz, err := gzip.NewReader(bytes.NewReader(page))
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip parquet page: %w", err)
+		}
+		defer gz.Close()
+		body, err := io.ReadAll(io.LimitReader(gz, int64(uncompressedSize)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip parquet page: %w", err)
+		}
+		return body, nil
+	default:
+		name, ok := parquetCodecNames[codec]
+		if !ok {
+			name = fmt.Sprint(codec)
+		}
+		return nil, fmt.Errorf("%w %s, only snappy and gzip are supported, rewrite the file with one of them or with a bbox in its geo metadata",
+			ErrUnsupportedParquetCompression, name)
+	}
+}
+
+// splitParquetLevelsV1 returns the definition levels and the values of a v1 data page, where each
+// level is prefixed with its length
+func splitParquetLevelsV1(body []byte, maxDef int, maxRep int) ([]byte, []byte, error) {
+	var defLevels []byte
+	for _, maxLevel := range []int{maxRep, maxDef} {
+		if maxLevel == 0 {
+			continue
+		}
+		if len(body) < 4 {
+			return nil, nil, errors.New("truncated parquet page levels")
+		}
+		length := int(binary.LittleEndian.Uint32(body))
+		if length < 0 || length > len(body)-4 {
+			return nil, nil, errors.New("truncated parquet page levels")
+		}
+		defLevels = body[4 : 4+length]
+		body = body[4+length:]
+	}
+	return defLevels, body, nil
+}
+
+// decodeParquetRle decodes n values of the RLE/bit-packing hybrid encoding used for levels and
+// dictionary indices
+func decodeParquetRle(data []byte, bitWidth int, n int) ([]uint32, error) {
+	if bitWidth > 32 {
+		return nil, fmt.Errorf("invalid bit width %d", bitWidth)
+	}
+	values := make([]uint32, 0, n)
+	pos := 0
+	for len(values) < n {
+		header, size := binary.Uvarint(data[pos:])
+		if size <= 0 {
+			return nil, errors.New("truncated RLE data")
+		}
+		pos += size
+
+		if header&1 == 0 {
+			// a run of the same value
+			count := int(header >> 1)
+			byteWidth := (bitWidth + 7) / 8
+			if pos+byteWidth > len(data) {
+				return nil, errors.New("truncated RLE data")
+			}
+			var value uint32
+			for i := 0; i < byteWidth; i++ {
+				value |= uint32(data[pos+i]) << (8 * i)
+			}
+			pos += byteWidth
+			for i := 0; i < count && len(values) < n; i++ {
+				values = append(values, value)
+			}
+		} else {
+			// groups of 8 bit-packed values, least significant bit first
+			count := int(header>>1) * 8
+			if pos+count*bitWidth/8 > len(data) {
+				return nil, errors.New("truncated bit-packed data")
+			}
+			var bitPos int
+			for i := 0; i < count; i++ {
+				var value uint32
+				for b := 0; b < bitWidth; b++ {
+					bit := (data[pos+bitPos/8] >> (bitPos % 8)) & 1
+					value |= uint32(bit) << b
+					bitPos++
+				}
+				if len(values) < n {
+					values = append(values, value)
+				}
+			}
+			pos += count * bitWidth / 8
+		}
+	}
+	return values, nil
+}
+
+// decodeParquetValues calls fn with n values of a page, PLAIN encoded
+func decodeParquetValues(data []byte, encoding int32, physicalType int32, n int, dictionary [][]byte, fn func(value []byte) error) error {
+	switch encoding {
+	case parquetPlain:
+		pos := 0
+		for i := 0; i < n; i++ {
+			var value []byte
+			switch physicalType {
+			case parquetByteArray:
+				if pos+4 > len(data) {
+					return errors.New("truncated parquet values")
+				}
+				length := int(binary.LittleEndian.Uint32(data[pos:]))
+				pos += 4
+				if length < 0 || length > len(data)-pos {
+					return errors.New("truncated parquet values")
+				}
+				value = data[pos : pos+length]
+				pos += length
+			case parquetDouble, parquetFloat:
+				size := 8
+				if physicalType == parquetFloat {
+					size = 4
+				}
+				if pos+size > len(data) {
+					return errors.New("truncated parquet values")
+				}
+				value = data[pos : pos+size]
+				pos += size
+			default:
+				return fmt.Errorf("unsupported parquet physical type %d", physicalType)
+			}
+			if err := fn(value); err != nil {
+				return err
+			}
+		}
+		return nil
+	case parquetPlainDictionary, parquetRleDictionary:
+		if n == 0 {
+			return nil
+		}
+		if len(data) == 0 {
+			return errors.New("truncated parquet dictionary indices")
+		}
+		indices, err := decodeParquetRle(data[1:], int(data[0]), n)
+		if err != nil {
+			return fmt.Errorf("invalid parquet dictionary indices: %w", err)
+		}
+		for _, index := range indices {
+			if int(index) >= len(dictionary) {
+				return fmt.Errorf("invalid parquet dictionary index %d", index)
+			}
+			if err := fn(dictionary[index]); err != nil {
+				return err
+			}
+		}
+		return nil
+	case parquetByteStreamSplit:
+		// byte k of value i is at k*n + i
+		size := 8
+		if physicalType == parquetFloat {
+			size = 4
+		} else if physicalType != parquetDouble {
+			return fmt.Errorf("unsupported parquet physical type %d for BYTE_STREAM_SPLIT", physicalType)
+		}
+		if len(data) < n*size {
+			return errors.New("truncated parquet values")
+		}
+		value := make([]byte, size)
+		for i := 0; i < n; i++ {
+			for k := 0; k < size; k++ {
+				value[k] = data[k*n+i]
+			}
+			if err := fn(value); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported parquet encoding %d", encoding)
+	}
+}
@@ -0,0 +1,519 @@
+package input
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mikeocool/bbox/core"
+)
+
+// thriftField is a field of a thrift compact struct, with its value already encoded
+type thriftField struct {
+	id        int16
+	fieldType byte
+	value     []byte
+}
+
+func zigzagEncode(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
+
+func encodeThriftStruct(fields ...thriftField) []byte {
+	var b []byte
+	var last int16
+	for _, f := range fields {
+		if delta := f.id - last; delta > 0 && delta <= 15 {
+			b = append(b, byte(delta)<<4|f.fieldType)
+		} else {
+			b = append(b, f.fieldType)
+			b = binary.AppendUvarint(b, zigzagEncode(int64(f.id)))
+		}
+		b = append(b, f.value...)
+		last = f.id
+	}
+	return append(b, thriftStop)
+}
+
+func i32Field(id int16, v int32) thriftField {
+	return thriftField{id, thriftI32, binary.AppendUvarint(nil, zigzagEncode(int64(v)))}
+}
+
+func i64Field(id int16, v int64) thriftField {
+	return thriftField{id, thriftI64, binary.AppendUvarint(nil, zigzagEncode(v))}
+}
+
+func binaryField(id int16, v []byte) thriftField {
+	return thriftField{id, thriftBinary, encodeThriftBinary(v)}
+}
+
+func structField(id int16, fields ...thriftField) thriftField {
+	return thriftField{id, thriftStruct, encodeThriftStruct(fields...)}
+}
+
+func listField(id int16, elemType byte, elems ...[]byte) thriftField {
+	var b []byte
+	if len(elems) < 15 {
+		b = append(b, byte(len(elems))<<4|elemType)
+	} else {
+		b = append(b, 0xf0|elemType)
+		b = binary.AppendUvarint(b, uint64(len(elems)))
+	}
+	for _, elem := range elems {
+		b = append(b, elem...)
+	}
+	return thriftField{id, thriftList, b}
+}
+
+func encodeThriftBinary(v []byte) []byte {
+	return append(binary.AppendUvarint(nil, uint64(len(v))), v...)
+}
+
+// snappyEncodeLiterals encodes data as snappy literals, without compressing it
+func snappyEncodeLiterals(data []byte) []byte {
+	b := binary.AppendUvarint(nil, uint64(len(data)))
+	for len(data) > 0 {
+		n := min(len(data), 60)
+		b = append(b, byte(n-1)<<2)
+		b = append(b, data[:n]...)
+		data = data[n:]
+	}
+	return b
+}
+
+func doubleValue(v float64) []byte {
+	return binary.LittleEndian.AppendUint64(nil, math.Float64bits(v))
+}
+
+// parquetTestColumn is a column chunk for buildParquet. The path has one or two elements, and
+// every element is optional.
+type parquetTestColumn struct {
+	path         []string
+	physicalType int32
+	// values are PLAIN encoded, with nil for nulls
+	values     [][]byte
+	stats      bool
+	dictionary bool
+}
+
+// parquetZstd is a codec that isn't supported
+const parquetZstd = 6
+
+// buildParquet writes a parquet file with a data page v1 per column chunk, and a row group
+// for each slice of columns. Every row group must have the same columns.
+func buildParquet(t *testing.T, geo string, codec int32, rowGroups ...[]parquetTestColumn) []byte {
+	t.Helper()
+	compress := func(page []byte) []byte {
+		switch codec {
+		case parquetUncompressed:
+			return page
+		case parquetSnappy:
+			return snappyEncodeLiterals(page)
+		case parquetZstd:
+			// the pages aren't decompressed, so they're left as they are
+			return page
+		default:
+			t.Fatalf("unsupported test codec %d", codec)
+			return nil
+		}
+	}
+	writePage := func(b []byte, header []thriftField, page []byte) []byte {
+		compressed := compress(page)
+		header = append([]thriftField{
+			header[0],
+			i32Field(2, int32(len(page))),
+			i32Field(3, int32(len(compressed))),
+		}, header[1:]...)
+		b = append(b, encodeThriftStruct(header...)...)
+		return append(b, compressed...)
+	}
+
+	data := append([]byte{}, parquetMagic...)
+	var rowGroupStructs [][]byte
+	for _, columns := range rowGroups {
+		var chunks [][]byte
+		for _, column := range columns {
+			start := int64(len(data))
+			maxDef := len(column.path)
+
+			// each definition level is its own RLE run
+			var levels []byte
+			var nonNull [][]byte
+			for _, v := range column.values {
+				level := byte(maxDef)
+				if v == nil {
+					level = 0
+				} else {
+					nonNull = append(nonNull, v)
+				}
+				levels = append(levels, 1<<1, level)
+			}
+
+			encoding := int32(parquetPlain)
+			dictionaryOffset := int64(0)
+			var values []byte
+			if column.dictionary {
+				var dictionary []byte
+				var indices []byte
+				seen := make(map[string]int)
+				for _, v := range nonNull {
+					index, ok := seen[string(v)]
+					if !ok {
+						index = len(seen)
+						seen[string(v)] = index
+						if column.physicalType == parquetByteArray {
+							dictionary = binary.LittleEndian.AppendUint32(dictionary, uint32(len(v)))
+						}
+						dictionary = append(dictionary, v...)
+					}
+					indices = append(indices, 1<<1, byte(index))
+				}
+				dictionaryOffset = int64(len(data))
+				data = writePage(data, []thriftField{
+					i32Field(1, parquetDictionaryPage),
+					structField(7, i32Field(1, int32(len(seen))), i32Field(2, parquetPlain)),
+				}, dictionary)
+				encoding = parquetRleDictionary
+				values = append([]byte{8}, indices...)
+			} else {
+				for _, v := range nonNull {
+					if column.physicalType == parquetByteArray {
+						values = binary.LittleEndian.AppendUint32(values, uint32(len(v)))
+					}
+					values = append(values, v...)
+				}
+			}
+
+			dataOffset := int64(len(data))
+			page := binary.LittleEndian.AppendUint32(nil, uint32(len(levels)))
+			page = append(page, levels...)
+			page = append(page, values...)
+			data = writePage(data, []thriftField{
+				i32Field(1, parquetDataPage),
+				structField(5,
+					i32Field(1, int32(len(column.values))),
+					i32Field(2, encoding),
+					i32Field(3, parquetRle),
+					i32Field(4, parquetRle)),
+			}, page)
+
+			var path [][]byte
+			for _, name := range column.path {
+				path = append(path, encodeThriftBinary([]byte(name)))
+			}
+			meta := []thriftField{
+				i32Field(1, column.physicalType),
+				listField(2, thriftI32, binary.AppendUvarint(nil, zigzagEncode(int64(encoding)))),
+				listField(3, thriftBinary, path...),
+				i32Field(4, codec),
+				i64Field(5, int64(len(column.values))),
+				i64Field(6, int64(len(data))-start),
+				i64Field(7, int64(len(data))-start),
+				i64Field(9, dataOffset),
+			}
+			if dictionaryOffset > 0 {
+				meta = append(meta, i64Field(11, dictionaryOffset))
+			}
+			if column.stats && len(nonNull) > 0 {
+				minValue, maxValue := nonNull[0], nonNull[0]
+				for _, v := range nonNull {
+					f, _ := parquetFloatValue(column.physicalType, v)
+					if minF, _ := parquetFloatValue(column.physicalType, minValue); f < minF {
+						minValue = v
+					}
+					if maxF, _ := parquetFloatValue(column.physicalType, maxValue); f > maxF {
+						maxValue = v
+					}
+				}
+				meta = append(meta, structField(12, binaryField(5, maxValue), binaryField(6, minValue)))
+			}
+			chunks = append(chunks, encodeThriftStruct(i64Field(2, start), structField(3, meta...)))
+		}
+		rowGroupStructs = append(rowGroupStructs, encodeThriftStruct(
+			listField(1, thriftStruct, chunks...),
+			i64Field(2, 0),
+			i64Field(3, int64(len(columns[0].values))),
+		))
+	}
+
+	// the schema is a depth first list of the root and the columns, with groups for nested paths
+	var schema [][]byte
+	var topLevel int
+	groups := make(map[string][]parquetTestColumn)
+	var order []string
+	for _, column := range rowGroups[0] {
+		if _, ok := groups[column.path[0]]; !ok {
+			order = append(order, column.path[0])
+			topLevel++
+		}
+		groups[column.path[0]] = append(groups[column.path[0]], column)
+	}
+	leaf := func(name string, physicalType int32) []byte {
+		return encodeThriftStruct(i32Field(1, physicalType), i32Field(3, 1), binaryField(4, []byte(name)))
+	}
+	schema = append(schema, encodeThriftStruct(binaryField(4, []byte("schema")), i32Field(5, int32(topLevel))))
+	for _, name := range order {
+		columns := groups[name]
+		if len(columns[0].path) == 1 {
+			schema = append(schema, leaf(name, columns[0].physicalType))
+			continue
+		}
+		schema = append(schema, encodeThriftStruct(i32Field(3, 1), binaryField(4, []byte(name)), i32Field(5, int32(len(columns)))))
+		for _, column := range columns {
+			schema = append(schema, leaf(column.path[1], column.physicalType))
+		}
+	}
+
+	keyValues := [][]byte{encodeThriftStruct(binaryField(1, []byte("other")), binaryField(2, []byte("value")))}
+	if geo != "" {
+		keyValues = append(keyValues, encodeThriftStruct(binaryField(1, []byte("geo")), binaryField(2, []byte(geo))))
+	}
+	footer := encodeThriftStruct(
+		i32Field(1, 2),
+		listField(2, thriftStruct, schema...),
+		i64Field(3, 0),
+		listField(4, thriftStruct, rowGroupStructs...),
+		listField(5, thriftStruct, keyValues...),
+	)
+	data = append(data, footer...)
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(footer)))
+	return append(data, parquetMagic...)
+}
+
+func geometryColumn(values ...[]byte) parquetTestColumn {
+	return parquetTestColumn{path: []string{"geometry"}, physicalType: parquetByteArray, values: values}
+}
+
+func coveringColumns(stats bool, boxes ...*core.Bbox) []parquetTestColumn {
+	columns := []parquetTestColumn{
+		{path: []string{"bbox", "xmin"}, physicalType: parquetDouble, stats: stats},
+		{path: []string{"bbox", "ymin"}, physicalType: parquetDouble, stats: stats},
+		{path: []string{"bbox", "xmax"}, physicalType: parquetDouble, stats: stats},
+		{path: []string{"bbox", "ymax"}, physicalType: parquetDouble, stats: stats},
+	}
+	for _, box := range boxes {
+		if box == nil {
+			for i := range columns {
+				columns[i].values = append(columns[i].values, nil)
+			}
+			continue
+		}
+		for i, v := range []float64{box.Left, box.Bottom, box.Right, box.Top} {
+			columns[i].values = append(columns[i].values, doubleValue(v))
+		}
+	}
+	return columns
+}
+
+const geoWkb = `{"version": "1.1.0", "primary_column": "geometry", "columns": {"geometry": {"encoding": "WKB", "geometry_types": []}}}`
+
+const geoCovering = `{"version": "1.1.0", "primary_column": "geometry", "columns": {"geometry": {"encoding": "WKB",
+	"covering": {"bbox": {"xmin": ["bbox", "xmin"], "ymin": ["bbox", "ymin"], "xmax": ["bbox", "xmax"], "ymax": ["bbox", "ymax"]}}}}}`
+
+func TestParseGeoparquet(t *testing.T) {
+	line := appendWkb(nil, binary.LittleEndian, wkbLineString)
+	line = binary.LittleEndian.AppendUint32(line, 2)
+	line = appendWkbCoords(line, binary.LittleEndian, -93.3, 44.9, -93.1, 45.2)
+
+	utmGeo := `{"version": "1.0.0", "primary_column": "geometry", "columns": {"geometry": {"encoding": "WKB",
+		"crs": {"type": "ProjectedCRS", "name": "WGS 84 / UTM zone 15N", "id": {"authority": "EPSG", "code": 32615}}}}}`
+
+	tests := []struct {
+		name     string
+		data     []byte
+		opts     ReadOptions
+		want     core.Bbox
+		wantCrs  int
+		wantNil  bool
+		wantErr  error
+		errorMsg string
+	}{
+		{
+			name: "metadata bbox",
+			data: buildParquet(t, `{"version": "1.0.0", "primary_column": "geometry", "columns": {"geometry": {"encoding": "WKB", "bbox": [-10, -5, 10, 5]}}}`,
+				parquetUncompressed, []parquetTestColumn{geometryColumn(wkbPointData(1, 2))}),
+			want:    core.Bbox{Left: -10, Bottom: -5, Right: 10, Top: 5},
+			wantCrs: 4326,
+		},
+		{
+			name: "3D metadata bbox",
+			data: buildParquet(t, `{"primary_column": "geometry", "columns": {"geometry": {"encoding": "WKB", "bbox": [-10, -5, 0, 10, 5, 100]}}}`,
+				parquetUncompressed, []parquetTestColumn{geometryColumn(wkbPointData(1, 2))}),
+			want:    core.Bbox{Left: -10, Bottom: -5, Right: 10, Top: 5, HasZ: true, MinZ: 0, MaxZ: 100},
+			wantCrs: 4326,
+		},
+		{
+			name: "scan geometries ignores metadata bbox",
+			data: buildParquet(t, `{"primary_column": "geometry", "columns": {"geometry": {"encoding": "WKB", "bbox": [-10, -5, 10, 5]}}}`,
+				parquetUncompressed, []parquetTestColumn{geometryColumn(wkbPointData(1, 2), wkbPointData(3, 4))}),
+			opts:    ReadOptions{ScanGeometries: true},
+			want:    core.Bbox{Left: 1, Bottom: 2, Right: 3, Top: 4},
+			wantCrs: 4326,
+		},
+		{
+			name: "WKB with nulls",
+			data: buildParquet(t, geoWkb, parquetUncompressed,
+				[]parquetTestColumn{geometryColumn(wkbPointData(-93.2, 45), nil, line)}),
+			want:    core.Bbox{Left: -93.3, Bottom: 44.9, Right: -93.1, Top: 45.2},
+			wantCrs: 4326,
+		},
+		{
+			name: "WKB snappy and multiple row groups",
+			data: buildParquet(t, geoWkb, parquetSnappy,
+				[]parquetTestColumn{geometryColumn(wkbPointData(1, 1), wkbPointData(2, 2))},
+				[]parquetTestColumn{geometryColumn(nil, line)}),
+			want:    core.Bbox{Left: -93.3, Bottom: 1, Right: 2, Top: 45.2},
+			wantCrs: 4326,
+		},
+		{
+			name: "WKB dictionary",
+			data: buildParquet(t, geoWkb, parquetSnappy, []parquetTestColumn{{
+				path:         []string{"geometry"},
+				physicalType: parquetByteArray,
+				values:       [][]byte{wkbPointData(5, 6), wkbPointData(-1, 0), wkbPointData(5, 6)},
+				dictionary:   true,
+			}}),
+			want:    core.Bbox{Left: -1, Bottom: 0, Right: 5, Top: 6},
+			wantCrs: 4326,
+		},
+		{
+			name: "covering statistics",
+			data: buildParquet(t, geoCovering, parquetUncompressed,
+				append(coveringColumns(true, &core.Bbox{Left: 1, Bottom: 2, Right: 3, Top: 4}, &core.Bbox{Left: -1, Bottom: 0, Right: 0, Top: 1}),
+					// the geometries disagree, to check the statistics are used
+					geometryColumn(wkbPointData(100, 100), wkbPointData(100, 100)))),
+			want:    core.Bbox{Left: -1, Bottom: 0, Right: 3, Top: 4},
+			wantCrs: 4326,
+		},
+		{
+			name: "covering values without statistics",
+			data: buildParquet(t, geoCovering, parquetSnappy,
+				append(coveringColumns(false, &core.Bbox{Left: 1, Bottom: 2, Right: 3, Top: 4}, nil, &core.Bbox{Left: 2, Bottom: -2, Right: 5, Top: 3}),
+					geometryColumn(wkbPointData(100, 100), nil, wkbPointData(100, 100)))),
+			want:    core.Bbox{Left: 1, Bottom: -2, Right: 5, Top: 4},
+			wantCrs: 4326,
+		},
+		{
+			name: "PROJJSON CRS",
+			data: buildParquet(t, utmGeo, parquetUncompressed,
+				[]parquetTestColumn{geometryColumn(wkbPointData(500000, 4970000))}),
+			want:    core.Bbox{Left: 500000, Bottom: 4970000, Right: 500000, Top: 4970000},
+			wantCrs: 32615,
+		},
+		{
+			name: "null CRS",
+			data: buildParquet(t, `{"primary_column": "geometry", "columns": {"geometry": {"encoding": "WKB", "crs": null}}}`,
+				parquetUncompressed, []parquetTestColumn{geometryColumn(wkbPointData(1, 2))}),
+			want:    core.Bbox{Left: 1, Bottom: 2, Right: 1, Top: 2},
+			wantNil: true,
+		},
+		{
+			name: "only null geometries",
+			data: buildParquet(t, geoWkb, parquetUncompressed,
+				[]parquetTestColumn{geometryColumn(nil, nil)}),
+			wantErr: ErrNoFeaturesFound,
+		},
+		{
+			name:     "no geo metadata",
+			data:     buildParquet(t, "", parquetUncompressed, []parquetTestColumn{geometryColumn(wkbPointData(1, 2))}),
+			errorMsg: "does not have GeoParquet metadata",
+		},
+		{
+			name: "native encoding without bbox",
+			data: buildParquet(t, `{"primary_column": "geometry", "columns": {"geometry": {"encoding": "point"}}}`,
+				parquetUncompressed, []parquetTestColumn{geometryColumn(wkbPointData(1, 2))}),
+			errorMsg: "unsupported geometry encoding",
+		},
+		{
+			name: "ZSTD metadata bbox",
+			data: buildParquet(t, `{"primary_column": "geometry", "columns": {"geometry": {"encoding": "WKB", "bbox": [-10, -5, 10, 5]}}}`,
+				parquetZstd, []parquetTestColumn{geometryColumn(wkbPointData(1, 2))}),
+			want:    core.Bbox{Left: -10, Bottom: -5, Right: 10, Top: 5},
+			wantCrs: 4326,
+		},
+		{
+			name:     "ZSTD geometries",
+			data:     buildParquet(t, geoWkb, parquetZstd, []parquetTestColumn{geometryColumn(wkbPointData(1, 2))}),
+			wantErr:  ErrUnsupportedParquetCompression,
+			errorMsg: "unsupported parquet compression ZSTD",
+		},
+		{
+			name:     "truncated",
+			data:     []byte("PAR1\x00\x00\x00\x00PAR1"),
+			errorMsg: "invalid parquet metadata",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, crs, err := ParseGeoparquet(bytes.NewReader(tt.data), int64(len(tt.data)), tt.opts)
+			if tt.wantErr != nil || tt.errorMsg != "" {
+				if err == nil {
+					t.Fatalf("ParseGeoparquet() expected an error")
+				}
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Errorf("ParseGeoparquet() error = %v, want %v", err, tt.wantErr)
+				}
+				if !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("ParseGeoparquet() error = %v, want it to contain %q", err, tt.errorMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseGeoparquet() unexpected error = %v", err)
+			}
+			if !bboxAlmostEqual(got, tt.want) {
+				t.Errorf("ParseGeoparquet() = %v, want %v", got, tt.want)
+			}
+			if tt.wantNil {
+				if crs != nil {
+					t.Errorf("ParseGeoparquet() crs = %v, want nil", crs)
+				}
+			} else if crs == nil || crs.Code != tt.wantCrs {
+				t.Errorf("ParseGeoparquet() crs = %v, want EPSG:%d", crs, tt.wantCrs)
+			}
+		})
+	}
+}
+
+func TestLoadFileGeoparquet(t *testing.T) {
+	data := buildParquet(t, geoWkb, parquetSnappy,
+		[]parquetTestColumn{geometryColumn(wkbPointData(-93.2, 45), wkbPointData(-93.3, 44.9))})
+	want := core.Bbox{Left: -93.3, Bottom: 44.9, Right: -93.2, Top: 45}
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "points.parquet")
+	if err := os.WriteFile(filename, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, crs, err := LoadFile(filename, ReadOptions{})
+	if err != nil {
+		t.Fatalf("LoadFile() unexpected error = %v", err)
+	}
+	if got != want || crs == nil || crs.Code != 4326 {
+		t.Errorf("LoadFile() = %v %v, want %v EPSG:4326", got, crs, want)
+	}
+
+	// detected from its contents
+	got, _, err = ParseData(bytes.NewReader(data), ReadOptions{})
+	if err != nil {
+		t.Fatalf("ParseData() unexpected error = %v", err)
+	}
+	if got != want {
+		t.Errorf("ParseData() = %v, want %v", got, want)
+	}
+
+	got, _, err = ParseZip(buildZip(t, []archiveMember{{"data/points.parquet", data}}), ReadOptions{})
+	if err != nil {
+		t.Fatalf("ParseZip() unexpected error = %v", err)
+	}
+	if got != want {
+		t.Errorf("ParseZip() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,376 @@
+package input
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/mikeocool/bbox/core"
+	"github.com/mikeocool/bbox/proj"
+)
+
+// TIFF tags
+const (
+	tiffImageWidth          = 256
+	tiffImageLength         = 257
+	tiffModelPixelScale     = 33550
+	tiffModelTiepoint       = 33922
+	tiffModelTransformation = 34264
+	tiffGeoKeyDirectory     = 34735
+	tiffGeoAsciiParams      = 34737
+)
+
+// TIFF field types
+const (
+	tiffAscii  = 2
+	tiffShort  = 3
+	tiffLong   = 4
+	tiffDouble = 12
+	tiffLong8  = 16
+)
+
+var tiffTypeSizes = map[uint16]uint64{1: 1, tiffAscii: 1, tiffShort: 2, tiffLong: 4, 5: 8, 6: 1, 7: 1, 8: 2, 9: 4, 10: 8, 11: 4, tiffDouble: 8, tiffLong8: 8, 17: 8, 18: 8}
+
+// GeoTIFF keys and values
+const (
+	geoKeyModelType       = 1024
+	geoKeyRasterType      = 1025
+	geoKeyCitation        = 1026
+	geoKeyGeographicType  = 2048
+	geoKeyProjectedType   = 3072
+	geoModelProjected     = 1
+	geoModelGeographic    = 2
+	geoRasterPixelIsPoint = 2
+	geoUserDefined        = 32767
+)
+
+// tiffMaxTagSize limits how much is read for a tag of a corrupt file
+const tiffMaxTagSize = 1 << 26
+
+// SniffTiff checks for a classic TIFF or BigTIFF header
+func SniffTiff(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("II*\x00")) || bytes.HasPrefix(data, []byte("MM\x00*")) ||
+		bytes.HasPrefix(data, []byte("II+\x00")) || bytes.HasPrefix(data, []byte("MM\x00+"))
+}
+
+// tiffReader reads the tags of a classic TIFF or BigTIFF
+type tiffReader struct {
+	r     io.ReaderAt
+	size  int64
+	order binary.ByteOrder
+	big   bool
+}
+
+type tiffEntry struct {
+	fieldType uint16
+	count     uint64
+	// data is the value, or the offset of the value if it doesn't fit in the entry
+	data []byte
+}
+
+// ParseGeotiff reads the footprint of the first image of a GeoTIFF from its tags, without reading
+// any pixels. The corners of the image are transformed to model coordinates with the
+// ModelTransformation tag, or with the ModelTiepoint and ModelPixelScale tags, and the CRS comes
+// from the EPSG code in the GeoKeyDirectory.
+func ParseGeotiff(r io.ReaderAt, size int64) (core.Bbox, *proj.CRS, error) {
+	t, ifdOffset, err := newTiffReader(r, size)
+	if err != nil {
+		return core.Bbox{}, nil, err
+	}
+	entries, err := t.readIfd(ifdOffset)
+	if err != nil {
+		return core.Bbox{}, nil, err
+	}
+
+	width, err := t.uint(entries, tiffImageWidth)
+	if err != nil {
+		return core.Bbox{}, nil, err
+	}
+	height, err := t.uint(entries, tiffImageLength)
+	if err != nil {
+		return core.Bbox{}, nil, err
+	}
+
+	geoKeys, citation, err := t.geoKeys(entries)
+	if err != nil {
+		return core.Bbox{}, nil, err
+	}
+
+	transform, err := t.modelTransform(entries)
+	if err != nil {
+		return core.Bbox{}, nil, err
+	}
+
+	// with PixelIsPoint the model coordinates are for the center of pixels, rather than their
+	// corner, so the footprint starts half a pixel before
+	var shift float64
+	if geoKeys[geoKeyRasterType] == geoRasterPixelIsPoint {
+		shift = -0.5
+	}
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, corner := range [][2]float64{{0, 0}, {float64(width), 0}, {0, float64(height)}, {float64(width), float64(height)}} {
+		x, y := transform(corner[0]+shift, corner[1]+shift)
+		updateBounds(&minX, &minY, &maxX, &maxY, x, y)
+	}
+	if boundsInvalid(minX, minY, maxX, maxY) {
+		return core.Bbox{}, nil, errors.New("invalid GeoTIFF georeferencing")
+	}
+	return core.Bbox{Left: minX, Bottom: minY, Right: maxX, Top: maxY}, geotiffCrs(geoKeys, citation), nil
+}
+
+func newTiffReader(r io.ReaderAt, size int64) (*tiffReader, uint64, error) {
+	header := make([]byte, 16)
+	n, err := r.ReadAt(header, 0)
+	if n < 8 || !SniffTiff(header) {
+		if err != nil && err != io.EOF {
+			return nil, 0, fmt.Errorf("failed to read TIFF header: %w", err)
+		}
+		return nil, 0, errors.New("invalid TIFF file")
+	}
+
+	t := &tiffReader{r: r, size: size, order: binary.LittleEndian}
+	if header[0] == 'M' {
+		t.order = binary.BigEndian
+	}
+	if t.order.Uint16(header[2:]) == 42 {
+		return t, uint64(t.order.Uint32(header[4:])), nil
+	}
+	// BigTIFF has 8 byte offsets
+	if n < 16 || t.order.Uint16(header[4:]) != 8 {
+		return nil, 0, errors.New("invalid BigTIFF header")
+	}
+	t.big = true
+	return t, t.order.Uint64(header[8:]), nil
+}
+
+func (t *tiffReader) read(offset uint64, size uint64) ([]byte, error) {
+	if size > tiffMaxTagSize || offset > uint64(t.size) || size > uint64(t.size)-offset {
+		return nil, errors.New("invalid TIFF file, offset is past the end of the file")
+	}
+	data := make([]byte, size)
+	if _, err := t.r.ReadAt(data, int64(offset)); err != nil {
+		return nil, fmt.Errorf("failed to read TIFF file: %w", err)
+	}
+	return data, nil
+}
+
+// readIfd reads the entries of an image file directory, by tag
+func (t *tiffReader) readIfd(offset uint64) (map[uint16]tiffEntry, error) {
+	countSize, entrySize, valueSize := uint64(2), uint64(12), 4
+	if t.big {
+		countSize, entrySize, valueSize = 8, 20, 8
+	}
+	countData, err := t.read(offset, countSize)
+	if err != nil {
+		return nil, err
+	}
+	var count uint64
+	if t.big {
+		count = t.order.Uint64(countData)
+	} else {
+		count = uint64(t.order.Uint16(countData))
+	}
+	data, err := t.read(offset+countSize, count*entrySize)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[uint16]tiffEntry)
+	for i := uint64(0); i < count; i++ {
+		entry := data[i*entrySize:]
+		tag := t.order.Uint16(entry)
+		e := tiffEntry{fieldType: t.order.Uint16(entry[2:])}
+		if t.big {
+			e.count = t.order.Uint64(entry[4:])
+			e.data = entry[12 : 12+valueSize]
+		} else {
+			e.count = uint64(t.order.Uint32(entry[4:]))
+			e.data = entry[8 : 8+valueSize]
+		}
+		entries[tag] = e
+	}
+	return entries, nil
+}
+
+// values returns the raw bytes of an entry's values, reading them from their offset if they
+// don't fit in the entry
+func (t *tiffReader) values(e tiffEntry) ([]byte, error) {
+	typeSize, ok := tiffTypeSizes[e.fieldType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported TIFF field type %d", e.fieldType)
+	}
+	if e.count > tiffMaxTagSize {
+		return nil, errors.New("invalid TIFF tag size")
+	}
+	size := e.count * typeSize
+	if size <= uint64(len(e.data)) {
+		return e.data[:size], nil
+	}
+	var offset uint64
+	if t.big {
+		offset = t.order.Uint64(e.data)
+	} else {
+		offset = uint64(t.order.Uint32(e.data))
+	}
+	return t.read(offset, size)
+}
+
+// uints returns the values of a SHORT, LONG or LONG8 tag, or nil if the tag isn't set
+func (t *tiffReader) uints(entries map[uint16]tiffEntry, tag uint16) ([]uint64, error) {
+	e, ok := entries[tag]
+	if !ok {
+		return nil, nil
+	}
+	data, err := t.values(e)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]uint64, e.count)
+	for i := range values {
+		switch e.fieldType {
+		case tiffShort:
+			values[i] = uint64(t.order.Uint16(data[i*2:]))
+		case tiffLong:
+			values[i] = uint64(t.order.Uint32(data[i*4:]))
+		case tiffLong8:
+			values[i] = t.order.Uint64(data[i*8:])
+		default:
+			return nil, fmt.Errorf("TIFF tag %d has type %d rather than an integer", tag, e.fieldType)
+		}
+	}
+	return values, nil
+}
+
+// uint returns the single value of an integer tag, which is required
+func (t *tiffReader) uint(entries map[uint16]tiffEntry, tag uint16) (uint64, error) {
+	values, err := t.uints(entries, tag)
+	if err != nil {
+		return 0, err
+	}
+	if len(values) != 1 {
+		return 0, fmt.Errorf("invalid TIFF file, missing tag %d", tag)
+	}
+	return values[0], nil
+}
+
+// doubles returns the values of a DOUBLE tag, or nil if the tag isn't set
+func (t *tiffReader) doubles(entries map[uint16]tiffEntry, tag uint16) ([]float64, error) {
+	e, ok := entries[tag]
+	if !ok {
+		return nil, nil
+	}
+	if e.fieldType != tiffDouble {
+		return nil, fmt.Errorf("TIFF tag %d has type %d rather than DOUBLE", tag, e.fieldType)
+	}
+	data, err := t.values(e)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]float64, e.count)
+	for i := range values {
+		values[i] = math.Float64frombits(t.order.Uint64(data[i*8:]))
+	}
+	return values, nil
+}
+
+// modelTransform returns the transformation from raster to model coordinates
+func (t *tiffReader) modelTransform(entries map[uint16]tiffEntry) (func(i, j float64) (float64, float64), error) {
+	matrix, err := t.doubles(entries, tiffModelTransformation)
+	if err != nil {
+		return nil, err
+	}
+	if len(matrix) >= 16 {
+		// a 4x4 matrix in row major order, of which the 2D affine part is used
+		return func(i, j float64) (float64, float64) {
+			return matrix[0]*i + matrix[1]*j + matrix[3], matrix[4]*i + matrix[5]*j + matrix[7]
+		}, nil
+	}
+
+	tiepoints, err := t.doubles(entries, tiffModelTiepoint)
+	if err != nil {
+		return nil, err
+	}
+	scale, err := t.doubles(entries, tiffModelPixelScale)
+	if err != nil {
+		return nil, err
+	}
+	if len(tiepoints) < 6 {
+		return nil, errors.New("TIFF file is not georeferenced, it does not have a ModelTiepoint or ModelTransformation tag")
+	}
+	if len(scale) < 2 {
+		return nil, errors.New("GeoTIFFs georeferenced with ground control points, rather than a ModelPixelScale, are not supported")
+	}
+
+	// the first tiepoint is (I, J, K, X, Y, Z), and the y axis of the raster points down
+	return func(i, j float64) (float64, float64) {
+		return tiepoints[3] + (i-tiepoints[0])*scale[0], tiepoints[4] - (j-tiepoints[1])*scale[1]
+	}, nil
+}
+
+// geoKeys returns the SHORT values of the GeoKeyDirectory by key, and the citation from GeoAsciiParams
+func (t *tiffReader) geoKeys(entries map[uint16]tiffEntry) (map[uint64]uint64, string, error) {
+	directory, err := t.uints(entries, tiffGeoKeyDirectory)
+	if err != nil {
+		return nil, "", err
+	}
+	var ascii []byte
+	if e, ok := entries[tiffGeoAsciiParams]; ok && e.fieldType == tiffAscii {
+		ascii, err = t.values(e)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	keys, citation := parseGeoKeys(directory, ascii)
+	return keys, citation, nil
+}
+
+// parseGeoKeys returns the SHORT values of a GeoKeyDirectory by key, and the citation from the
+// GeoAsciiParams. LAS files store the same directory as GeoTIFF.
+func parseGeoKeys(directory []uint64, ascii []byte) (map[uint64]uint64, string) {
+	keys := make(map[uint64]uint64)
+	if len(directory) < 4 {
+		return keys, ""
+	}
+
+	var citation string
+	// a header of the version, revision, minor revision and number of keys, then 4 values for each key:
+	// the key, the tag the value is in or 0 if it's the 4th value, the count and the value or offset
+	count := min(int(directory[3]), (len(directory)-4)/4)
+	for i := 0; i < count; i++ {
+		key := directory[4+i*4 : 8+i*4]
+		switch key[1] {
+		case 0:
+			keys[key[0]] = key[3]
+		case tiffGeoAsciiParams:
+			if key[0] == geoKeyCitation && key[3]+key[2] <= uint64(len(ascii)) {
+				// strings in GeoAsciiParams end with a |
+				citation = string(bytes.TrimRight(ascii[key[3]:key[3]+key[2]], "|\x00"))
+			}
+		}
+	}
+	return keys, citation
+}
+
+// geotiffCrs returns the CRS from the geo keys, or nil if there isn't one
+func geotiffCrs(keys map[uint64]uint64, citation string) *proj.CRS {
+	code := keys[geoKeyProjectedType]
+	geographic := false
+	if keys[geoKeyModelType] == geoModelGeographic || code == 0 {
+		code = keys[geoKeyGeographicType]
+		geographic = true
+	}
+	if code == 0 {
+		return nil
+	}
+	if code == geoUserDefined {
+		return proj.Unsupported(0, citation, geographic)
+	}
+	if crs, err := proj.Lookup(int(code)); err == nil {
+		return crs
+	}
+	return proj.Unsupported(int(code), citation, geographic)
+}
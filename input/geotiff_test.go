@@ -0,0 +1,266 @@
+package input
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/mikeocool/bbox/core"
+)
+
+type tiffTag struct {
+	tag       uint16
+	fieldType uint16
+	values    any // []uint16, []uint32, []float64 or string
+}
+
+// buildTiff writes a TIFF with one IFD of the tags, and their values after it
+func buildTiff(order binary.AppendByteOrder, big bool, tags []tiffTag) []byte {
+	sort.Slice(tags, func(i, j int) bool { return tags[i].tag < tags[j].tag })
+
+	var data []byte
+	if order == binary.LittleEndian {
+		data = []byte("II")
+	} else {
+		data = []byte("MM")
+	}
+	headerSize, entrySize, countSize, valueSize := 8, 12, 2, 4
+	if big {
+		headerSize, entrySize, countSize, valueSize = 16, 20, 8, 8
+		data = order.AppendUint16(data, 43)
+		data = order.AppendUint16(data, 8)
+		data = order.AppendUint16(data, 0)
+		data = order.AppendUint64(data, uint64(headerSize))
+	} else {
+		data = order.AppendUint16(data, 42)
+		data = order.AppendUint32(data, uint32(headerSize))
+	}
+
+	var ifd, values []byte
+	if big {
+		ifd = order.AppendUint64(ifd, uint64(len(tags)))
+	} else {
+		ifd = order.AppendUint16(ifd, uint16(len(tags)))
+	}
+	valuesOffset := headerSize + countSize + len(tags)*entrySize + valueSize
+	for _, tag := range tags {
+		var value []byte
+		var count int
+		switch v := tag.values.(type) {
+		case []uint16:
+			for _, n := range v {
+				value = order.AppendUint16(value, n)
+			}
+			count = len(v)
+		case []uint32:
+			for _, n := range v {
+				value = order.AppendUint32(value, n)
+			}
+			count = len(v)
+		case []float64:
+			for _, n := range v {
+				value = order.AppendUint64(value, math.Float64bits(n))
+			}
+			count = len(v)
+		case string:
+			value = append([]byte(v), 0)
+			count = len(value)
+		}
+
+		ifd = order.AppendUint16(ifd, tag.tag)
+		ifd = order.AppendUint16(ifd, tag.fieldType)
+		if big {
+			ifd = order.AppendUint64(ifd, uint64(count))
+		} else {
+			ifd = order.AppendUint32(ifd, uint32(count))
+		}
+		if len(value) <= valueSize {
+			ifd = append(ifd, value...)
+			ifd = append(ifd, make([]byte, valueSize-len(value))...)
+			continue
+		}
+		offset := valuesOffset + len(values)
+		if big {
+			ifd = order.AppendUint64(ifd, uint64(offset))
+		} else {
+			ifd = order.AppendUint32(ifd, uint32(offset))
+		}
+		values = append(values, value...)
+	}
+	// no next IFD
+	ifd = append(ifd, make([]byte, valueSize)...)
+
+	return append(append(data, ifd...), values...)
+}
+
+// geoKeyDirectory builds GeoKeyDirectory values from key, location, count and value quadruples
+func geoKeyDirectory(keys ...[4]uint16) []uint16 {
+	directory := []uint16{1, 1, 0, uint16(len(keys))}
+	for _, key := range keys {
+		directory = append(directory, key[:]...)
+	}
+	return directory
+}
+
+func TestParseGeotiff(t *testing.T) {
+	size := []tiffTag{
+		{tag: tiffImageWidth, fieldType: tiffShort, values: []uint16{200}},
+		{tag: tiffImageLength, fieldType: tiffLong, values: []uint32{100}},
+	}
+	utm := tiffTag{tag: tiffGeoKeyDirectory, fieldType: tiffShort, values: geoKeyDirectory(
+		[4]uint16{geoKeyModelType, 0, 1, geoModelProjected},
+		[4]uint16{geoKeyProjectedType, 0, 1, 32615},
+	)}
+	wgs84 := tiffTag{tag: tiffGeoKeyDirectory, fieldType: tiffShort, values: geoKeyDirectory(
+		[4]uint16{geoKeyModelType, 0, 1, geoModelGeographic},
+		[4]uint16{geoKeyRasterType, 0, 1, geoRasterPixelIsPoint},
+		[4]uint16{geoKeyGeographicType, 0, 1, 4326},
+	)}
+	tiepoint := tiffTag{tag: tiffModelTiepoint, fieldType: tiffDouble, values: []float64{0, 0, 0, 500000, 5300000, 0}}
+	scale := tiffTag{tag: tiffModelPixelScale, fieldType: tiffDouble, values: []float64{30, 30, 0}}
+
+	tests := []struct {
+		name     string
+		order    binary.AppendByteOrder
+		big      bool
+		tags     []tiffTag
+		want     core.Bbox
+		wantCode int
+		wantName string
+		errorMsg string
+	}{
+		{
+			name:     "tiepoint and pixel scale",
+			order:    binary.LittleEndian,
+			tags:     append([]tiffTag{utm, tiepoint, scale}, size...),
+			want:     core.Bbox{Left: 500000, Bottom: 5297000, Right: 506000, Top: 5300000},
+			wantCode: 32615,
+		},
+		{
+			name:     "big endian BigTIFF",
+			order:    binary.BigEndian,
+			big:      true,
+			tags:     append([]tiffTag{utm, tiepoint, scale}, size...),
+			want:     core.Bbox{Left: 500000, Bottom: 5297000, Right: 506000, Top: 5300000},
+			wantCode: 32615,
+		},
+		{
+			name:  "pixel is point",
+			order: binary.BigEndian,
+			tags: append([]tiffTag{wgs84,
+				{tag: tiffModelTiepoint, fieldType: tiffDouble, values: []float64{0, 0, 0, -92, 48, 0}},
+				{tag: tiffModelPixelScale, fieldType: tiffDouble, values: []float64{0.01, 0.01, 0}},
+			}, size...),
+			want:     core.Bbox{Left: -92.005, Bottom: 47.005, Right: -90.005, Top: 48.005},
+			wantCode: 4326,
+		},
+		{
+			// rotated 90 degrees, so the columns run north
+			name:  "transformation",
+			order: binary.LittleEndian,
+			tags: append([]tiffTag{utm, {tag: tiffModelTransformation, fieldType: tiffDouble, values: []float64{
+				0, 10, 0, 1000,
+				10, 0, 0, 2000,
+				0, 0, 0, 0,
+				0, 0, 0, 1,
+			}}}, size...),
+			want:     core.Bbox{Left: 1000, Bottom: 2000, Right: 2000, Top: 4000},
+			wantCode: 32615,
+		},
+		{
+			name:  "user defined CRS",
+			order: binary.LittleEndian,
+			tags: append([]tiffTag{tiepoint, scale,
+				{tag: tiffGeoKeyDirectory, fieldType: tiffShort, values: geoKeyDirectory(
+					[4]uint16{geoKeyModelType, 0, 1, geoModelProjected},
+					[4]uint16{geoKeyCitation, tiffGeoAsciiParams, 12, 0},
+					[4]uint16{geoKeyProjectedType, 0, 1, geoUserDefined},
+				)},
+				{tag: tiffGeoAsciiParams, fieldType: tiffAscii, values: "Local grid|"},
+			}, size...),
+			want:     core.Bbox{Left: 500000, Bottom: 5297000, Right: 506000, Top: 5300000},
+			wantName: "Local grid",
+		},
+		{
+			name:     "not georeferenced",
+			order:    binary.LittleEndian,
+			tags:     size,
+			errorMsg: "not georeferenced",
+		},
+		{
+			name:     "ground control points",
+			order:    binary.LittleEndian,
+			tags:     append([]tiffTag{utm, tiepoint}, size...),
+			errorMsg: "ground control points",
+		},
+		{
+			name:     "missing image size",
+			order:    binary.LittleEndian,
+			tags:     []tiffTag{utm, tiepoint, scale},
+			errorMsg: "missing tag 256",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := buildTiff(tt.order, tt.big, tt.tags)
+			if !SniffTiff(data) {
+				t.Fatalf("SniffTiff() = false")
+			}
+			got, crs, err := ParseGeotiff(bytes.NewReader(data), int64(len(data)))
+			if tt.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("ParseGeotiff() error = %v, want it to contain %q", err, tt.errorMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseGeotiff() unexpected error = %v", err)
+			}
+			if !bboxWithin(got, tt.want, 1e-9) {
+				t.Errorf("ParseGeotiff() = %v, want %v", got, tt.want)
+			}
+			if crs == nil || crs.Code != tt.wantCode || (tt.wantName != "" && crs.Name != tt.wantName) {
+				t.Errorf("ParseGeotiff() crs = %v, want %d %q", crs, tt.wantCode, tt.wantName)
+			}
+		})
+	}
+
+	t.Run("truncated", func(t *testing.T) {
+		data := buildTiff(binary.LittleEndian, false, append([]tiffTag{utm, tiepoint, scale}, size...))
+		data = data[:len(data)-10]
+		if _, _, err := ParseGeotiff(bytes.NewReader(data), int64(len(data))); err == nil {
+			t.Errorf("ParseGeotiff() expected an error")
+		}
+	})
+}
+
+func TestFileBuilderGeotiff(t *testing.T) {
+	// a WGS84 raster east of subset_a.geojson
+	tiff := buildTiff(binary.LittleEndian, false, []tiffTag{
+		{tag: tiffImageWidth, fieldType: tiffShort, values: []uint16{10}},
+		{tag: tiffImageLength, fieldType: tiffShort, values: []uint16{10}},
+		{tag: tiffModelTiepoint, fieldType: tiffDouble, values: []float64{0, 0, 0, -91, 48.1, 0}},
+		{tag: tiffModelPixelScale, fieldType: tiffDouble, values: []float64{0.01, 0.01, 0}},
+		{tag: tiffGeoKeyDirectory, fieldType: tiffShort, values: geoKeyDirectory([4]uint16{geoKeyGeographicType, 0, 1, 4326})},
+	})
+	filename := filepath.Join(t.TempDir(), "scene.tif")
+	if err := os.WriteFile(filename, tiff, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	params := InputParams{File: []string{getTestDataPath(t, "../integration_tests/data/subset_a.geojson"), filename}}
+	got, crs, err := params.GetBboxWithCrs()
+	if err != nil {
+		t.Fatalf("GetBboxWithCrs() unexpected error = %v", err)
+	}
+	want := core.Bbox{Left: -91.34175985747542, Bottom: 47.99755413385825, Right: -90.9, Top: 48.1}
+	if !bboxWithin(got, want, 1e-9) || crs == nil || crs.Code != 4326 {
+		t.Errorf("GetBboxWithCrs() = %v in %v, want %v in EPSG:4326", got, crs, want)
+	}
+}
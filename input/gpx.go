@@ -0,0 +1,93 @@
+package input
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/mikeocool/bbox/core"
+	"github.com/mikeocool/bbox/proj"
+)
+
+func SniffGpx(data []byte) bool {
+	return bytes.Contains(data, []byte("<gpx"))
+}
+
+// ParseGpx reads the bounds of the waypoints, route points and track points in a GPX document.
+// If the document has a bounds element it's used as is, unless opts.ScanGeometries is set or
+// the points are restricted to tracks or waypoints. The document is streamed, rather than read
+// into memory. GPX is always WGS84.
+func ParseGpx(r io.Reader, opts ReadOptions) (core.Bbox, *proj.CRS, error) {
+	if opts.GpxTracksOnly && opts.GpxWaypointsOnly {
+		return core.Bbox{}, nil, fmt.Errorf("GPX tracks only and waypoints only can't be used together")
+	}
+	wgs84, err := proj.Lookup(proj.EPSGWgs84)
+	if err != nil {
+		return core.Bbox{}, nil, err
+	}
+	useBounds := !opts.ScanGeometries && !opts.GpxTracksOnly && !opts.GpxWaypointsOnly
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+
+	decoder := newXmlDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return core.Bbox{}, nil, fmt.Errorf("invalid GPX: %w", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "bounds":
+			// in the metadata in GPX 1.1, and the root in GPX 1.0
+			if !useBounds {
+				continue
+			}
+			bounds, err := parseXmlBounds(start)
+			if err != nil {
+				return core.Bbox{}, nil, fmt.Errorf("invalid GPX: %w", err)
+			}
+			return bounds, wgs84, nil
+		case "wpt", "rtept", "trkpt":
+			if (opts.GpxTracksOnly && start.Name.Local != "trkpt") ||
+				(opts.GpxWaypointsOnly && start.Name.Local != "wpt") {
+				continue
+			}
+			lon, lat, err := parseGpxPoint(start)
+			if err != nil {
+				return core.Bbox{}, nil, err
+			}
+			updateBounds(&minX, &minY, &maxX, &maxY, lon, lat)
+		}
+	}
+
+	if minX > maxX {
+		return core.Bbox{}, nil, ErrNoFeaturesFound
+	}
+	return core.Bbox{
+		Left:   minX,
+		Bottom: minY,
+		Right:  maxX,
+		Top:    maxY,
+	}, wgs84, nil
+}
+
+// parseGpxPoint parses the lon and lat attributes of a wpt, rtept or trkpt element
+func parseGpxPoint(start xml.StartElement) (float64, float64, error) {
+	lon, errLon := strconv.ParseFloat(xmlAttr(start, "lon"), 64)
+	lat, errLat := strconv.ParseFloat(xmlAttr(start, "lat"), 64)
+	if errLon != nil || errLat != nil {
+		return 0, 0, fmt.Errorf("invalid GPX %s: lat=%q lon=%q", start.Name.Local, xmlAttr(start, "lat"), xmlAttr(start, "lon"))
+	}
+	return lon, lat, nil
+}
@@ -0,0 +1,152 @@
+package input
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mikeocool/bbox/core"
+)
+
+const testGpx = `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="test" xmlns="http://www.topografix.com/GPX/1/1">
+  <metadata>
+    <name>Morning ride</name>
+    <bounds minlat="40.0" minlon="-106.0" maxlat="41.0" maxlon="-105.0"/>
+  </metadata>
+  <wpt lat="45.0" lon="-100.0"><name>Stray waypoint</name></wpt>
+  <rte>
+    <rtept lat="39.5" lon="-105.5"/>
+  </rte>
+  <trk>
+    <trkseg>
+      <trkpt lat="40.1" lon="-105.3"><ele>1600</ele></trkpt>
+      <trkpt lat="40.2" lon="-105.2"/>
+    </trkseg>
+    <trkseg>
+      <trkpt lat="40.3" lon="-105.4"/>
+    </trkseg>
+  </trk>
+</gpx>`
+
+func TestParseGpx(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		opts    ReadOptions
+		want    core.Bbox
+		wantErr error
+	}{
+		{
+			name:  "bounds element",
+			input: testGpx,
+			want:  core.Bbox{Left: -106, Bottom: 40, Right: -105, Top: 41},
+		},
+		{
+			name:  "scan all points",
+			input: testGpx,
+			opts:  ReadOptions{ScanGeometries: true},
+			want:  core.Bbox{Left: -105.5, Bottom: 39.5, Right: -100, Top: 45},
+		},
+		{
+			name:  "tracks only",
+			input: testGpx,
+			opts:  ReadOptions{GpxTracksOnly: true},
+			want:  core.Bbox{Left: -105.4, Bottom: 40.1, Right: -105.2, Top: 40.3},
+		},
+		{
+			name:  "waypoints only",
+			input: testGpx,
+			opts:  ReadOptions{GpxWaypointsOnly: true},
+			want:  core.Bbox{Left: -100, Bottom: 45, Right: -100, Top: 45},
+		},
+		{
+			name: "GPX 1.0 without bounds",
+			input: `<gpx version="1.0" xmlns="http://www.topografix.com/GPX/1/0">
+				<rte><rtept lat="1" lon="2"/><rtept lat="3" lon="4"/></rte>
+			</gpx>`,
+			want: core.Bbox{Left: 2, Bottom: 1, Right: 4, Top: 3},
+		},
+		{
+			name:    "waypoints only without waypoints",
+			input:   `<gpx><trk><trkseg><trkpt lat="1" lon="2"/></trkseg></trk></gpx>`,
+			opts:    ReadOptions{GpxWaypointsOnly: true},
+			wantErr: ErrNoFeaturesFound,
+		},
+		{
+			name:    "no points",
+			input:   `<gpx><metadata><name>empty</name></metadata></gpx>`,
+			wantErr: ErrNoFeaturesFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, crs, err := ParseGpx(strings.NewReader(tt.input), tt.opts)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("ParseGpx() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseGpx() unexpected error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseGpx() = %v, want %v", got, tt.want)
+			}
+			if crs == nil || crs.Code != 4326 {
+				t.Errorf("ParseGpx() crs = %v, want EPSG:4326", crs)
+			}
+		})
+	}
+
+	invalid := []struct {
+		name  string
+		input string
+		opts  ReadOptions
+	}{
+		{name: "invalid point", input: `<gpx><wpt lat="north" lon="2"/></gpx>`},
+		{name: "invalid bounds", input: `<gpx><metadata><bounds minlat="1"/></metadata></gpx>`},
+		{name: "invalid XML", input: `<gpx><wpt lat="1" lon="2"></gpx>`},
+		{name: "tracks and waypoints only", input: testGpx, opts: ReadOptions{GpxTracksOnly: true, GpxWaypointsOnly: true}},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := ParseGpx(strings.NewReader(tt.input), tt.opts); err == nil {
+				t.Errorf("ParseGpx() expected error")
+			}
+		})
+	}
+}
+
+func TestLoadFileGpx(t *testing.T) {
+	want := core.Bbox{Left: -105.4, Bottom: 40.1, Right: -105.2, Top: 40.3}
+	opts := ReadOptions{GpxTracksOnly: true}
+
+	t.Run("by extension", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "ride.gpx")
+		if err := os.WriteFile(filename, []byte(testGpx), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		got, _, err := LoadFile(filename, opts)
+		if err != nil {
+			t.Fatalf("LoadFile() unexpected error = %v", err)
+		}
+		if got != want {
+			t.Errorf("LoadFile() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("by sniffing", func(t *testing.T) {
+		got, _, err := ParseData(strings.NewReader(testGpx), opts)
+		if err != nil {
+			t.Fatalf("ParseData() unexpected error = %v", err)
+		}
+		if got != want {
+			t.Errorf("ParseData() = %v, want %v", got, want)
+		}
+	})
+}
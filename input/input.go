@@ -1,32 +1,79 @@
 package input
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"reflect"
-	"strconv"
 	"strings"
 
 	"github.com/mikeocool/bbox/core"
 	"github.com/mikeocool/bbox/geocoding"
+	"github.com/mikeocool/bbox/proj"
 )
 
 type InputParams struct {
-	Left            *float64
-	Bottom          *float64
-	Right           *float64
-	Top             *float64
-	Center          []float64 // a pair of floats representing the center coordinates
-	Width           string
-	Height          string
-	Raw             []byte
-	File            []string
-	Place           string
-	Geocoder        string
-	GeocoderURL     string
-	GeocoderHeaders []string
-	Buffer          float64
+	Left             *float64
+	Bottom           *float64
+	Right            *float64
+	Top              *float64
+	Center           []float64 // a pair of floats representing the center coordinates
+	Width            string
+	Height           string
+	Raw              []byte
+	File             []string
+	Place            string
+	Geocoder         string
+	GeocoderURL      string
+	GeocoderHeaders  []string
+	Buffer           string // a distance, optionally with a unit suffix (mi, ft, km, m)
+	FromCrs          string // EPSG code of the input coordinates, WGS84 if empty
+	ToCrs            string // EPSG code to transform the box to, no transform if empty
+	ScanGeometries   bool   // compute file bounds from the geometries rather than header extents
+	GpxTracksOnly    bool   // only use track points for GPX bounds
+	GpxWaypointsOnly bool   // only use waypoints for GPX bounds
+	Layer            string // the layer to read from files with several, like GeoPackage
+	XColumn          string // the CSV column with x coordinates, rather than detecting it
+	YColumn          string // the CSV column with y coordinates, rather than detecting it
+	TrustBbox        bool   // use the bbox member of GeoJSON documents rather than reading their coordinates
+	Verbose          bool   // log details of the input, like the zoom levels of tile archives
+}
+
+// globalFields can be used with any builder
+var globalFields = map[string]bool{
+	"Buffer":  true,
+	"FromCrs": true,
+	"ToCrs":   true,
+	"Verbose": true,
+}
+
+// readOptionFields are the fields used by readOptions, for the builders that read files and raw data
+var readOptionFields = []string{"ScanGeometries", "GpxTracksOnly", "GpxWaypointsOnly", "Layer", "XColumn", "YColumn", "TrustBbox"}
+
+// readOptions returns the options for reading files and raw data
+func (params *InputParams) readOptions() ReadOptions {
+	return ReadOptions{
+		ScanGeometries:   params.ScanGeometries,
+		GpxTracksOnly:    params.GpxTracksOnly,
+		GpxWaypointsOnly: params.GpxWaypointsOnly,
+		Layer:            params.Layer,
+		XColumn:          params.XColumn,
+		YColumn:          params.YColumn,
+		TrustBbox:        params.TrustBbox,
+		Verbose:          params.Verbose,
+	}
+}
+
+// validateReadOptions checks the fields used by readOptions
+func (params *InputParams) validateReadOptions() error {
+	if params.GpxTracksOnly && params.GpxWaypointsOnly {
+		return InputValidationError{Field: "GpxWaypointsOnly", Message: "cannot be used with GpxTracksOnly"}
+	}
+	if (params.XColumn == "") != (params.YColumn == "") {
+		return InputValidationError{Field: "XColumn", Message: "must be used with YColumn"}
+	}
+	return nil
 }
 
 func (params *InputParams) HasWidth() bool  { return params.Width != "" }
@@ -37,6 +84,13 @@ func (params *InputParams) HasAnyCoordinates() bool {
 }
 
 func (params *InputParams) GetBbox() (core.Bbox, error) {
+	bbox, _, err := params.GetBboxWithCrs()
+	return bbox, err
+}
+
+// GetBboxWithCrs returns the box and its CRS. The CRS is nil if it wasn't specified
+// with FromCrs or ToCrs, or detected in the input.
+func (params *InputParams) GetBboxWithCrs() (core.Bbox, *proj.CRS, error) {
 	builders := []BboxBuilder{
 		RawBuilder,
 		PlaceBuilder,
@@ -52,11 +106,11 @@ func (params *InputParams) GetBbox() (core.Bbox, error) {
 	}
 
 	// dont allow the buffer parameter if there is no GetBbox
-	if params.Buffer != 0 {
-		return core.Bbox{}, fmt.Errorf("Cannot specify buffer without a bounding box")
+	if params.Buffer != "" {
+		return core.Bbox{}, nil, fmt.Errorf("Cannot specify buffer without a bounding box")
 	}
 
-	return core.Bbox{}, NoUsableBuilderError{}
+	return core.Bbox{}, nil, NoUsableBuilderError{}
 }
 
 func (p *InputParams) getSetFields() []string {
@@ -71,7 +125,7 @@ func (p *InputParams) getSetFields() []string {
 	return fields
 }
 
-func buildBbox(builder BboxBuilder, params *InputParams) (core.Bbox, error) {
+func buildBbox(builder BboxBuilder, params *InputParams) (core.Bbox, *proj.CRS, error) {
 	usedFieldsSet := make(map[string]bool)
 	for _, field := range builder.UsedFields {
 		usedFieldsSet[field] = true
@@ -79,28 +133,135 @@ func buildBbox(builder BboxBuilder, params *InputParams) (core.Bbox, error) {
 
 	setFields := params.getSetFields()
 	for _, field := range setFields {
-		// buffer is a global used field TODO make this better
-		if !usedFieldsSet[field] && field != "Buffer" {
-			return core.Bbox{}, fmt.Errorf("Unexpected argument: %s with %s", field, builder.Name)
+		if !usedFieldsSet[field] && !globalFields[field] {
+			return core.Bbox{}, nil, fmt.Errorf("Unexpected argument: %s with %s", field, builder.Name)
 		}
 	}
 
+	fromCrs, err := ParseCrs("from-crs", params.FromCrs)
+	if err != nil {
+		return core.Bbox{}, nil, err
+	}
+	toCrs, err := ParseCrs("to-crs", params.ToCrs)
+	if err != nil {
+		return core.Bbox{}, nil, err
+	}
+
 	if err := builder.ValidateParams(params); err != nil {
-		return core.Bbox{}, err
+		return core.Bbox{}, nil, err
 	}
-	bbox, err := builder.Build(params)
+	bbox, detectedCrs, err := builder.Build(params)
 	if err != nil {
-		return core.Bbox{}, err
+		return core.Bbox{}, nil, err
+	}
+
+	// --from-crs overrides the CRS detected in the input
+	if fromCrs == nil {
+		fromCrs = detectedCrs
+	} else if detectedCrs != nil && !detectedCrs.Equal(fromCrs) {
+		log.Printf("Using --from-crs %s rather than %s from the input\n", fromCrs, detectedCrs)
+	}
+
+	if toCrs == nil {
+		toCrs = fromCrs
+	} else {
+		if fromCrs == nil {
+			fromCrs, _ = proj.Lookup(proj.EPSGWgs84)
+		}
+		bbox, err = proj.TransformBbox(bbox, fromCrs, toCrs)
+		if err != nil {
+			return core.Bbox{}, nil, err
+		}
 	}
 
-	if params.Buffer != 0 {
-		bbox, err = bbox.Buffer(params.Buffer)
+	if params.Buffer != "" {
+		bbox, err = bufferBbox(bbox, params.Buffer, toCrs)
+		if err != nil {
+			return core.Bbox{}, nil, err
+		}
+	}
+
+	return bbox, toCrs, nil
+}
+
+// sourceCrs returns the CRS of the input coordinates given with FromCrs, WGS84 if it isn't set
+func (params *InputParams) sourceCrs() (*proj.CRS, error) {
+	if params.FromCrs == "" {
+		return proj.Lookup(proj.EPSGWgs84)
+	}
+	return ParseCrs("from-crs", params.FromCrs)
+}
+
+// ParseCrs parses a CRS parameter, returning an InputValidationError
+// for the field if it is invalid. Empty values return nil.
+func ParseCrs(field string, value string) (*proj.CRS, error) {
+	if value == "" {
+		return nil, nil
+	}
+	crs, err := proj.Parse(value)
+	if err != nil {
+		return nil, InputValidationError{Field: field, Message: err.Error()}
+	}
+	return crs, nil
+}
+
+// bufferBbox grows or shrinks the box by the buffer distance. In a geographic CRS distances
+// with a unit are converted to degrees at the latitude of the center of the box, in a
+// projected CRS they're converted to its unit. Boxes without a CRS are treated as geographic.
+func bufferBbox(bbox core.Bbox, buffer string, crs *proj.CRS) (core.Bbox, error) {
+	dist, err := parseDistanceParam("buffer", buffer)
+	if err != nil {
+		return core.Bbox{}, err
+	}
+	if crs != nil && !crs.IsGeographic() {
+		value, err := projectedDistance("buffer", dist, crs)
 		if err != nil {
 			return core.Bbox{}, err
 		}
+		return bbox.Buffer(value)
 	}
+	if dist.IsDegrees() {
+		return bbox.Buffer(dist.Value)
+	}
+
+	lat := bbox.Center()[1]
+	return bbox.BufferXY(dist.LonDegrees(lat), dist.LatDegrees())
+}
+
+// parseDistanceParam parses a distance parameter, returning an InputValidationError
+// for the field if it is invalid.
+func parseDistanceParam(field string, value string) (core.Distance, error) {
+	dist, err := core.ParseDistance(value)
+	if err != nil {
+		return core.Distance{}, InputValidationError{Field: field, Message: err.Error()}
+	}
+	return dist, nil
+}
 
-	return bbox, nil
+// projectedDistance converts a distance parameter to the unit of a projected CRS, returning an
+// InputValidationError for the field if the CRS's unit isn't known. Distances without a unit are
+// already in the CRS's unit.
+func projectedDistance(field string, dist core.Distance, crs *proj.CRS) (float64, error) {
+	if dist.IsDegrees() {
+		return dist.Value, nil
+	}
+	metersPerUnit, ok := crs.MetersPerUnit()
+	if !ok {
+		return 0, InputValidationError{Field: field, Message: fmt.Sprintf("the unit of %s isn't known, so %s can't be converted to it", crs, dist.Unit)}
+	}
+	return dist.Meters() / metersPerUnit, nil
+}
+
+// parseLengthParam parses a width or height, which can't be negative
+func parseLengthParam(field string, value string) (core.Distance, error) {
+	dist, err := parseDistanceParam(field, value)
+	if err != nil {
+		return core.Distance{}, err
+	}
+	if dist.Value < 0 {
+		return core.Distance{}, InputValidationError{Field: field, Message: fmt.Sprintf("cannot be negative: %s", value)}
+	}
+	return dist, nil
 }
 
 type InputValidationError struct {
@@ -123,7 +284,8 @@ type BboxBuilder struct {
 	IsUsable       func(*InputParams) bool
 	ValidateParams func(*InputParams) error
 	UsedFields     []string
-	Build          func(*InputParams) (core.Bbox, error)
+	// Build returns the box, and the CRS if it was detected in the input
+	Build func(*InputParams) (core.Bbox, *proj.CRS, error)
 }
 
 var RawBuilder = BboxBuilder{
@@ -131,11 +293,11 @@ var RawBuilder = BboxBuilder{
 		return params.Raw != nil
 	},
 	ValidateParams: func(params *InputParams) error {
-		return nil
+		return params.validateReadOptions()
 	},
-	UsedFields: []string{"Raw"},
-	Build: func(params *InputParams) (core.Bbox, error) {
-		return ParseRaw(params.Raw)
+	UsedFields: append([]string{"Raw"}, readOptionFields...),
+	Build: func(params *InputParams) (core.Bbox, *proj.CRS, error) {
+		return ParseRaw(params.Raw, params.readOptions())
 	},
 }
 
@@ -157,37 +319,65 @@ var FileBuilder = BboxBuilder{
 			return InputValidationError{Field: "File", Message: "no valid file paths provided"}
 		}
 
-		return nil
+		return params.validateReadOptions()
 	},
-	UsedFields: []string{"File"},
-	Build: func(params *InputParams) (core.Bbox, error) {
-		var bbox *core.Bbox
-
+	UsedFields: append([]string{"File"}, readOptionFields...),
+	Build: func(params *InputParams) (core.Bbox, *proj.CRS, error) {
+		var union bboxUnion
 		for _, file := range params.File {
 			if file == "" {
 				continue
 			}
-			fbox, err := LoadFile(file)
-			if err == ErrNoFeaturesFound {
+			fbox, fcrs, err := LoadFile(file, params.readOptions())
+			if errors.Is(err, ErrNoFeaturesFound) {
 				continue
 			} else if err != nil {
-				return core.Bbox{}, err
+				return core.Bbox{}, nil, err
 			}
 
-			if bbox == nil {
-				bbox = &fbox
-			} else {
-				updated_bbox := bbox.Union(fbox)
-				bbox = &updated_bbox
+			if err := union.add(fbox, fcrs); err != nil {
+				return core.Bbox{}, nil, fmt.Errorf("%s: %w", file, err)
 			}
 		}
-		if bbox == nil {
-			return core.Bbox{}, ErrNoFeaturesFound
-		}
-		return *bbox, nil
+		return union.result()
 	},
 }
 
+// bboxUnion accumulates the union of the boxes from several sources. Boxes in a different CRS
+// than the first one are transformed to it.
+type bboxUnion struct {
+	bbox *core.Bbox
+	crs  *proj.CRS
+}
+
+func (u *bboxUnion) add(box core.Bbox, crs *proj.CRS) error {
+	if u.crs == nil {
+		u.crs = crs
+	} else if crs != nil && !crs.Equal(u.crs) {
+		var err error
+		box, err = proj.TransformBbox(box, crs, u.crs)
+		if err != nil {
+			return err
+		}
+	}
+
+	if u.bbox == nil {
+		u.bbox = &box
+	} else {
+		updated_bbox := u.bbox.Union(box)
+		u.bbox = &updated_bbox
+	}
+	return nil
+}
+
+// result returns the union, or ErrNoFeaturesFound if no boxes were added
+func (u *bboxUnion) result() (core.Bbox, *proj.CRS, error) {
+	if u.bbox == nil {
+		return core.Bbox{}, nil, ErrNoFeaturesFound
+	}
+	return *u.bbox, u.crs, nil
+}
+
 var PlaceBuilder = BboxBuilder{
 	Name: "place",
 	IsUsable: func(params *InputParams) bool {
@@ -202,10 +392,13 @@ var PlaceBuilder = BboxBuilder{
 		if params.Geocoder != "" && params.GeocoderURL != "" {
 			return InputValidationError{Field: "geocoder", Message: "cannot specify both --geocoder and --geocoder-url"}
 		}
+		if params.FromCrs != "" {
+			return InputValidationError{Field: "from-crs", Message: "geocoded places are always in WGS84"}
+		}
 		return nil
 	},
 	UsedFields: []string{"Place", "Geocoder", "GeocoderURL", "GeocoderHeaders", "Width", "Height"},
-	Build: func(params *InputParams) (core.Bbox, error) {
+	Build: func(params *InputParams) (core.Bbox, *proj.CRS, error) {
 		var result *geocoding.GeocodeResult
 		var err error
 		
@@ -220,38 +413,24 @@ var PlaceBuilder = BboxBuilder{
 			result, err = geocoding.GeocodePlace(geocoder, params.Place, params.GeocoderHeaders)
 		}
 		if err != nil {
-			return core.Bbox{}, err
+			return core.Bbox{}, nil, err
 		}
 
 		log.Printf("Geocoder matched %s: %s\n", result.Type, result.FullName)
 
 		// If width and height are specified, create bounds around the center
 		if params.HasWidth() && params.HasHeight() {
-			width, err := strconv.ParseFloat(params.Width, 64)
-			if err != nil {
-				return core.Bbox{}, fmt.Errorf("invalid width: %w", err)
-			}
-
-			height, err := strconv.ParseFloat(params.Height, 64)
-			if err != nil {
-				return core.Bbox{}, fmt.Errorf("invalid height: %w", err)
-			}
-
-			return core.Bbox{
-				Left:   result.LocationX - width/2,
-				Bottom: result.LocationY - height/2,
-				Right:  result.LocationX + width/2,
-				Top:    result.LocationY + height/2,
-			}, nil
+			bbox, err := centeredBbox(result.LocationX, result.LocationY, params.Width, params.Height, nil)
+			return bbox, nil, err
 		}
 
 		// Use extent if available
 		if result.Extent != nil {
-			return *result.Extent, nil
+			return *result.Extent, nil, nil
 		}
 
 		// No extent and no width/height
-		return core.Bbox{}, fmt.Errorf("Geocoder did not return extent for '%s', please a width and height", params.Place)
+		return core.Bbox{}, nil, fmt.Errorf("Geocoder did not return extent for '%s', please a width and height", params.Place)
 	},
 }
 
@@ -273,24 +452,48 @@ var CenterBuilder = BboxBuilder{
 		return nil
 	},
 	UsedFields: []string{"Center", "Width", "Height"},
-	Build: func(params *InputParams) (core.Bbox, error) {
-		width, err := strconv.ParseFloat(params.Width, 64)
+	Build: func(params *InputParams) (core.Bbox, *proj.CRS, error) {
+		crs, err := params.sourceCrs()
 		if err != nil {
-			return core.Bbox{}, err
+			return core.Bbox{}, nil, err
 		}
+		bbox, err := centeredBbox(params.Center[0], params.Center[1], params.Width, params.Height, crs)
+		return bbox, nil, err
+	},
+}
 
-		height, err := strconv.ParseFloat(params.Height, 64)
-		if err != nil {
+// centeredBbox creates a box of the given width and height around a center point.
+// Widths and heights with a unit are converted to degrees at the latitude of the center,
+// or to the CRS's unit if the center is in a projected CRS. A nil CRS is geographic.
+func centeredBbox(x, y float64, width, height string, crs *proj.CRS) (core.Bbox, error) {
+	widthDist, err := parseLengthParam("width", width)
+	if err != nil {
+		return core.Bbox{}, err
+	}
+
+	heightDist, err := parseLengthParam("height", height)
+	if err != nil {
+		return core.Bbox{}, err
+	}
+
+	halfWidth := widthDist.LonDegrees(y) / 2
+	halfHeight := heightDist.LatDegrees() / 2
+	if crs != nil && !crs.IsGeographic() {
+		if halfWidth, err = projectedDistance("width", widthDist, crs); err != nil {
+			return core.Bbox{}, err
+		}
+		if halfHeight, err = projectedDistance("height", heightDist, crs); err != nil {
 			return core.Bbox{}, err
 		}
+		halfWidth, halfHeight = halfWidth/2, halfHeight/2
+	}
 
-		return core.Bbox{
-			Left:   params.Center[0] - width/2,
-			Bottom: params.Center[1] - height/2,
-			Right:  params.Center[0] + width/2,
-			Top:    params.Center[1] + height/2,
-		}, nil // TODO
-	},
+	return core.Bbox{
+		Left:   x - halfWidth,
+		Bottom: y - halfHeight,
+		Right:  x + halfWidth,
+		Top:    y + halfHeight,
+	}, nil
 }
 
 var BoundsBuilder = BboxBuilder{
@@ -308,16 +511,41 @@ var BoundsBuilder = BboxBuilder{
 		return nil
 	},
 	UsedFields: []string{"Left", "Bottom", "Right", "Top", "Width", "Height"},
-	Build: func(params *InputParams) (core.Bbox, error) {
-		left, right := getBoundsPair(params.Left, params.Right, params.Width)
-		bottom, top := getBoundsPair(params.Bottom, params.Top, params.Height)
+	Build: func(params *InputParams) (core.Bbox, *proj.CRS, error) {
+		crs, err := params.sourceCrs()
+		if err != nil {
+			return core.Bbox{}, nil, err
+		}
+		heightToCrs := func(d core.Distance) (float64, error) {
+			if !crs.IsGeographic() {
+				return projectedDistance("height", d, crs)
+			}
+			return d.LatDegrees(), nil
+		}
+
+		bottom, top, err := getBoundsPair(params.Bottom, params.Top, "height", params.Height, heightToCrs)
+		if err != nil {
+			return core.Bbox{}, nil, err
+		}
+
+		// widths with units depend on the latitude, so resolve them at the middle of the box
+		lat := (bottom + top) / 2
+		left, right, err := getBoundsPair(params.Left, params.Right, "width", params.Width, func(d core.Distance) (float64, error) {
+			if !crs.IsGeographic() {
+				return projectedDistance("width", d, crs)
+			}
+			return d.LonDegrees(lat), nil
+		})
+		if err != nil {
+			return core.Bbox{}, nil, err
+		}
 
 		return core.Bbox{
 			Left:   left,
 			Right:  right,
 			Bottom: bottom,
 			Top:    top,
-		}, nil // TODO
+		}, nil, nil // TODO
 	},
 }
 
@@ -343,24 +571,31 @@ func validateBoundsPair(min, max *float64, length string) error {
 	return nil
 }
 
-func getBoundsPair(min, max *float64, length string) (float64, float64) {
+// getBoundsPair resolves the min and max of one axis from two of min, max and length.
+// toCrsUnits converts the parsed length into the units of the CRS along the axis.
+func getBoundsPair(min, max *float64, lengthField string, length string, toCrsUnits func(core.Distance) (float64, error)) (float64, float64, error) {
 	if min != nil && max != nil {
-		return *min, *max
+		return *min, *max, nil
 	}
 
 	lengthVal := 0.0
 	if length != "" {
-		// TODO handle errors
-		lengthVal, _ = strconv.ParseFloat(length, 64)
+		dist, err := parseLengthParam(lengthField, length)
+		if err != nil {
+			return 0, 0, err
+		}
+		if lengthVal, err = toCrsUnits(dist); err != nil {
+			return 0, 0, err
+		}
 	}
 
 	if min != nil && length != "" {
-		return *min, *min + lengthVal
+		return *min, *min + lengthVal, nil
 	}
 	if max != nil && length != "" {
-		return *max - lengthVal, *max
+		return *max - lengthVal, *max, nil
 	}
-	return 0, 0 // TODO
+	return 0, 0, nil // TODO
 }
 
 func isFieldEmpty(p *InputParams, fieldName string) bool {
@@ -1,12 +1,16 @@
 package input
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"os"
 	"path/filepath"
 	"runtime"
 	"testing"
 
 	"github.com/mikeocool/bbox/core"
+	"github.com/mikeocool/bbox/proj"
 )
 
 func TestInputParams_GetBbox(t *testing.T) {
@@ -35,6 +39,34 @@ func TestInputParams_GetBbox(t *testing.T) {
 			expectError: true,
 			errorMsg:    "Unexpected argument: Place with ",
 		},
+		{
+			name: "RawBuilder - GPX tracks and waypoints only",
+			params: InputParams{
+				Raw:              []byte("1 2 3 4"),
+				GpxTracksOnly:    true,
+				GpxWaypointsOnly: true,
+			},
+			expectError: true,
+			errorMsg:    "GpxWaypointsOnly: cannot be used with GpxTracksOnly",
+		},
+		{
+			name: "RawBuilder - x column without y column",
+			params: InputParams{
+				Raw:     []byte("lon,lat\n1,2"),
+				XColumn: "lon",
+			},
+			expectError: true,
+			errorMsg:    "XColumn: must be used with YColumn",
+		},
+		{
+			name: "RawBuilder - CSV columns",
+			params: InputParams{
+				Raw:     []byte("site,easting,northing\na,1,2\nb,3,4"),
+				XColumn: "easting",
+				YColumn: "northing",
+			},
+			expectBbox: &core.Bbox{Left: 1, Bottom: 2, Right: 3, Top: 4},
+		},
 
 		// PlaceBuilder tests
 		// TODO dont hit geocoder durring tests
@@ -231,7 +263,7 @@ func TestInputParams_GetBbox(t *testing.T) {
 		{
 			name: "BoundsBuilder - Left, Right and Width (invalid)",
 			params: InputParams{
-				Buffer: 2.0,
+				Buffer: "2",
 			},
 			expectError: true,
 			errorMsg:    "Cannot specify buffer without a bounding box",
@@ -239,7 +271,7 @@ func TestInputParams_GetBbox(t *testing.T) {
 		{
 			name: "Buffered bounds",
 			params: InputParams{
-				Buffer: 2.0,
+				Buffer: "2",
 				Left:   floatPtr(1.0),
 				Right:  floatPtr(5.0),
 				Bottom: floatPtr(2.0),
@@ -256,7 +288,7 @@ func TestInputParams_GetBbox(t *testing.T) {
 		{
 			name: "Invalid buffer",
 			params: InputParams{
-				Buffer: -2.0,
+				Buffer: "-2",
 				Left:   floatPtr(1.0),
 				Right:  floatPtr(2.0),
 				Bottom: floatPtr(1.0),
@@ -308,6 +340,268 @@ func TestInputParams_GetBbox(t *testing.T) {
 	}
 }
 
+func TestInputParams_GetBboxWithUnits(t *testing.T) {
+	const tolerance = 1e-9
+	kmPerDegree := 6371008.8 * math.Pi / 180 / 1000
+
+	tests := []struct {
+		name   string
+		params InputParams
+		want   core.Bbox
+	}{
+		{
+			name: "Center with km at the equator",
+			params: InputParams{
+				Center: []float64{0, 0},
+				Width:  fmt.Sprintf("%gkm", kmPerDegree*2),
+				Height: fmt.Sprintf("%gkm", kmPerDegree*2),
+			},
+			want: core.Bbox{Left: -1, Bottom: -1, Right: 1, Top: 1},
+		},
+		{
+			name: "Center with km at 60 degrees",
+			params: InputParams{
+				Center: []float64{10, 60},
+				Width:  fmt.Sprintf("%gkm", kmPerDegree),
+				Height: fmt.Sprintf("%gkm", kmPerDegree),
+			},
+			want: core.Bbox{Left: 9, Bottom: 59.5, Right: 11, Top: 60.5},
+		},
+		{
+			name: "Left, bottom and sizes in meters",
+			params: InputParams{
+				Left:   floatPtr(10),
+				Bottom: floatPtr(59.5),
+				Width:  fmt.Sprintf("%gm", kmPerDegree*1000),
+				Height: fmt.Sprintf("%gm", kmPerDegree*1000),
+			},
+			want: core.Bbox{Left: 10, Bottom: 59.5, Right: 12, Top: 60.5},
+		},
+		{
+			name: "Buffer in km",
+			params: InputParams{
+				Left:   floatPtr(-1),
+				Bottom: floatPtr(-1),
+				Right:  floatPtr(1),
+				Top:    floatPtr(1),
+				Buffer: fmt.Sprintf("%gkm", kmPerDegree),
+			},
+			want: core.Bbox{Left: -2, Bottom: -2, Right: 2, Top: 2},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.params.GetBbox()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if math.Abs(got.Left-tc.want.Left) > tolerance || math.Abs(got.Bottom-tc.want.Bottom) > tolerance ||
+				math.Abs(got.Right-tc.want.Right) > tolerance || math.Abs(got.Top-tc.want.Top) > tolerance {
+				t.Errorf("Expected %v but got %v", tc.want, got)
+			}
+		})
+	}
+
+	t.Run("Invalid unit", func(t *testing.T) {
+		params := InputParams{Center: []float64{0, 0}, Width: "5yd", Height: "5km"}
+		_, err := params.GetBbox()
+		var validationErr InputValidationError
+		if !errors.As(err, &validationErr) || validationErr.Field != "width" {
+			t.Errorf("Expected width validation error but got %v", err)
+		}
+	})
+
+	t.Run("Negative or infinite sizes", func(t *testing.T) {
+		for _, params := range []InputParams{
+			{Center: []float64{0, 0}, Width: "5km", Height: "-5km"},
+			{Left: floatPtr(0), Bottom: floatPtr(0), Width: "1", Height: "-2"},
+			{Left: floatPtr(0), Bottom: floatPtr(0), Width: "1", Height: "inf"},
+		} {
+			_, err := params.GetBbox()
+			var validationErr InputValidationError
+			if !errors.As(err, &validationErr) || validationErr.Field != "height" {
+				t.Errorf("Expected height validation error for %q but got %v", params.Height, err)
+			}
+		}
+	})
+}
+
+func TestInputParams_GetBboxWithCrs(t *testing.T) {
+	tolerance := 0.001
+
+	tests := []struct {
+		name   string
+		params InputParams
+		want   core.Bbox
+	}{
+		{
+			name: "WGS84 to web mercator",
+			params: InputParams{
+				Left:   floatPtr(0),
+				Bottom: floatPtr(0),
+				Right:  floatPtr(180),
+				Top:    floatPtr(85.0511287798066),
+				ToCrs:  "EPSG:3857",
+			},
+			want: core.Bbox{Left: 0, Bottom: 0, Right: 20037508.342789244, Top: 20037508.342789244},
+		},
+		{
+			name: "Web mercator to WGS84",
+			params: InputParams{
+				Raw:     []byte("0 0 20037508.342789244 20037508.342789244"),
+				FromCrs: "3857",
+				ToCrs:   "4326",
+			},
+			want: core.Bbox{Left: 0, Bottom: 0, Right: 180, Top: 85.0511287798066},
+		},
+		{
+			name: "Sizes are in meters in a projected CRS",
+			params: InputParams{
+				Center:  []float64{500000, 5000000},
+				Width:   "2km",
+				Height:  "1000",
+				FromCrs: "EPSG:32633",
+			},
+			want: core.Bbox{Left: 499000, Bottom: 4999500, Right: 501000, Top: 5000500},
+		},
+		{
+			name: "Buffer is in meters in a projected target CRS",
+			params: InputParams{
+				Left:   floatPtr(0),
+				Bottom: floatPtr(0),
+				Right:  floatPtr(180),
+				Top:    floatPtr(85.0511287798066),
+				ToCrs:  "EPSG:3857",
+				Buffer: "1km",
+			},
+			want: core.Bbox{Left: -1000, Bottom: -1000, Right: 20038508.342789244, Top: 20038508.342789244},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.params.GetBbox()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if math.Abs(got.Left-tc.want.Left) > tolerance || math.Abs(got.Bottom-tc.want.Bottom) > tolerance ||
+				math.Abs(got.Right-tc.want.Right) > tolerance || math.Abs(got.Top-tc.want.Top) > tolerance {
+				t.Errorf("Expected %v but got %v", tc.want, got)
+			}
+		})
+	}
+
+	t.Run("Unsupported CRS", func(t *testing.T) {
+		params := InputParams{Raw: []byte("1 2 3 4"), ToCrs: "EPSG:1"}
+		_, err := params.GetBbox()
+		var validationErr InputValidationError
+		if !errors.As(err, &validationErr) || validationErr.Field != "to-crs" {
+			t.Errorf("Expected to-crs validation error but got %v", err)
+		}
+	})
+}
+
+func TestInputParams_GetBboxWithCrsDetected(t *testing.T) {
+	mercatorFeature := `{"type":"Feature","crs":{"type":"name","properties":{"name":"EPSG:3857"}},` +
+		`"geometry":{"type":"Point","coordinates":[20037508.342789244,0]}}`
+
+	t.Run("No CRS", func(t *testing.T) {
+		params := InputParams{Raw: []byte("1 2 3 4")}
+		_, crs, err := params.GetBboxWithCrs()
+		if err != nil || crs != nil {
+			t.Errorf("Expected no CRS but got %v %v", crs, err)
+		}
+	})
+
+	t.Run("Detected CRS is returned", func(t *testing.T) {
+		params := InputParams{Raw: []byte(mercatorFeature)}
+		bbox, crs, err := params.GetBboxWithCrs()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if crs == nil || crs.Code != 3857 || bbox.Left != 20037508.342789244 {
+			t.Errorf("Expected the box in EPSG:3857 but got %v in %v", bbox, crs)
+		}
+	})
+
+	t.Run("Detected CRS is transformed to --to-crs", func(t *testing.T) {
+		params := InputParams{Raw: []byte(mercatorFeature), ToCrs: "EPSG:4326"}
+		bbox, crs, err := params.GetBboxWithCrs()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if crs.Code != 4326 || math.Abs(bbox.Left-180) > 1e-9 || math.Abs(bbox.Bottom) > 1e-9 {
+			t.Errorf("Expected the box in EPSG:4326 but got %v in %v", bbox, crs)
+		}
+	})
+
+	t.Run("--from-crs overrides the detected CRS", func(t *testing.T) {
+		params := InputParams{Raw: []byte(mercatorFeature), FromCrs: "EPSG:32631"}
+		_, crs, err := params.GetBboxWithCrs()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if crs.Code != 32631 {
+			t.Errorf("Expected EPSG:32631 but got %v", crs)
+		}
+	})
+
+	t.Run("Unsupported detected CRS can't be transformed", func(t *testing.T) {
+		params := InputParams{
+			Raw:   []byte(`{"type":"Feature","crs":{"type":"name","properties":{"name":"EPSG:2263"}},"geometry":{"type":"Point","coordinates":[1,2]}}`),
+			ToCrs: "EPSG:4326",
+		}
+		_, _, err := params.GetBboxWithCrs()
+		if !errors.Is(err, proj.ErrUnsupportedCRS) {
+			t.Errorf("Expected unsupported CRS error but got %v", err)
+		}
+	})
+
+	t.Run("Buffer with a unit in a CRS in feet", func(t *testing.T) {
+		shp, err := os.ReadFile(campsitesShp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dir := t.TempDir()
+		filename := filepath.Join(dir, "places.shp")
+		prj := `PROJCS["NAD83 / New York Long Island (ftUS)",GEOGCS["NAD83",DATUM["North_American_Datum_1983",SPHEROID["GRS 1980",6378137,298.257222101]]],` +
+			`PROJECTION["Lambert_Conformal_Conic_2SP"],UNIT["US survey foot",0.3048006096012192],AUTHORITY["EPSG","2263"]]`
+		if err := os.WriteFile(filename, shp, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "places.prj"), []byte(prj), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		unbuffered, _, err := (&InputParams{File: []string{filename}}).GetBboxWithCrs()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		bbox, crs, err := (&InputParams{File: []string{filename}, Buffer: "0.3048006096012192m"}).GetBboxWithCrs()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if crs.Code != 2263 || math.Abs(bbox.Left-(unbuffered.Left-1)) > 1e-9 || math.Abs(bbox.Top-(unbuffered.Top+1)) > 1e-9 {
+			t.Errorf("Expected %v buffered by a foot but got %v in %v", unbuffered, bbox, crs)
+		}
+	})
+
+	t.Run("Buffer with a unit in a CRS whose unit isn't known", func(t *testing.T) {
+		raw := []byte(`{"type":"Feature","crs":{"type":"name","properties":{"name":"EPSG:2263"}},"geometry":{"type":"Point","coordinates":[1,2]}}`)
+		_, _, err := (&InputParams{Raw: raw, Buffer: "10m"}).GetBboxWithCrs()
+		var validationErr InputValidationError
+		if !errors.As(err, &validationErr) || validationErr.Field != "buffer" {
+			t.Errorf("Expected buffer validation error but got %v", err)
+		}
+
+		bbox, _, err := (&InputParams{Raw: raw, Buffer: "10"}).GetBboxWithCrs()
+		if err != nil || bbox != (core.Bbox{Left: -9, Bottom: -8, Right: 11, Top: 12}) {
+			t.Errorf("Expected a buffer without a unit to be in the CRS's unit but got %v, %v", bbox, err)
+		}
+	})
+}
+
 func TestInputParams_HasAnyCoordinates(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -631,6 +925,12 @@ func TestFileBuilder(t *testing.T) {
 			expectError: false,
 			expectBbox:  &core.Bbox{Left: -91.34175985747542, Bottom: 47.99755413385825, Right: -91.14794444117372, Top: 48.01355378301334},
 		},
+		{
+			name:        "Mixed valid and null geometry files",
+			files:       []string{getTestDataPath(t, "../integration_tests/data/null_geometries.geojson"), getTestDataPath(t, "../integration_tests/data/subset_a.geojson")},
+			expectError: false,
+			expectBbox:  &core.Bbox{Left: -91.34175985747542, Bottom: 47.99755413385825, Right: -91.14794444117372, Top: 48.01355378301334},
+		},
 		{
 			name:        "Empty string in file list",
 			files:       []string{getTestDataPath(t, "../integration_tests/data/subset_a.geojson"), "", getTestDataPath(t, "../integration_tests/data/subset_b.geojson")},
@@ -0,0 +1,336 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// jsonMaxDepth limits the nesting of objects and arrays
+const jsonMaxDepth = 1000
+
+var errInvalidJSON = errors.New("invalid JSON")
+
+// jsonScanner reads JSON a value at a time from a stream, so documents don't need to fit in memory.
+// The caller walks the values it needs, and skips the rest.
+type jsonScanner struct {
+	r      *bufio.Reader
+	offset int64
+	depth  int
+	// record collects the bytes that are read, while it isn't nil
+	record *bytes.Buffer
+	// number is reused to read numbers
+	number []byte
+}
+
+func newJSONScanner(r io.Reader) *jsonScanner {
+	return &jsonScanner{r: bufio.NewReaderSize(r, 64*1024)}
+}
+
+// read returns the next byte, or 0 and an error at the end of the data
+func (s *jsonScanner) read() (byte, error) {
+	c, err := s.r.ReadByte()
+	if err == io.EOF {
+		return 0, s.errorf("unexpected end of JSON")
+	} else if err != nil {
+		return 0, err
+	}
+	s.offset++
+	if s.record != nil {
+		s.record.WriteByte(c)
+	}
+	return c, nil
+}
+
+// peek skips whitespace and returns the next byte without reading it, or 0 at the end of the data
+func (s *jsonScanner) peek() (byte, error) {
+	for {
+		next, err := s.r.Peek(1)
+		if err == io.EOF {
+			return 0, nil
+		} else if err != nil {
+			return 0, err
+		}
+		switch next[0] {
+		case ' ', '\t', '\r', '\n':
+			if _, err := s.read(); err != nil {
+				return 0, err
+			}
+		default:
+			return next[0], nil
+		}
+	}
+}
+
+// more skips whitespace and RFC 8142 record separators between values, and reports whether
+// there's another value
+func (s *jsonScanner) more() (bool, error) {
+	for {
+		c, err := s.peek()
+		if err != nil {
+			return false, err
+		}
+		if c != geojsonRecordSeparator {
+			return c != 0, nil
+		}
+		if _, err := s.read(); err != nil {
+			return false, err
+		}
+	}
+}
+
+func (s *jsonScanner) expect(c byte) error {
+	next, err := s.peek()
+	if err != nil {
+		return err
+	}
+	if next != c {
+		return s.unexpected(next, fmt.Sprintf("%q", c))
+	}
+	_, err = s.read()
+	return err
+}
+
+// object reads an object, calling member with each key. member has to read the value.
+func (s *jsonScanner) object(member func(key string) error) error {
+	if err := s.enter('{'); err != nil {
+		return err
+	}
+	if next, err := s.peek(); err != nil {
+		return err
+	} else if next == '}' {
+		return s.leave()
+	}
+	for {
+		key, err := s.string()
+		if err != nil {
+			return err
+		}
+		if err := s.expect(':'); err != nil {
+			return err
+		}
+		if err := member(key); err != nil {
+			return err
+		}
+		if done, err := s.endOf('}'); err != nil || done {
+			return err
+		}
+	}
+}
+
+// array reads an array, calling element for each of its values. element has to read the value.
+func (s *jsonScanner) array(element func() error) error {
+	if err := s.enter('['); err != nil {
+		return err
+	}
+	if next, err := s.peek(); err != nil {
+		return err
+	} else if next == ']' {
+		return s.leave()
+	}
+	for {
+		if err := element(); err != nil {
+			return err
+		}
+		if done, err := s.endOf(']'); err != nil || done {
+			return err
+		}
+	}
+}
+
+func (s *jsonScanner) enter(open byte) error {
+	if err := s.expect(open); err != nil {
+		return err
+	}
+	if s.depth >= jsonMaxDepth {
+		return s.errorf("JSON is nested too deeply")
+	}
+	s.depth++
+	return nil
+}
+
+func (s *jsonScanner) leave() error {
+	s.depth--
+	_, err := s.read()
+	return err
+}
+
+// endOf reads the comma after an object member or array element, or the closing bracket
+func (s *jsonScanner) endOf(close byte) (bool, error) {
+	next, err := s.peek()
+	if err != nil {
+		return false, err
+	}
+	switch next {
+	case ',':
+		_, err := s.read()
+		return false, err
+	case close:
+		return true, s.leave()
+	default:
+		return false, s.unexpected(next, fmt.Sprintf("',' or %q", close))
+	}
+}
+
+// string reads a string, and unescapes it
+func (s *jsonScanner) string() (string, error) {
+	if err := s.expect('"'); err != nil {
+		return "", err
+	}
+	raw := []byte{'"'}
+	escaped := false
+	for {
+		c, err := s.read()
+		if err != nil {
+			return "", err
+		}
+		raw = append(raw, c)
+		switch {
+		case c == '\\':
+			escaped = true
+			next, err := s.read()
+			if err != nil {
+				return "", err
+			}
+			raw = append(raw, next)
+		case c == '"':
+			if !escaped {
+				return string(raw[1 : len(raw)-1]), nil
+			}
+			var value string
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return "", s.errorf("invalid string %s", raw)
+			}
+			return value, nil
+		case c < 0x20:
+			return "", s.errorf("invalid character %q in string", c)
+		}
+	}
+}
+
+// float reads a number
+func (s *jsonScanner) float() (float64, error) {
+	next, err := s.peek()
+	if err != nil {
+		return 0, err
+	}
+	if next != '-' && (next < '0' || next > '9') {
+		return 0, s.unexpected(next, "a number")
+	}
+	s.number = s.number[:0]
+	for {
+		next, err := s.r.Peek(1)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return 0, err
+		}
+		c := next[0]
+		if c != '+' && c != '-' && c != '.' && c != 'e' && c != 'E' && (c < '0' || c > '9') {
+			break
+		}
+		if _, err := s.read(); err != nil {
+			return 0, err
+		}
+		s.number = append(s.number, c)
+	}
+	value, err := strconv.ParseFloat(string(s.number), 64)
+	if err != nil && !errors.Is(err, strconv.ErrRange) {
+		return 0, s.errorf("invalid number %q", s.number)
+	}
+	return value, nil
+}
+
+// null reads null and returns true if it's the next value
+func (s *jsonScanner) null() (bool, error) {
+	next, err := s.peek()
+	if err != nil || next != 'n' {
+		return false, err
+	}
+	return true, s.literal("null")
+}
+
+func (s *jsonScanner) literal(word string) error {
+	for i := 0; i < len(word); i++ {
+		c, err := s.read()
+		if err != nil {
+			return err
+		}
+		if c != word[i] {
+			return s.errorf("invalid literal, expected %s", word)
+		}
+	}
+	return nil
+}
+
+// skip reads a value of any type, without keeping it
+func (s *jsonScanner) skip() error {
+	next, err := s.peek()
+	if err != nil {
+		return err
+	}
+	switch {
+	case next == '{':
+		return s.object(func(string) error { return s.skip() })
+	case next == '[':
+		return s.array(s.skip)
+	case next == '"':
+		// strings are skipped without being unescaped
+		if _, err := s.read(); err != nil {
+			return err
+		}
+		for {
+			c, err := s.read()
+			if err != nil {
+				return err
+			}
+			if c == '\\' {
+				if _, err := s.read(); err != nil {
+					return err
+				}
+			} else if c == '"' {
+				return nil
+			} else if c < 0x20 {
+				return s.errorf("invalid character %q in string", c)
+			}
+		}
+	case next == 't':
+		return s.literal("true")
+	case next == 'f':
+		return s.literal("false")
+	case next == 'n':
+		return s.literal("null")
+	default:
+		_, err := s.float()
+		return err
+	}
+}
+
+// raw reads a value of any type, returning its JSON. It's for small values that are decoded with
+// encoding/json.
+func (s *jsonScanner) raw() (json.RawMessage, error) {
+	if _, err := s.peek(); err != nil {
+		return nil, err
+	}
+	s.record = &bytes.Buffer{}
+	defer func() { s.record = nil }()
+	if err := s.skip(); err != nil {
+		return nil, err
+	}
+	return s.record.Bytes(), nil
+}
+
+func (s *jsonScanner) unexpected(c byte, expected string) error {
+	if c == 0 {
+		return s.errorf("unexpected end of JSON, expected %s", expected)
+	}
+	return s.errorf("invalid character %q, expected %s", c, expected)
+}
+
+func (s *jsonScanner) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w at offset %d: %s", errInvalidJSON, s.offset, fmt.Sprintf(format, args...))
+}
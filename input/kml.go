@@ -0,0 +1,138 @@
+package input
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/mikeocool/bbox/core"
+	"github.com/mikeocool/bbox/proj"
+)
+
+func SniffKml(data []byte) bool {
+	return bytes.Contains(data, []byte("<kml"))
+}
+
+// kmlLatLonBox is the extent of a GroundOverlay
+type kmlLatLonBox struct {
+	North float64 `xml:"north"`
+	South float64 `xml:"south"`
+	East  float64 `xml:"east"`
+	West  float64 `xml:"west"`
+}
+
+// ParseKml reads the bounds of the geometries in a KML document: the coordinates of Points, LineStrings,
+// Polygons and the geometries in a MultiGeometry, the positions of a gx:Track, and the LatLonBox of
+// GroundOverlays. The document is streamed, rather than read into memory. KML is always WGS84.
+func ParseKml(r io.Reader) (core.Bbox, *proj.CRS, error) {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	visit := func(x, y float64) {
+		updateBounds(&minX, &minY, &maxX, &maxY, x, y)
+	}
+	// overlays are kept as boxes, since they can cross the antimeridian
+	var overlays []core.Bbox
+
+	decoder := newXmlDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return core.Bbox{}, nil, fmt.Errorf("invalid KML: %w", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "coordinates":
+			var text string
+			if err := decoder.DecodeElement(&text, &start); err != nil {
+				return core.Bbox{}, nil, fmt.Errorf("invalid KML: %w", err)
+			}
+			if err := parseKmlCoordinates(text, visit); err != nil {
+				return core.Bbox{}, nil, err
+			}
+		case "coord":
+			// gx:coord in a gx:Track, "lon lat alt" separated by spaces
+			var text string
+			if err := decoder.DecodeElement(&text, &start); err != nil {
+				return core.Bbox{}, nil, fmt.Errorf("invalid KML: %w", err)
+			}
+			x, y, err := parseKmlPosition(strings.Fields(text))
+			if err != nil {
+				return core.Bbox{}, nil, err
+			}
+			visit(x, y)
+		case "LatLonBox":
+			var box kmlLatLonBox
+			if err := decoder.DecodeElement(&box, &start); err != nil {
+				return core.Bbox{}, nil, fmt.Errorf("invalid KML LatLonBox: %w", err)
+			}
+			overlays = append(overlays, core.Bbox{Left: box.West, Bottom: box.South, Right: box.East, Top: box.North})
+		}
+	}
+
+	var bbox *core.Bbox
+	if minX <= maxX {
+		bbox = &core.Bbox{Left: minX, Bottom: minY, Right: maxX, Top: maxY}
+	}
+	for _, overlay := range overlays {
+		if bbox == nil {
+			bbox = &overlay
+		} else {
+			updated_bbox := bbox.Union(overlay)
+			bbox = &updated_bbox
+		}
+	}
+	if bbox == nil {
+		return core.Bbox{}, nil, ErrNoFeaturesFound
+	}
+
+	wgs84, err := proj.Lookup(proj.EPSGWgs84)
+	if err != nil {
+		return core.Bbox{}, nil, err
+	}
+	return *bbox, wgs84, nil
+}
+
+// parseKmlCoordinates parses the "lon,lat[,alt]" tuples separated by whitespace in a coordinates
+// element. Some writers put spaces after the commas, so those are joined back into one tuple.
+func parseKmlCoordinates(text string, visit func(x, y float64)) error {
+	fields := strings.Fields(text)
+	for i := 0; i < len(fields); i++ {
+		tuple := fields[i]
+		for i+1 < len(fields) && (strings.HasSuffix(tuple, ",") || strings.HasPrefix(fields[i+1], ",")) {
+			i++
+			tuple += fields[i]
+		}
+
+		x, y, err := parseKmlPosition(strings.Split(tuple, ","))
+		if err != nil {
+			return err
+		}
+		visit(x, y)
+	}
+	return nil
+}
+
+// parseKmlPosition parses the longitude and latitude of a position, ignoring the altitude
+func parseKmlPosition(values []string) (float64, float64, error) {
+	if len(values) < 2 {
+		return 0, 0, fmt.Errorf("invalid KML coordinate %q", strings.Join(values, ","))
+	}
+	x, errX := strconv.ParseFloat(values[0], 64)
+	y, errY := strconv.ParseFloat(values[1], 64)
+	if err := errors.Join(errX, errY); err != nil {
+		return 0, 0, fmt.Errorf("invalid KML coordinate %q", strings.Join(values, ","))
+	}
+	return x, y, nil
+}
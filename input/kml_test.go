@@ -0,0 +1,181 @@
+package input
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mikeocool/bbox/core"
+)
+
+func kmlDocument(body string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<kml xmlns="http://www.opengis.net/kml/2.2" xmlns:gx="http://www.google.com/kml/ext/2.2">
+<Document>` + body + `</Document>
+</kml>`
+}
+
+func TestParseKml(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    core.Bbox
+		wantErr bool
+	}{
+		{
+			name:  "point",
+			input: kmlDocument(`<Placemark><Point><coordinates>-122.08,37.42,0</coordinates></Point></Placemark>`),
+			want:  core.Bbox{Left: -122.08, Bottom: 37.42, Right: -122.08, Top: 37.42},
+		},
+		{
+			name: "line string and polygon",
+			input: kmlDocument(`
+				<Placemark><LineString><coordinates>
+					-112.26,36.09,2357
+					-112.25,36.08,2357
+				</coordinates></LineString></Placemark>
+				<Placemark><Polygon>
+					<outerBoundaryIs><LinearRing><coordinates>
+						-77.05,38.87 -77.06,38.87 -77.06,38.86 -77.05,38.87
+					</coordinates></LinearRing></outerBoundaryIs>
+					<innerBoundaryIs><LinearRing><coordinates>
+						-77.055,38.868 -77.056,38.868 -77.055,38.867 -77.055,38.868
+					</coordinates></LinearRing></innerBoundaryIs>
+				</Polygon></Placemark>`),
+			want: core.Bbox{Left: -112.26, Bottom: 36.08, Right: -77.05, Top: 38.87},
+		},
+		{
+			name: "multi geometry in folders",
+			input: kmlDocument(`<Folder><Folder><Placemark><MultiGeometry>
+				<Point><coordinates>1,2</coordinates></Point>
+				<LineString><coordinates>3,4 5,6</coordinates></LineString>
+				<MultiGeometry><Point><coordinates>-1,-2</coordinates></Point></MultiGeometry>
+			</MultiGeometry></Placemark></Folder></Folder>`),
+			want: core.Bbox{Left: -1, Bottom: -2, Right: 5, Top: 6},
+		},
+		{
+			name: "gx:Track",
+			input: kmlDocument(`<Placemark><gx:Track>
+				<when>2010-05-28T02:02:09Z</when>
+				<when>2010-05-28T02:02:35Z</when>
+				<gx:coord>-122.207881 37.371915 156.0</gx:coord>
+				<gx:coord>-122.205712 37.373288 152.0</gx:coord>
+			</gx:Track></Placemark>`),
+			want: core.Bbox{Left: -122.207881, Bottom: 37.371915, Right: -122.205712, Top: 37.373288},
+		},
+		{
+			name: "ground overlay",
+			input: kmlDocument(`<GroundOverlay><Icon><href>overlay.png</href></Icon>
+				<LatLonBox><north>37.91</north><south>37.46</south><east>15.35</east><west>14.60</west><rotation>0</rotation></LatLonBox>
+			</GroundOverlay>`),
+			want: core.Bbox{Left: 14.60, Bottom: 37.46, Right: 15.35, Top: 37.91},
+		},
+		{
+			name: "ground overlay across the antimeridian",
+			input: kmlDocument(`
+				<GroundOverlay><LatLonBox><north>-15</north><south>-20</south><east>-178</east><west>177</west></LatLonBox></GroundOverlay>
+				<Placemark><Point><coordinates>179,-16</coordinates></Point></Placemark>`),
+			want: core.Bbox{Left: 177, Bottom: -20, Right: -178, Top: -15},
+		},
+		{
+			name:  "spaces after commas",
+			input: kmlDocument(`<Placemark><LineString><coordinates>10, 20, 0 11 ,21</coordinates></LineString></Placemark>`),
+			want:  core.Bbox{Left: 10, Bottom: 20, Right: 11, Top: 21},
+		},
+		{
+			name: "latin-1 encoding",
+			input: `<?xml version="1.0" encoding="ISO-8859-1"?>
+<kml xmlns="http://www.opengis.net/kml/2.2"><Placemark><Point><coordinates>1,2</coordinates></Point></Placemark></kml>`,
+			want: core.Bbox{Left: 1, Bottom: 2, Right: 1, Top: 2},
+		},
+		{
+			name:    "invalid coordinates",
+			input:   kmlDocument(`<Placemark><Point><coordinates>a,b</coordinates></Point></Placemark>`),
+			wantErr: true,
+		},
+		{
+			name:    "invalid XML",
+			input:   `<kml><Placemark><Point><coordinates>1,2</Point>`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, crs, err := ParseKml(strings.NewReader(tt.input))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseKml() expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseKml() unexpected error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseKml() = %v, want %v", got, tt.want)
+			}
+			if crs == nil || crs.Code != 4326 {
+				t.Errorf("ParseKml() crs = %v, want EPSG:4326", crs)
+			}
+		})
+	}
+
+	t.Run("no geometries", func(t *testing.T) {
+		_, _, err := ParseKml(strings.NewReader(kmlDocument(`<Placemark><name>nothing</name></Placemark>`)))
+		if !errors.Is(err, ErrNoFeaturesFound) {
+			t.Errorf("ParseKml() error = %v, want %v", err, ErrNoFeaturesFound)
+		}
+	})
+}
+
+func TestParseDataKml(t *testing.T) {
+	doc := kmlDocument(`<Placemark><Point><coordinates>-122.08,37.42</coordinates></Point></Placemark>`)
+	want := core.Bbox{Left: -122.08, Bottom: 37.42, Right: -122.08, Top: 37.42}
+
+	t.Run("sniffed kml", func(t *testing.T) {
+		got, _, err := ParseData(strings.NewReader(doc), ReadOptions{})
+		if err != nil {
+			t.Fatalf("ParseData() unexpected error = %v", err)
+		}
+		if got != want {
+			t.Errorf("ParseData() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("kmz", func(t *testing.T) {
+		data := buildZip(t, []archiveMember{
+			{"doc.kml", []byte(doc)},
+			{"files/overlay.png", []byte("\x89PNG\r\n")},
+		})
+		filename := filepath.Join(t.TempDir(), "export.kmz")
+		if err := os.WriteFile(filename, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		got, crs, err := LoadFile(filename, ReadOptions{})
+		if err != nil {
+			t.Fatalf("LoadFile() unexpected error = %v", err)
+		}
+		if got != want {
+			t.Errorf("LoadFile() = %v, want %v", got, want)
+		}
+		if crs == nil || crs.Code != 4326 {
+			t.Errorf("LoadFile() crs = %v, want EPSG:4326", crs)
+		}
+	})
+
+	t.Run("kml with unknown extension in kmz", func(t *testing.T) {
+		data := buildZip(t, []archiveMember{{"export.txt", []byte(doc)}})
+		got, _, err := ParseData(bytes.NewReader(data), ReadOptions{})
+		if err != nil {
+			t.Fatalf("ParseData() unexpected error = %v", err)
+		}
+		if got != want {
+			t.Errorf("ParseData() = %v, want %v", got, want)
+		}
+	})
+}
@@ -0,0 +1,154 @@
+package input
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+
+	"github.com/mikeocool/bbox/core"
+	"github.com/mikeocool/bbox/proj"
+)
+
+// Offsets in the LAS public header block
+const (
+	lasGlobalEncoding = 6
+	lasVersionMinor   = 25
+	lasHeaderSize     = 94
+	lasPointOffset    = 96
+	lasVlrCount       = 100
+	lasLegacyCount    = 107
+	lasMaxX           = 179
+	lasMinX           = 187
+	lasMaxY           = 195
+	lasMinY           = 203
+	lasMaxZ           = 211
+	lasMinZ           = 219
+	lasPointCount     = 247
+	// lasMinHeaderSize is the size of the LAS 1.0 to 1.2 header, the fields of later versions are after it
+	lasMinHeaderSize = 227
+	lasVlrHeaderSize = 54
+)
+
+// lasWktEncoding is the global encoding bit for a CRS in WKT, rather than GeoTIFF keys
+const lasWktEncoding = 1 << 4
+
+// LAS projection records
+const (
+	lasProjectionUser   = "LASF_Projection"
+	lasGeoKeyDirectory  = 34735
+	lasGeoAsciiParams   = 34737
+	lasWktCoordinateSys = 2112
+)
+
+// SniffLas checks for the LAS file signature, which LAZ files share
+func SniffLas(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("LASF"))
+}
+
+// ParseLas reads the bounds of a LAS or LAZ point cloud from the min and max X, Y and Z in its
+// header, and the CRS from its projection records. The points aren't read, so compressed LAZ
+// files don't need to be decompressed.
+func ParseLas(r io.Reader) (core.Bbox, *proj.CRS, error) {
+	header := make([]byte, lasMinHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil || !SniffLas(header) {
+		return core.Bbox{}, nil, errors.New("invalid LAS file, missing header")
+	}
+	headerSize := int(binary.LittleEndian.Uint16(header[lasHeaderSize:]))
+	if headerSize < lasMinHeaderSize {
+		return core.Bbox{}, nil, fmt.Errorf("invalid LAS header size %d", headerSize)
+	}
+	header = append(header, make([]byte, headerSize-lasMinHeaderSize)...)
+	if _, err := io.ReadFull(r, header[lasMinHeaderSize:]); err != nil {
+		return core.Bbox{}, nil, fmt.Errorf("failed to read LAS header: %w", err)
+	}
+
+	// LAS 1.4 has a 64 bit point count, and the legacy count is 0 for larger files
+	count := uint64(binary.LittleEndian.Uint32(header[lasLegacyCount:]))
+	if header[lasVersionMinor] >= 4 && headerSize >= lasPointCount+8 {
+		count = max(count, binary.LittleEndian.Uint64(header[lasPointCount:]))
+	}
+	if count == 0 {
+		return core.Bbox{}, nil, ErrNoFeaturesFound
+	}
+
+	float := func(offset int) float64 {
+		return math.Float64frombits(binary.LittleEndian.Uint64(header[offset:]))
+	}
+	minX, minY, maxX, maxY := float(lasMinX), float(lasMinY), float(lasMaxX), float(lasMaxY)
+	if boundsInvalid(minX, minY, maxX, maxY) {
+		return core.Bbox{}, nil, errors.New("invalid LAS header bounds")
+	}
+
+	vlrs, err := readLasVlrs(r, binary.LittleEndian.Uint32(header[lasVlrCount:]),
+		int64(binary.LittleEndian.Uint32(header[lasPointOffset:]))-int64(headerSize))
+	if err != nil {
+		return core.Bbox{}, nil, err
+	}
+	box := core.Bbox{Left: minX, Bottom: minY, Right: maxX, Top: maxY}
+	// the heights are left out if they aren't valid, rather than failing
+	minZ, maxZ := float(lasMinZ), float(lasMaxZ)
+	if !math.IsNaN(minZ) && !math.IsNaN(maxZ) && !math.IsInf(minZ, 0) && !math.IsInf(maxZ, 0) && minZ <= maxZ {
+		box.HasZ, box.MinZ, box.MaxZ = true, minZ, maxZ
+	}
+	wkt := binary.LittleEndian.Uint16(header[lasGlobalEncoding:])&lasWktEncoding != 0
+	return box, lasCrs(vlrs, wkt), nil
+}
+
+// readLasVlrs reads the projection variable length records that are between the header and the
+// points, by record id
+func readLasVlrs(r io.Reader, count uint32, size int64) (map[uint16][]byte, error) {
+	vlrs := make(map[uint16][]byte)
+	vlrHeader := make([]byte, lasVlrHeaderSize)
+	for i := uint32(0); i < count && size >= lasVlrHeaderSize; i++ {
+		if _, err := io.ReadFull(r, vlrHeader); err != nil {
+			return nil, fmt.Errorf("failed to read LAS variable length record: %w", err)
+		}
+		user := string(bytes.TrimRight(vlrHeader[2:18], "\x00"))
+		recordId := binary.LittleEndian.Uint16(vlrHeader[18:])
+		length := int64(binary.LittleEndian.Uint16(vlrHeader[20:]))
+		size -= lasVlrHeaderSize + length
+
+		if user != lasProjectionUser {
+			if _, err := io.CopyN(io.Discard, r, length); err != nil {
+				return nil, fmt.Errorf("failed to read LAS variable length record: %w", err)
+			}
+			continue
+		}
+		data := make([]byte, length)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, fmt.Errorf("failed to read LAS variable length record: %w", err)
+		}
+		vlrs[recordId] = data
+	}
+	return vlrs, nil
+}
+
+// lasCrs returns the CRS from the WKT or GeoTIFF keys projection records, or nil if there isn't one
+func lasCrs(vlrs map[uint16][]byte, wkt bool) *proj.CRS {
+	if wkt {
+		data, ok := vlrs[lasWktCoordinateSys]
+		if !ok {
+			return nil
+		}
+		crs, err := proj.ParseWkt(string(bytes.TrimRight(data, "\x00")))
+		if err != nil {
+			log.Printf("Could not parse LAS CRS: %v\n", err)
+			return nil
+		}
+		return crs
+	}
+
+	data, ok := vlrs[lasGeoKeyDirectory]
+	if !ok {
+		return nil
+	}
+	directory := make([]uint64, len(data)/2)
+	for i := range directory {
+		directory[i] = uint64(binary.LittleEndian.Uint16(data[i*2:]))
+	}
+	return geotiffCrs(parseGeoKeys(directory, vlrs[lasGeoAsciiParams]))
+}
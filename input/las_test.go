@@ -0,0 +1,128 @@
+package input
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/mikeocool/bbox/core"
+)
+
+type lasVlr struct {
+	user     string
+	recordId uint16
+	data     []byte
+}
+
+// buildLas writes a LAS header of the version with the bounds and VLRs, followed by a fake point
+func buildLas(minor byte, count uint64, box core.Bbox, encoding uint16, vlrs []lasVlr) []byte {
+	headerSize := lasMinHeaderSize
+	if minor >= 4 {
+		headerSize = 375
+	}
+	header := make([]byte, headerSize)
+	copy(header, "LASF")
+	binary.LittleEndian.PutUint16(header[lasGlobalEncoding:], encoding)
+	header[24], header[lasVersionMinor] = 1, minor
+	binary.LittleEndian.PutUint16(header[lasHeaderSize:], uint16(headerSize))
+	binary.LittleEndian.PutUint32(header[lasVlrCount:], uint32(len(vlrs)))
+	if minor >= 4 {
+		binary.LittleEndian.PutUint64(header[lasPointCount:], count)
+	} else {
+		binary.LittleEndian.PutUint32(header[lasLegacyCount:], uint32(count))
+	}
+	bounds := map[int]float64{lasMinX: box.Left, lasMinY: box.Bottom, lasMaxX: box.Right, lasMaxY: box.Top}
+	if box.HasZ {
+		bounds[lasMinZ], bounds[lasMaxZ] = box.MinZ, box.MaxZ
+	}
+	for offset, v := range bounds {
+		binary.LittleEndian.PutUint64(header[offset:], math.Float64bits(v))
+	}
+
+	for _, vlr := range vlrs {
+		vlrHeader := make([]byte, lasVlrHeaderSize)
+		copy(vlrHeader[2:18], vlr.user)
+		binary.LittleEndian.PutUint16(vlrHeader[18:], vlr.recordId)
+		binary.LittleEndian.PutUint16(vlrHeader[20:], uint16(len(vlr.data)))
+		header = append(append(header, vlrHeader...), vlr.data...)
+	}
+	binary.LittleEndian.PutUint32(header[lasPointOffset:], uint32(len(header)))
+	return append(header, make([]byte, 20)...)
+}
+
+func TestParseLas(t *testing.T) {
+	box := core.Bbox{Left: 500000.25, Bottom: 5297000.5, Right: 501000.75, Top: 5298000, HasZ: true, MinZ: 180.5, MaxZ: 412.25}
+	var geoKeys []byte
+	for _, v := range geoKeyDirectory([4]uint16{geoKeyModelType, 0, 1, geoModelProjected}, [4]uint16{geoKeyProjectedType, 0, 1, 26915}) {
+		geoKeys = binary.LittleEndian.AppendUint16(geoKeys, v)
+	}
+	wkt := `PROJCS["WGS 84 / UTM zone 15N",GEOGCS["WGS 84",DATUM["WGS_1984",SPHEROID["WGS 84",6378137,298.257223563]],` +
+		`PRIMEM["Greenwich",0],UNIT["degree",0.0174532925199433]],PROJECTION["Transverse_Mercator"],` +
+		`PARAMETER["latitude_of_origin",0],PARAMETER["central_meridian",-93],PARAMETER["scale_factor",0.9996],` +
+		`PARAMETER["false_easting",500000],PARAMETER["false_northing",0],UNIT["metre",1],AUTHORITY["EPSG","32615"]]` + "\x00"
+
+	tests := []struct {
+		name     string
+		data     []byte
+		wantCode int
+		errorMsg string
+	}{
+		{
+			name: "GeoTIFF keys",
+			data: buildLas(2, 1000, box, 0, []lasVlr{
+				{user: "LASF_Spec", recordId: 4, data: []byte("extra bytes")},
+				{user: lasProjectionUser, recordId: lasGeoKeyDirectory, data: geoKeys},
+			}),
+			wantCode: 26915,
+		},
+		{
+			name:     "LAS 1.4 with WKT",
+			data:     buildLas(4, 1<<33, box, lasWktEncoding, []lasVlr{{user: lasProjectionUser, recordId: lasWktCoordinateSys, data: []byte(wkt)}}),
+			wantCode: 32615,
+		},
+		{
+			name: "no CRS",
+			data: buildLas(2, 1000, box, 0, nil),
+		},
+		{
+			name:     "no points",
+			data:     buildLas(2, 0, box, 0, nil),
+			errorMsg: "no features found",
+		},
+		{
+			name:     "invalid bounds",
+			data:     buildLas(2, 1000, core.Bbox{Left: 1, Bottom: 1, Right: 0, Top: 0}, 0, nil),
+			errorMsg: "invalid LAS header bounds",
+		},
+		{
+			name:     "truncated",
+			data:     buildLas(2, 1000, box, 0, nil)[:100],
+			errorMsg: "invalid LAS file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, crs, err := ParseData(bytes.NewReader(tt.data), ReadOptions{})
+			if tt.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("ParseData() error = %v, want it to contain %q", err, tt.errorMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseData() unexpected error = %v", err)
+			}
+			if got != box {
+				t.Errorf("ParseData() = %v, want %v", got, box)
+			}
+			if tt.wantCode == 0 && crs != nil {
+				t.Errorf("ParseData() crs = %v, want nil", crs)
+			} else if tt.wantCode != 0 && (crs == nil || crs.Code != tt.wantCode) {
+				t.Errorf("ParseData() crs = %v, want EPSG:%d", crs, tt.wantCode)
+			}
+		})
+	}
+}
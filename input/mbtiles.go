@@ -0,0 +1,169 @@
+package input
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/mikeocool/bbox/core"
+	"github.com/mikeocool/bbox/proj"
+)
+
+// SniffMbtiles checks for a SQLite database with the MBTiles application id. Older MBTiles
+// files don't have one, and are only recognized by their extension.
+func SniffMbtiles(data []byte) bool {
+	return len(data) >= 72 && bytes.HasPrefix(data, sqliteMagic) && string(data[68:72]) == "MPBX"
+}
+
+// ParseMbtiles reads the bounds of an MBTiles file from the bounds row of its metadata table. When
+// there isn't one, the bounds are computed from the tiles at the highest zoom level. The zoom
+// levels are logged when opts.Verbose is set.
+func ParseMbtiles(r io.ReaderAt, size int64, opts ReadOptions) (core.Bbox, *proj.CRS, error) {
+	db, err := openSqlite(r, size)
+	if err != nil {
+		return core.Bbox{}, nil, fmt.Errorf("invalid MBTiles: %w", err)
+	}
+	metadata, err := readMbtilesMetadata(db)
+	if err != nil {
+		return core.Bbox{}, nil, err
+	}
+
+	var box core.Bbox
+	minZoom, maxZoom := metadata["minzoom"], metadata["maxzoom"]
+	if bounds, ok := metadata["bounds"]; ok {
+		if box, err = parseMbtilesBounds(bounds); err != nil {
+			return core.Bbox{}, nil, err
+		}
+	} else {
+		var tiles core.TileRange
+		if tiles, minZoom, err = readMbtilesTileRange(db); err != nil {
+			return core.Bbox{}, nil, err
+		}
+		box = mbtilesTileRangeBbox(tiles)
+		maxZoom = strconv.Itoa(tiles.Z)
+	}
+	if opts.Verbose {
+		log.Printf("MBTiles zoom levels %s to %s\n", minZoom, maxZoom)
+	}
+
+	crs, err := proj.Lookup(proj.EPSGWgs84)
+	if err != nil {
+		return core.Bbox{}, nil, err
+	}
+	return box, crs, nil
+}
+
+// readMbtilesMetadata returns the values of the metadata table by name
+func readMbtilesMetadata(db *sqliteDB) (map[string]string, error) {
+	table, err := db.table("metadata")
+	if err != nil {
+		return nil, fmt.Errorf("invalid MBTiles: %w", err)
+	}
+	if table == nil {
+		return nil, errors.New("invalid MBTiles, missing the metadata table")
+	}
+	nameColumn, valueColumn := table.column("name"), table.column("value")
+	if nameColumn < 0 || valueColumn < 0 {
+		return nil, errors.New("invalid MBTiles, metadata does not have name and value columns")
+	}
+
+	metadata := make(map[string]string)
+	err = table.scan(func(row sqliteRow) error {
+		name, _ := row.value(nameColumn).(string)
+		// zoom levels are sometimes stored as integers rather than text
+		switch value := row.value(valueColumn).(type) {
+		case string:
+			metadata[name] = value
+		case int64:
+			metadata[name] = strconv.FormatInt(value, 10)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid MBTiles: %w", err)
+	}
+	return metadata, nil
+}
+
+// parseMbtilesBounds parses bounds metadata, which is the left, bottom, right and top in WGS84
+// separated by commas
+func parseMbtilesBounds(bounds string) (core.Bbox, error) {
+	parts := strings.Split(bounds, ",")
+	if len(parts) != 4 {
+		return core.Bbox{}, fmt.Errorf("invalid MBTiles bounds %q", bounds)
+	}
+	var values [4]float64
+	for i, part := range parts {
+		value, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return core.Bbox{}, fmt.Errorf("invalid MBTiles bounds %q", bounds)
+		}
+		values[i] = value
+	}
+	if boundsInvalid(values[0], values[1], values[2], values[3]) {
+		return core.Bbox{}, fmt.Errorf("invalid MBTiles bounds %q", bounds)
+	}
+	return core.Bbox{Left: values[0], Bottom: values[1], Right: values[2], Top: values[3]}, nil
+}
+
+// readMbtilesTileRange returns the range of tiles at the highest zoom level, and the lowest zoom
+// level. The rows are converted from the TMS scheme of MBTiles, which counts up from the bottom,
+// to XYZ rows. Tiles are in the tiles table, or the map table that tiles is a view of.
+func readMbtilesTileRange(db *sqliteDB) (core.TileRange, string, error) {
+	var table *sqliteTable
+	for _, name := range []string{"tiles", "map"} {
+		var err error
+		if table, err = db.table(name); err != nil {
+			return core.TileRange{}, "", fmt.Errorf("invalid MBTiles: %w", err)
+		}
+		if table != nil {
+			break
+		}
+	}
+	if table == nil {
+		return core.TileRange{}, "", errors.New("MBTiles does not have bounds metadata or a tiles table")
+	}
+	zoomColumn, columnColumn, rowColumn := table.column("zoom_level"), table.column("tile_column"), table.column("tile_row")
+	if zoomColumn < 0 || columnColumn < 0 || rowColumn < 0 {
+		return core.TileRange{}, "", fmt.Errorf("invalid MBTiles, %s does not have zoom_level, tile_column and tile_row columns", table.name)
+	}
+
+	tiles := core.TileRange{Z: -1}
+	minZoom := math.MaxInt
+	err := table.scan(func(row sqliteRow) error {
+		zoom, _ := row.value(zoomColumn).(int64)
+		column, _ := row.value(columnColumn).(int64)
+		tmsRow, _ := row.value(rowColumn).(int64)
+		if zoom < 0 || zoom > core.MaxTileZoom {
+			return fmt.Errorf("invalid MBTiles zoom level %d", zoom)
+		}
+		z, x, y := int(zoom), int(column), 1<<zoom-1-int(tmsRow)
+		minZoom = min(minZoom, z)
+		if z > tiles.Z {
+			tiles = core.TileRange{Z: z, MinX: x, MinY: y, MaxX: x, MaxY: y}
+		} else if z == tiles.Z {
+			tiles.MinX, tiles.MaxX = min(tiles.MinX, x), max(tiles.MaxX, x)
+			tiles.MinY, tiles.MaxY = min(tiles.MinY, y), max(tiles.MaxY, y)
+		}
+		return nil
+	})
+	if err != nil {
+		return core.TileRange{}, "", fmt.Errorf("invalid MBTiles: %w", err)
+	}
+	if tiles.Z < 0 {
+		return core.TileRange{}, "", ErrNoFeaturesFound
+	}
+	return tiles, strconv.Itoa(minZoom), nil
+}
+
+// mbtilesTileRangeBbox returns the bounds of the tiles in the range
+func mbtilesTileRangeBbox(tiles core.TileRange) core.Bbox {
+	topLeft := core.Tile{Z: tiles.Z, X: tiles.MinX, Y: tiles.MinY}.Bbox()
+	bottomRight := core.Tile{Z: tiles.Z, X: tiles.MaxX, Y: tiles.MaxY}.Bbox()
+	return core.Bbox{Left: topLeft.Left, Bottom: bottomRight.Bottom, Right: bottomRight.Right, Top: topLeft.Top}
+}
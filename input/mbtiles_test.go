@@ -0,0 +1,88 @@
+package input
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mikeocool/bbox/core"
+)
+
+const (
+	// campsitesMbtiles has bounds, minzoom and maxzoom metadata, with maxzoom stored as an integer
+	campsitesMbtiles = "../integration_tests/data/campsites.mbtiles"
+	// campsitesTilesMbtiles has no bounds or zoom metadata, and its tiles are a view of a map table,
+	// at zoom levels 6 and 9
+	campsitesTilesMbtiles = "../integration_tests/data/campsites_tiles.mbtiles"
+)
+
+func TestParseMbtiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		want     core.Bbox
+		wantZoom string
+	}{
+		{
+			name:     "bounds metadata",
+			file:     campsitesMbtiles,
+			want:     core.Bbox{Left: -92.434258, Bottom: 47.751725, Right: -90.021912, Top: 48.356628},
+			wantZoom: "MBTiles zoom levels 4 to 12",
+		},
+		{
+			name:     "tiles at the highest zoom",
+			file:     campsitesTilesMbtiles,
+			want:     core.Bbox{Left: -92.8125, Bottom: 47.517200697, Right: -90, Top: 48.458351882},
+			wantZoom: "MBTiles zoom levels 6 to 9",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logged bytes.Buffer
+			previous := log.Writer()
+			log.SetOutput(&logged)
+			defer log.SetOutput(previous)
+
+			got, crs, err := LoadFile(tt.file, ReadOptions{Verbose: true})
+			if err != nil {
+				t.Fatalf("LoadFile() unexpected error = %v", err)
+			}
+			if !bboxWithin(got, tt.want, 1e-8) {
+				t.Errorf("LoadFile() = %v, want %v", got, tt.want)
+			}
+			if crs == nil || crs.Code != 4326 {
+				t.Errorf("LoadFile() crs = %v, want EPSG:4326", crs)
+			}
+			if !strings.Contains(logged.String(), tt.wantZoom) {
+				t.Errorf("LoadFile() logged %q, want %q", logged.String(), tt.wantZoom)
+			}
+		})
+	}
+
+	t.Run("ParseData sniffs the application id", func(t *testing.T) {
+		data, err := os.ReadFile(campsitesMbtiles)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _, err := ParseData(bytes.NewReader(data), ReadOptions{})
+		if err != nil || got.Left != -92.434258 {
+			t.Errorf("ParseData() = %v, %v", got, err)
+		}
+	})
+}
+
+func TestParseMbtilesBounds(t *testing.T) {
+	if _, err := parseMbtilesBounds("-180,-85.05,180"); err == nil {
+		t.Errorf("parseMbtilesBounds() expected an error for 3 values")
+	}
+	if _, err := parseMbtilesBounds("10,0,-10,5"); err == nil {
+		t.Errorf("parseMbtilesBounds() expected an error for left > right")
+	}
+	got, err := parseMbtilesBounds("-180, -85.0511, 180, 85.0511")
+	if err != nil || got != (core.Bbox{Left: -180, Bottom: -85.0511, Right: 180, Top: 85.0511}) {
+		t.Errorf("parseMbtilesBounds() = %v, %v", got, err)
+	}
+}
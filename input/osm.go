@@ -0,0 +1,462 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/mikeocool/bbox/core"
+	"github.com/mikeocool/bbox/proj"
+)
+
+func SniffOsm(data []byte) bool {
+	return bytes.Contains(data, []byte("<osm"))
+}
+
+// SniffOsmPbf checks for the header of the first blob in an OSM PBF file, which has the type "OSMHeader"
+func SniffOsmPbf(data []byte) bool {
+	return len(data) >= 15 && data[4] == 0x0a && data[5] == 9 && string(data[6:15]) == "OSMHeader"
+}
+
+// ParseOsm reads the bounds of an OSM XML document. The bounds element is used if there is one and
+// opts.ScanGeometries isn't set, otherwise the bounds are computed from the nodes. The document is
+// streamed, rather than read into memory.
+func ParseOsm(r io.Reader, opts ReadOptions) (core.Bbox, *proj.CRS, error) {
+	wgs84, err := proj.Lookup(proj.EPSGWgs84)
+	if err != nil {
+		return core.Bbox{}, nil, err
+	}
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+
+	decoder := newXmlDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return core.Bbox{}, nil, fmt.Errorf("invalid OSM XML: %w", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "bounds":
+			if opts.ScanGeometries {
+				continue
+			}
+			bounds, err := parseXmlBounds(start)
+			if err != nil {
+				return core.Bbox{}, nil, fmt.Errorf("invalid OSM XML: %w", err)
+			}
+			return bounds, wgs84, nil
+		case "bound":
+			// osmosis writes <bound box="minlat,minlon,maxlat,maxlon"/>
+			if opts.ScanGeometries {
+				continue
+			}
+			bounds, err := parseOsmosisBound(xmlAttr(start, "box"))
+			if err != nil {
+				return core.Bbox{}, nil, err
+			}
+			return bounds, wgs84, nil
+		case "node":
+			// deleted nodes in history and change files don't have a location
+			lat, lon := xmlAttr(start, "lat"), xmlAttr(start, "lon")
+			if lat == "" && lon == "" {
+				continue
+			}
+			y, errLat := strconv.ParseFloat(lat, 64)
+			x, errLon := strconv.ParseFloat(lon, 64)
+			if errLat != nil || errLon != nil {
+				return core.Bbox{}, nil, fmt.Errorf("invalid OSM node %s: lat=%q lon=%q", xmlAttr(start, "id"), lat, lon)
+			}
+			updateBounds(&minX, &minY, &maxX, &maxY, x, y)
+		}
+	}
+
+	if minX > maxX {
+		return core.Bbox{}, nil, ErrNoFeaturesFound
+	}
+	return core.Bbox{
+		Left:   minX,
+		Bottom: minY,
+		Right:  maxX,
+		Top:    maxY,
+	}, wgs84, nil
+}
+
+func parseOsmosisBound(box string) (core.Bbox, error) {
+	parts := strings.Split(box, ",")
+	if len(parts) != 4 {
+		return core.Bbox{}, fmt.Errorf("invalid OSM bound box=%q", box)
+	}
+	var values [4]float64
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return core.Bbox{}, fmt.Errorf("invalid OSM bound box=%q", box)
+		}
+		values[i] = v
+	}
+	return core.Bbox{Left: values[1], Bottom: values[0], Right: values[3], Top: values[2]}, nil
+}
+
+// Size limits from the OSM PBF spec
+const (
+	pbfMaxBlobHeaderSize = 64 * 1024
+	pbfMaxBlobSize       = 32 * 1024 * 1024
+)
+
+// ParseOsmPbf reads the bounds of an OSM PBF file. The bbox in the file's header is used if there
+// is one and opts.ScanGeometries isn't set, otherwise the bounds are computed from the nodes. The
+// file is read one blob at a time, rather than into memory.
+func ParseOsmPbf(r io.Reader, opts ReadOptions) (core.Bbox, *proj.CRS, error) {
+	wgs84, err := proj.Lookup(proj.EPSGWgs84)
+	if err != nil {
+		return core.Bbox{}, nil, err
+	}
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	visit := func(x, y float64) {
+		updateBounds(&minX, &minY, &maxX, &maxY, x, y)
+	}
+
+	br := bufio.NewReader(r)
+	sizeBuf := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(br, sizeBuf); err == io.EOF {
+			break
+		} else if err != nil {
+			return core.Bbox{}, nil, fmt.Errorf("truncated OSM PBF blob header")
+		}
+
+		headerSize := binary.BigEndian.Uint32(sizeBuf)
+		if headerSize > pbfMaxBlobHeaderSize {
+			return core.Bbox{}, nil, fmt.Errorf("invalid OSM PBF blob header size %d", headerSize)
+		}
+		header := make([]byte, headerSize)
+		if _, err := io.ReadFull(br, header); err != nil {
+			return core.Bbox{}, nil, fmt.Errorf("truncated OSM PBF blob header")
+		}
+		blobType, blobSize, err := parsePbfBlobHeader(header)
+		if err != nil {
+			return core.Bbox{}, nil, err
+		}
+		if blobSize > pbfMaxBlobSize {
+			return core.Bbox{}, nil, fmt.Errorf("invalid OSM PBF blob size %d", blobSize)
+		}
+		blob := make([]byte, blobSize)
+		if _, err := io.ReadFull(br, blob); err != nil {
+			return core.Bbox{}, nil, fmt.Errorf("truncated OSM PBF %s blob", blobType)
+		}
+
+		switch blobType {
+		case "OSMHeader":
+			data, err := pbfBlobData(blob)
+			if err != nil {
+				return core.Bbox{}, nil, err
+			}
+			bbox, ok, err := parsePbfHeaderBbox(data)
+			if err != nil {
+				return core.Bbox{}, nil, err
+			}
+			if ok && !opts.ScanGeometries {
+				return bbox, wgs84, nil
+			}
+		case "OSMData":
+			data, err := pbfBlobData(blob)
+			if err != nil {
+				return core.Bbox{}, nil, err
+			}
+			if err := scanPbfPrimitiveBlock(data, visit); err != nil {
+				return core.Bbox{}, nil, err
+			}
+		}
+		// other blob types are skipped, as the spec requires
+	}
+
+	if minX > maxX {
+		return core.Bbox{}, nil, ErrNoFeaturesFound
+	}
+	return core.Bbox{
+		Left:   minX,
+		Bottom: minY,
+		Right:  maxX,
+		Top:    maxY,
+	}, wgs84, nil
+}
+
+// parsePbfBlobHeader returns the type and size of the blob from a BlobHeader message
+func parsePbfBlobHeader(header []byte) (string, int, error) {
+	var blobType string
+	blobSize := -1
+	pr := newProtoReader(header)
+	for pr.more() {
+		field, wireType, err := pr.next()
+		if err != nil {
+			return "", 0, fmt.Errorf("invalid OSM PBF blob header: %w", err)
+		}
+		switch {
+		case field == 1 && wireType == protoBytes:
+			b, err := pr.bytes()
+			if err != nil {
+				return "", 0, fmt.Errorf("invalid OSM PBF blob header: %w", err)
+			}
+			blobType = string(b)
+		case field == 3 && wireType == protoVarint:
+			v, err := pr.varint()
+			if err != nil {
+				return "", 0, fmt.Errorf("invalid OSM PBF blob header: %w", err)
+			}
+			blobSize = int(int32(v))
+		default:
+			if err := pr.skip(wireType); err != nil {
+				return "", 0, fmt.Errorf("invalid OSM PBF blob header: %w", err)
+			}
+		}
+	}
+	if blobType == "" || blobSize < 0 {
+		return "", 0, errors.New("invalid OSM PBF blob header: missing type or size")
+	}
+	return blobType, blobSize, nil
+}
+
+// pbfBlobData returns the uncompressed contents of a Blob message. Only raw and zlib
+// blobs are supported, which is all the common tools write.
+func pbfBlobData(blob []byte) ([]byte, error) {
+	rawSize := 0
+	pr := newProtoReader(blob)
+	for pr.more() {
+		field, wireType, err := pr.next()
+		if err != nil {
+			return nil, fmt.Errorf("invalid OSM PBF blob: %w", err)
+		}
+		switch {
+		case field == 1 && wireType == protoBytes:
+			return pr.bytes()
+		case field == 2 && wireType == protoVarint:
+			v, err := pr.varint()
+			if err != nil {
+				return nil, fmt.Errorf("invalid OSM PBF blob: %w", err)
+			}
+			rawSize = int(int32(v))
+		case field == 3 && wireType == protoBytes:
+			compressed, err := pr.bytes()
+			if err != nil {
+				return nil, fmt.Errorf("invalid OSM PBF blob: %w", err)
+			}
+			zr, err := zlib.NewReader(bytes.NewReader(compressed))
+			if err != nil {
+				return nil, fmt.Errorf("invalid OSM PBF blob: %w", err)
+			}
+			defer zr.Close()
+			if rawSize < 0 || rawSize > pbfMaxBlobSize {
+				rawSize = 0
+			}
+			buf := bytes.NewBuffer(make([]byte, 0, rawSize))
+			if _, err := io.Copy(buf, io.LimitReader(zr, pbfMaxBlobSize+1)); err != nil {
+				return nil, fmt.Errorf("invalid OSM PBF blob: %w", err)
+			}
+			if buf.Len() > pbfMaxBlobSize {
+				return nil, fmt.Errorf("invalid OSM PBF blob: larger than %d bytes", pbfMaxBlobSize)
+			}
+			return buf.Bytes(), nil
+		case field >= 4 && field <= 7:
+			return nil, fmt.Errorf("unsupported OSM PBF compression, only zlib is supported")
+		default:
+			if err := pr.skip(wireType); err != nil {
+				return nil, fmt.Errorf("invalid OSM PBF blob: %w", err)
+			}
+		}
+	}
+	return nil, errors.New("invalid OSM PBF blob: no data")
+}
+
+// parsePbfHeaderBbox returns the bbox from a HeaderBlock message, and whether it has one
+func parsePbfHeaderBbox(data []byte) (core.Bbox, bool, error) {
+	pr := newProtoReader(data)
+	for pr.more() {
+		field, wireType, err := pr.next()
+		if err != nil {
+			return core.Bbox{}, false, fmt.Errorf("invalid OSM PBF header: %w", err)
+		}
+		if field != 1 || wireType != protoBytes {
+			if err := pr.skip(wireType); err != nil {
+				return core.Bbox{}, false, fmt.Errorf("invalid OSM PBF header: %w", err)
+			}
+			continue
+		}
+
+		bboxData, err := pr.bytes()
+		if err != nil {
+			return core.Bbox{}, false, fmt.Errorf("invalid OSM PBF header: %w", err)
+		}
+		// HeaderBBox has left, right, top, bottom in nanodegrees
+		var values [4]int64
+		bp := newProtoReader(bboxData)
+		for bp.more() {
+			field, wireType, err := bp.next()
+			if err != nil {
+				return core.Bbox{}, false, fmt.Errorf("invalid OSM PBF header bbox: %w", err)
+			}
+			if field >= 1 && field <= 4 && wireType == protoVarint {
+				v, err := bp.sint64()
+				if err != nil {
+					return core.Bbox{}, false, fmt.Errorf("invalid OSM PBF header bbox: %w", err)
+				}
+				values[field-1] = v
+			} else if err := bp.skip(wireType); err != nil {
+				return core.Bbox{}, false, fmt.Errorf("invalid OSM PBF header bbox: %w", err)
+			}
+		}
+		return core.Bbox{
+			Left:   float64(values[0]) * 1e-9,
+			Bottom: float64(values[3]) * 1e-9,
+			Right:  float64(values[1]) * 1e-9,
+			Top:    float64(values[2]) * 1e-9,
+		}, true, nil
+	}
+	return core.Bbox{}, false, nil
+}
+
+// scanPbfPrimitiveBlock calls visit with the location of every node in a PrimitiveBlock message,
+// both the plain and dense nodes
+func scanPbfPrimitiveBlock(data []byte, visit func(x, y float64)) error {
+	var groups [][]byte
+	granularity := int64(100)
+	var latOffset, lonOffset int64
+
+	pr := newProtoReader(data)
+	for pr.more() {
+		field, wireType, err := pr.next()
+		if err != nil {
+			return fmt.Errorf("invalid OSM PBF block: %w", err)
+		}
+		switch {
+		case field == 2 && wireType == protoBytes:
+			group, err := pr.bytes()
+			if err != nil {
+				return fmt.Errorf("invalid OSM PBF block: %w", err)
+			}
+			groups = append(groups, group)
+		case (field == 17 || field == 19 || field == 20) && wireType == protoVarint:
+			v, err := pr.varint()
+			if err != nil {
+				return fmt.Errorf("invalid OSM PBF block: %w", err)
+			}
+			switch field {
+			case 17:
+				granularity = int64(int32(v))
+			case 19:
+				latOffset = int64(v)
+			case 20:
+				lonOffset = int64(v)
+			}
+		default:
+			if err := pr.skip(wireType); err != nil {
+				return fmt.Errorf("invalid OSM PBF block: %w", err)
+			}
+		}
+	}
+
+	// the groups come before the granularity and offsets in the message, so the nodes
+	// are decoded after reading the whole block
+	toDegrees := func(offset, value int64) float64 {
+		return float64(offset+granularity*value) * 1e-9
+	}
+	for _, group := range groups {
+		gr := newProtoReader(group)
+		for gr.more() {
+			field, wireType, err := gr.next()
+			if err != nil {
+				return fmt.Errorf("invalid OSM PBF group: %w", err)
+			}
+			if (field != 1 && field != 2) || wireType != protoBytes {
+				if err := gr.skip(wireType); err != nil {
+					return fmt.Errorf("invalid OSM PBF group: %w", err)
+				}
+				continue
+			}
+			message, err := gr.bytes()
+			if err != nil {
+				return fmt.Errorf("invalid OSM PBF group: %w", err)
+			}
+
+			lats, lons, err := parsePbfNodeLocations(message, field == 2)
+			if err != nil {
+				return err
+			}
+			for i := range lats {
+				visit(toDegrees(lonOffset, lons[i]), toDegrees(latOffset, lats[i]))
+			}
+		}
+	}
+	return nil
+}
+
+// parsePbfNodeLocations returns the raw lat and lon values of a Node message, or of all the nodes
+// in a DenseNodes message, where the values are packed and delta encoded
+func parsePbfNodeLocations(message []byte, dense bool) ([]int64, []int64, error) {
+	var lats, lons []int64
+	pr := newProtoReader(message)
+	for pr.more() {
+		field, wireType, err := pr.next()
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid OSM PBF node: %w", err)
+		}
+		if field != 8 && field != 9 {
+			if err := pr.skip(wireType); err != nil {
+				return nil, nil, fmt.Errorf("invalid OSM PBF node: %w", err)
+			}
+			continue
+		}
+
+		var values []int64
+		if dense && wireType == protoBytes {
+			packed, err := pr.bytes()
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid OSM PBF dense nodes: %w", err)
+			}
+			values, err = packedSint64(packed)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid OSM PBF dense nodes: %w", err)
+			}
+			for i := 1; i < len(values); i++ {
+				values[i] += values[i-1]
+			}
+		} else if !dense && wireType == protoVarint {
+			v, err := pr.sint64()
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid OSM PBF node: %w", err)
+			}
+			values = []int64{v}
+		} else {
+			return nil, nil, fmt.Errorf("invalid OSM PBF node: unexpected wire type %d", wireType)
+		}
+
+		if field == 8 {
+			lats = values
+		} else {
+			lons = values
+		}
+	}
+
+	if len(lats) != len(lons) {
+		return nil, nil, fmt.Errorf("invalid OSM PBF nodes: %d latitudes and %d longitudes", len(lats), len(lons))
+	}
+	return lats, lons, nil
+}
@@ -0,0 +1,323 @@
+package input
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mikeocool/bbox/core"
+)
+
+const testOsm = `<?xml version="1.0" encoding="UTF-8"?>
+<osm version="0.6" generator="test">
+  <bounds minlat="44.9" minlon="-93.3" maxlat="45.0" maxlon="-93.2"/>
+  <node id="1" lat="44.95" lon="-93.25" version="1"/>
+  <node id="2" lat="44.96" lon="-93.26" version="1"><tag k="amenity" v="cafe"/></node>
+  <node id="3" visible="false" version="2"/>
+  <way id="10"><nd ref="1"/><nd ref="2"/></way>
+</osm>`
+
+func TestParseOsm(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		opts    ReadOptions
+		want    core.Bbox
+		wantErr error
+	}{
+		{
+			name:  "bounds element",
+			input: testOsm,
+			want:  core.Bbox{Left: -93.3, Bottom: 44.9, Right: -93.2, Top: 45.0},
+		},
+		{
+			name:  "scan nodes",
+			input: testOsm,
+			opts:  ReadOptions{ScanGeometries: true},
+			want:  core.Bbox{Left: -93.26, Bottom: 44.95, Right: -93.25, Top: 44.96},
+		},
+		{
+			name:  "osmosis bound",
+			input: `<osm><bound box="44.9,-93.3,45.0,-93.2" origin="osmosis"/><node id="1" lat="0" lon="0"/></osm>`,
+			want:  core.Bbox{Left: -93.3, Bottom: 44.9, Right: -93.2, Top: 45.0},
+		},
+		{
+			name:  "no bounds",
+			input: `<osm><node id="1" lat="1" lon="2"/><node id="2" lat="-1" lon="3"/></osm>`,
+			want:  core.Bbox{Left: 2, Bottom: -1, Right: 3, Top: 1},
+		},
+		{
+			name:    "no nodes",
+			input:   `<osm version="0.6"></osm>`,
+			wantErr: ErrNoFeaturesFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, crs, err := ParseOsm(strings.NewReader(tt.input), tt.opts)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("ParseOsm() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseOsm() unexpected error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseOsm() = %v, want %v", got, tt.want)
+			}
+			if crs == nil || crs.Code != 4326 {
+				t.Errorf("ParseOsm() crs = %v, want EPSG:4326", crs)
+			}
+		})
+	}
+
+	t.Run("invalid node", func(t *testing.T) {
+		if _, _, err := ParseOsm(strings.NewReader(`<osm><node id="1" lat="x" lon="2"/></osm>`), ReadOptions{}); err == nil {
+			t.Errorf("ParseOsm() expected error")
+		}
+	})
+}
+
+// bboxAlmostEqual compares boxes read from nanodegrees, which aren't exact in floating point
+func bboxAlmostEqual(a, b core.Bbox) bool {
+	const tolerance = 1e-7
+	return math.Abs(a.Left-b.Left) < tolerance && math.Abs(a.Bottom-b.Bottom) < tolerance &&
+		math.Abs(a.Right-b.Right) < tolerance && math.Abs(a.Top-b.Top) < tolerance &&
+		a.HasZ == b.HasZ && math.Abs(a.MinZ-b.MinZ) < tolerance && math.Abs(a.MaxZ-b.MaxZ) < tolerance
+}
+
+// protobuf encoding helpers for building PBF files
+
+func appendProtoKey(b []byte, field, wireType int) []byte {
+	return binary.AppendUvarint(b, uint64(field<<3|wireType))
+}
+
+func appendProtoVarint(b []byte, field int, v uint64) []byte {
+	return binary.AppendUvarint(appendProtoKey(b, field, protoVarint), v)
+}
+
+func appendProtoSint64(b []byte, field int, v int64) []byte {
+	return appendProtoVarint(b, field, uint64(v<<1)^uint64(v>>63))
+}
+
+func appendProtoBytes(b []byte, field int, data []byte) []byte {
+	b = binary.AppendUvarint(appendProtoKey(b, field, protoBytes), uint64(len(data)))
+	return append(b, data...)
+}
+
+func packSint64(values []int64) []byte {
+	var b []byte
+	for _, v := range values {
+		b = binary.AppendUvarint(b, uint64(v<<1)^uint64(v>>63))
+	}
+	return b
+}
+
+// appendPbfBlob appends a blob with its header, zlib compressed if compress is set
+func appendPbfBlob(t *testing.T, b []byte, blobType string, data []byte, compress bool) []byte {
+	t.Helper()
+	var blob []byte
+	if compress {
+		var buf bytes.Buffer
+		zw := zlib.NewWriter(&buf)
+		if _, err := zw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		blob = appendProtoVarint(blob, 2, uint64(len(data)))
+		blob = appendProtoBytes(blob, 3, buf.Bytes())
+	} else {
+		blob = appendProtoBytes(blob, 1, data)
+	}
+
+	var header []byte
+	header = appendProtoBytes(header, 1, []byte(blobType))
+	header = appendProtoVarint(header, 3, uint64(len(blob)))
+	b = binary.BigEndian.AppendUint32(b, uint32(len(header)))
+	b = append(b, header...)
+	return append(b, blob...)
+}
+
+// buildOsmPbf builds a PBF file with an optional header bbox, a block of dense nodes and a block
+// with a plain node. Coordinates are in degrees.
+func buildOsmPbf(t *testing.T, headerBbox *core.Bbox, dense [][2]float64, plain [2]float64) []byte {
+	t.Helper()
+	nano := func(v float64) int64 { return int64(v * 1e9) }
+
+	var headerBlock []byte
+	if headerBbox != nil {
+		var bbox []byte
+		bbox = appendProtoSint64(bbox, 1, nano(headerBbox.Left))
+		bbox = appendProtoSint64(bbox, 2, nano(headerBbox.Right))
+		bbox = appendProtoSint64(bbox, 3, nano(headerBbox.Top))
+		bbox = appendProtoSint64(bbox, 4, nano(headerBbox.Bottom))
+		headerBlock = appendProtoBytes(headerBlock, 1, bbox)
+	}
+	headerBlock = appendProtoBytes(headerBlock, 4, []byte("OsmSchema-V0.6"))
+	headerBlock = appendProtoBytes(headerBlock, 4, []byte("DenseNodes"))
+
+	// dense nodes with the default granularity of 100 nanodegrees, delta encoded
+	var ids, lats, lons []int64
+	var prevLat, prevLon int64
+	for _, p := range dense {
+		lat, lon := nano(p[1])/100, nano(p[0])/100
+		ids = append(ids, 1)
+		lats = append(lats, lat-prevLat)
+		lons = append(lons, lon-prevLon)
+		prevLat, prevLon = lat, lon
+	}
+	var denseNodes []byte
+	denseNodes = appendProtoBytes(denseNodes, 1, packSint64(ids))
+	denseNodes = appendProtoBytes(denseNodes, 8, packSint64(lats))
+	denseNodes = appendProtoBytes(denseNodes, 9, packSint64(lons))
+	var denseGroup []byte
+	denseGroup = appendProtoBytes(denseGroup, 2, denseNodes)
+
+	var denseBlock []byte
+	denseBlock = appendProtoBytes(denseBlock, 1, appendProtoBytes(nil, 1, []byte("")))
+	denseBlock = appendProtoBytes(denseBlock, 2, denseGroup)
+
+	// a plain node, with a granularity of 1000 and offsets, which come after the group in the block
+	var node []byte
+	node = appendProtoSint64(node, 1, 42)
+	node = appendProtoSint64(node, 8, (nano(plain[1])-1_000_000)/1000)
+	node = appendProtoSint64(node, 9, (nano(plain[0])+2_000_000)/1000)
+	var plainGroup []byte
+	plainGroup = appendProtoBytes(plainGroup, 1, node)
+	var plainBlock []byte
+	plainBlock = appendProtoBytes(plainBlock, 1, appendProtoBytes(nil, 1, []byte("")))
+	plainBlock = appendProtoBytes(plainBlock, 2, plainGroup)
+	plainBlock = appendProtoVarint(plainBlock, 17, 1000)
+	plainBlock = appendProtoVarint(plainBlock, 19, 1_000_000)
+	minus := int64(-2_000_000)
+	plainBlock = appendProtoVarint(plainBlock, 20, uint64(minus))
+
+	var data []byte
+	data = appendPbfBlob(t, data, "OSMHeader", headerBlock, false)
+	data = appendPbfBlob(t, data, "OSMData", denseBlock, true)
+	data = appendPbfBlob(t, data, "OSMData", plainBlock, false)
+	return data
+}
+
+func TestParseOsmPbf(t *testing.T) {
+	headerBbox := core.Bbox{Left: -93.5, Bottom: 44.5, Right: -93, Top: 45.5}
+	dense := [][2]float64{{-93.25, 44.95}, {-93.26, 44.96}, {-93.2, 44.9}}
+	plain := [2]float64{-93.1, 45.1}
+	nodesBbox := core.Bbox{Left: -93.26, Bottom: 44.9, Right: -93.1, Top: 45.1}
+
+	tests := []struct {
+		name string
+		data []byte
+		opts ReadOptions
+		want core.Bbox
+	}{
+		{
+			name: "header bbox",
+			data: buildOsmPbf(t, &headerBbox, dense, plain),
+			want: headerBbox,
+		},
+		{
+			name: "scan nodes",
+			data: buildOsmPbf(t, &headerBbox, dense, plain),
+			opts: ReadOptions{ScanGeometries: true},
+			want: nodesBbox,
+		},
+		{
+			name: "no header bbox",
+			data: buildOsmPbf(t, nil, dense, plain),
+			want: nodesBbox,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, crs, err := ParseOsmPbf(bytes.NewReader(tt.data), tt.opts)
+			if err != nil {
+				t.Fatalf("ParseOsmPbf() unexpected error = %v", err)
+			}
+			if !bboxAlmostEqual(got, tt.want) {
+				t.Errorf("ParseOsmPbf() = %v, want %v", got, tt.want)
+			}
+			if crs == nil || crs.Code != 4326 {
+				t.Errorf("ParseOsmPbf() crs = %v, want EPSG:4326", crs)
+			}
+		})
+	}
+
+	t.Run("truncated", func(t *testing.T) {
+		data := buildOsmPbf(t, nil, dense, plain)
+		if _, _, err := ParseOsmPbf(bytes.NewReader(data[:len(data)-5]), ReadOptions{}); err == nil {
+			t.Errorf("ParseOsmPbf() expected error for truncated file")
+		}
+	})
+
+	t.Run("unsupported compression", func(t *testing.T) {
+		var blob []byte
+		blob = appendProtoBytes(blob, 7, []byte("zstd data"))
+		var header []byte
+		header = appendProtoBytes(header, 1, []byte("OSMData"))
+		header = appendProtoVarint(header, 3, uint64(len(blob)))
+		data := binary.BigEndian.AppendUint32(nil, uint32(len(header)))
+		data = append(append(data, header...), blob...)
+		if _, _, err := ParseOsmPbf(bytes.NewReader(data), ReadOptions{}); err == nil {
+			t.Errorf("ParseOsmPbf() expected error for zstd blob")
+		}
+	})
+}
+
+func TestLoadFileOsm(t *testing.T) {
+	dir := t.TempDir()
+	headerBbox := core.Bbox{Left: -93.5, Bottom: 44.5, Right: -93, Top: 45.5}
+	pbf := buildOsmPbf(t, &headerBbox, [][2]float64{{-93.25, 44.95}}, [2]float64{-93.1, 45.1})
+
+	files := []struct {
+		name string
+		data []byte
+		want core.Bbox
+	}{
+		{name: "extract.osm", data: []byte(testOsm), want: core.Bbox{Left: -93.3, Bottom: 44.9, Right: -93.2, Top: 45.0}},
+		{name: "extract.osm.pbf", data: pbf, want: headerBbox},
+		// detected by sniffing
+		{name: "extract.xml", data: []byte(testOsm), want: core.Bbox{Left: -93.3, Bottom: 44.9, Right: -93.2, Top: 45.0}},
+		{name: "extract.bin", data: pbf, want: headerBbox},
+		{name: "extract.pbf", data: pbf, want: headerBbox},
+	}
+	for _, f := range files {
+		t.Run(f.name, func(t *testing.T) {
+			filename := filepath.Join(dir, f.name)
+			if err := os.WriteFile(filename, f.data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			got, _, err := LoadFile(filename, ReadOptions{})
+			if err != nil {
+				t.Fatalf("LoadFile() unexpected error = %v", err)
+			}
+			if !bboxAlmostEqual(got, f.want) {
+				t.Errorf("LoadFile() = %v, want %v", got, f.want)
+			}
+		})
+	}
+
+	t.Run("vector tile isn't read as OSM", func(t *testing.T) {
+		filename := filepath.Join(dir, "0.pbf")
+		// a Mapbox vector tile with an empty layer named roads
+		if err := os.WriteFile(filename, []byte("\x1a\x09\x0a\x05roads\x78\x02"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, _, err := LoadFile(filename, ReadOptions{})
+		if !errors.Is(err, ErrUnrecognizedDataFormat) {
+			t.Errorf("LoadFile() error = %v, want ErrUnrecognizedDataFormat", err)
+		}
+	})
+}
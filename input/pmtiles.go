@@ -0,0 +1,62 @@
+package input
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/mikeocool/bbox/core"
+	"github.com/mikeocool/bbox/proj"
+)
+
+var pmtilesMagic = []byte("PMTiles")
+
+// Offsets in the PMTiles v3 header
+const (
+	pmtilesVersion    = 7
+	pmtilesMinZoom    = 100
+	pmtilesMaxZoom    = 101
+	pmtilesMinLon     = 102
+	pmtilesMinLat     = 106
+	pmtilesMaxLon     = 110
+	pmtilesMaxLat     = 114
+	pmtilesHeaderSize = 127
+)
+
+// SniffPmtiles checks for the PMTiles magic bytes
+func SniffPmtiles(data []byte) bool {
+	return bytes.HasPrefix(data, pmtilesMagic)
+}
+
+// ParsePmtiles reads the bounds of a PMTiles v3 archive from its header, which stores them as
+// longitudes and latitudes times 10,000,000. The zoom levels are logged when opts.Verbose is set.
+func ParsePmtiles(r io.Reader, opts ReadOptions) (core.Bbox, *proj.CRS, error) {
+	header := make([]byte, pmtilesHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil || !SniffPmtiles(header) {
+		return core.Bbox{}, nil, errors.New("invalid PMTiles file, missing header")
+	}
+	if header[pmtilesVersion] != 3 {
+		return core.Bbox{}, nil, fmt.Errorf("PMTiles version %d is not supported, only version 3", header[pmtilesVersion])
+	}
+
+	coordinate := func(offset int) float64 {
+		return float64(int32(binary.LittleEndian.Uint32(header[offset:]))) / 1e7
+	}
+	minX, minY := coordinate(pmtilesMinLon), coordinate(pmtilesMinLat)
+	maxX, maxY := coordinate(pmtilesMaxLon), coordinate(pmtilesMaxLat)
+	if boundsInvalid(minX, minY, maxX, maxY) {
+		return core.Bbox{}, nil, errors.New("invalid PMTiles header bounds")
+	}
+	if opts.Verbose {
+		log.Printf("PMTiles zoom levels %d to %d\n", header[pmtilesMinZoom], header[pmtilesMaxZoom])
+	}
+
+	crs, err := proj.Lookup(proj.EPSGWgs84)
+	if err != nil {
+		return core.Bbox{}, nil, err
+	}
+	return core.Bbox{Left: minX, Bottom: minY, Right: maxX, Top: maxY}, crs, nil
+}
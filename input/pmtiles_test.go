@@ -0,0 +1,74 @@
+package input
+
+import (
+	"bytes"
+	"encoding/binary"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/mikeocool/bbox/core"
+)
+
+// buildPmtilesHeader writes a PMTiles header with the version, zoom levels and bounds
+func buildPmtilesHeader(version byte, minZoom, maxZoom byte, box core.Bbox) []byte {
+	header := make([]byte, pmtilesHeaderSize)
+	copy(header, pmtilesMagic)
+	header[pmtilesVersion] = version
+	header[pmtilesMinZoom], header[pmtilesMaxZoom] = minZoom, maxZoom
+	for offset, v := range map[int]float64{pmtilesMinLon: box.Left, pmtilesMinLat: box.Bottom, pmtilesMaxLon: box.Right, pmtilesMaxLat: box.Top} {
+		binary.LittleEndian.PutUint32(header[offset:], uint32(int32(v*1e7)))
+	}
+	return header
+}
+
+func TestParsePmtiles(t *testing.T) {
+	box := core.Bbox{Left: -92.4342581, Bottom: 47.7517253, Right: -90.0219119, Top: 48.3566276}
+
+	tests := []struct {
+		name     string
+		data     []byte
+		errorMsg string
+	}{
+		{name: "v3 header", data: append(buildPmtilesHeader(3, 0, 14, box), make([]byte, 100)...)},
+		{name: "v2", data: buildPmtilesHeader(2, 0, 14, box), errorMsg: "PMTiles version 2 is not supported"},
+		{name: "invalid bounds", data: buildPmtilesHeader(3, 0, 14, core.Bbox{Left: 1, Right: -1}), errorMsg: "invalid PMTiles header bounds"},
+		{name: "truncated", data: buildPmtilesHeader(3, 0, 14, box)[:100], errorMsg: "invalid PMTiles file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, crs, err := ParseData(bytes.NewReader(tt.data), ReadOptions{})
+			if tt.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("ParseData() error = %v, want it to contain %q", err, tt.errorMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseData() unexpected error = %v", err)
+			}
+			if !bboxWithin(got, box, 1e-7) {
+				t.Errorf("ParseData() = %v, want %v", got, box)
+			}
+			if crs == nil || crs.Code != 4326 {
+				t.Errorf("ParseData() crs = %v, want EPSG:4326", crs)
+			}
+		})
+	}
+
+	t.Run("verbose logs the zoom levels", func(t *testing.T) {
+		var logged bytes.Buffer
+		previous := log.Writer()
+		log.SetOutput(&logged)
+		defer log.SetOutput(previous)
+
+		data := buildPmtilesHeader(3, 2, 14, box)
+		if _, _, err := ParsePmtiles(bytes.NewReader(data), ReadOptions{Verbose: true}); err != nil {
+			t.Fatalf("ParsePmtiles() unexpected error = %v", err)
+		}
+		if !strings.Contains(logged.String(), "PMTiles zoom levels 2 to 14") {
+			t.Errorf("ParsePmtiles() logged %q, want the zoom levels", logged.String())
+		}
+	})
+}
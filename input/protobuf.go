@@ -0,0 +1,112 @@
+package input
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Protocol buffer wire types
+const (
+	protoVarint  = 0
+	protoFixed64 = 1
+	protoBytes   = 2
+	protoFixed32 = 5
+)
+
+var errProtoTruncated = errors.New("truncated protocol buffer message")
+
+// protoReader reads the fields of an encoded protocol buffer message, without a schema
+type protoReader struct {
+	data []byte
+	pos  int
+}
+
+func newProtoReader(data []byte) *protoReader {
+	return &protoReader{data: data}
+}
+
+// more reports whether there are fields left to read
+func (r *protoReader) more() bool {
+	return r.pos < len(r.data)
+}
+
+// next reads the key of the next field
+func (r *protoReader) next() (field int, wireType int, err error) {
+	key, err := r.varint()
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(key >> 3), int(key & 7), nil
+}
+
+func (r *protoReader) varint() (uint64, error) {
+	v, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 {
+		return 0, errProtoTruncated
+	}
+	r.pos += n
+	return v, nil
+}
+
+// sint64 reads a zigzag encoded varint
+func (r *protoReader) sint64() (int64, error) {
+	v, err := r.varint()
+	return zigzag(v), err
+}
+
+// bytes reads a length delimited field: strings, bytes, embedded messages and packed repeated fields
+func (r *protoReader) bytes() ([]byte, error) {
+	length, err := r.varint()
+	if err != nil {
+		return nil, err
+	}
+	if length > uint64(len(r.data)-r.pos) {
+		return nil, errProtoTruncated
+	}
+	b := r.data[r.pos : r.pos+int(length)]
+	r.pos += int(length)
+	return b, nil
+}
+
+// skip skips the value of a field that isn't needed
+func (r *protoReader) skip(wireType int) error {
+	var size int
+	switch wireType {
+	case protoVarint:
+		_, err := r.varint()
+		return err
+	case protoBytes:
+		_, err := r.bytes()
+		return err
+	case protoFixed64:
+		size = 8
+	case protoFixed32:
+		size = 4
+	default:
+		return fmt.Errorf("unsupported protocol buffer wire type %d", wireType)
+	}
+	if r.pos+size > len(r.data) {
+		return errProtoTruncated
+	}
+	r.pos += size
+	return nil
+}
+
+// packedSint64 decodes a packed repeated sint64 field
+func packedSint64(data []byte) ([]int64, error) {
+	r := newProtoReader(data)
+	var values []int64
+	for r.more() {
+		v, err := r.sint64()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+func zigzag(v uint64) int64 {
+	return int64(v>>1) ^ -int64(v&1)
+}
@@ -9,20 +9,26 @@ import (
 	"strings"
 
 	"github.com/mikeocool/bbox/core"
+	"github.com/mikeocool/bbox/proj"
 )
 
-func ParseRaw(input []byte) (core.Bbox, error) {
+// ParseRaw parses a box from raw input. The CRS is nil if the input doesn't specify one.
+func ParseRaw(input []byte, opts ReadOptions) (core.Bbox, *proj.CRS, error) {
 	// TODO integrate ParseData here
 
 	// attempt to parse as a GeoJSON document
-	bbox, err := ParseData(bytes.NewReader(input))
+	bbox, crs, err := ParseData(bytes.NewReader(input), opts)
 	if err != nil {
 		if !errors.Is(err, ErrUnrecognizedDataFormat) {
-			return core.Bbox{}, err
+			return core.Bbox{}, nil, err
 		}
 		// Continue to try other parsing methods
 	} else {
-		return bbox, nil
+		return bbox, crs, nil
+	}
+
+	if looksLikeCsv(input, opts) {
+		return ParseCsv(bytes.NewReader(input), opts)
 	}
 
 	var rbbox *core.Bbox
@@ -35,17 +41,15 @@ func ParseRaw(input []byte) (core.Bbox, error) {
 			continue
 		}
 
-		// TODO try ParseGeojson, incase it's geojsonl
-
 		lineVals, err := parseLine(line)
 		if err != nil {
-			return core.Bbox{}, err
+			return core.Bbox{}, nil, err
 		}
 
 		// TODO ensure # of vals remains consistent
 		var lineBbox core.Bbox
 		if expectedLineVals != 0 && len(lineVals) != expectedLineVals {
-			return core.Bbox{}, fmt.Errorf("invalid input")
+			return core.Bbox{}, nil, fmt.Errorf("invalid input")
 		}
 
 		expectedLineVals = len(lineVals)
@@ -64,7 +68,7 @@ func ParseRaw(input []byte) (core.Bbox, error) {
 				Top:    lineVals[1],
 			}
 		} else {
-			return core.Bbox{}, fmt.Errorf("invalid input")
+			return core.Bbox{}, nil, fmt.Errorf("invalid input")
 		}
 
 		if rbbox == nil {
@@ -77,10 +81,10 @@ func ParseRaw(input []byte) (core.Bbox, error) {
 	}
 
 	if rbbox == nil {
-		return core.Bbox{}, fmt.Errorf("invalid input")
+		return core.Bbox{}, nil, fmt.Errorf("invalid input")
 	}
 
-	return *rbbox, nil
+	return *rbbox, nil, nil
 }
 
 func parseLine(line string) ([]float64, error) {
@@ -217,7 +217,7 @@ func TestParseRaw(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			bbox, err := ParseRaw([]byte(tc.input))
+			bbox, _, err := ParseRaw([]byte(tc.input), ReadOptions{})
 
 			// Check error status
 			if tc.expectError && err == nil {
@@ -1,14 +1,20 @@
 package input
 
 import (
+	"bufio"
 	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"log"
 	"math"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/mikeocool/bbox/core"
+	"github.com/mikeocool/bbox/proj"
 )
 
 const (
@@ -34,24 +40,42 @@ func SniffShapefile(data []byte) bool {
 	return false
 }
 
-func LoadShapefile(filename string) (core.Bbox, error) {
-	r, err := os.Open(filename)
-	if err != nil {
-		return core.Bbox{}, err
+// loadPrj reads the CRS from the .prj sidecar of a shapefile, returning nil if there
+// isn't one or it can't be parsed
+func loadPrj(shpFilename string) *proj.CRS {
+	base := strings.TrimSuffix(shpFilename, filepath.Ext(shpFilename))
+	for _, ext := range []string{".prj", ".PRJ"} {
+		data, err := os.ReadFile(base + ext)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			log.Printf("Could not read %s: %v\n", base+ext, err)
+			return nil
+		}
+		return parsePrj(base+ext, data)
 	}
-	defer r.Close()
-	return ParseShapefile(r)
+	return nil
 }
 
-func ParseShapefile(r io.Reader) (core.Bbox, error) {
-	header := make([]byte, shpHeaderSize)
-	_, err := r.Read(header)
+// parsePrj parses the contents of a .prj file, logging and returning nil if it can't be parsed
+func parsePrj(name string, data []byte) *proj.CRS {
+	crs, err := proj.ParseWkt(string(data))
 	if err != nil {
-		return core.Bbox{}, err
+		log.Printf("Could not parse projection in %s: %v\n", name, err)
+		return nil
 	}
+	return crs
+}
 
-	if len(header) < shpHeaderSize {
-		return core.Bbox{}, fmt.Errorf("shapefile does not have valid header")
+// ParseShapefile reads the bounds of a shapefile from its header. If opts.ScanGeometries is set, or
+// the header bounds look wrong, the bounds are computed from the vertices of every shape record instead.
+func ParseShapefile(r io.Reader, opts ReadOptions) (core.Bbox, error) {
+	header := make([]byte, shpHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return core.Bbox{}, fmt.Errorf("shapefile does not have valid header")
+		}
+		return core.Bbox{}, err
 	}
 
 	if headerFileCode := binary.BigEndian.Uint32(header[:4]); headerFileCode != shpFileCode {
@@ -61,25 +85,87 @@ func ParseShapefile(r io.Reader) (core.Bbox, error) {
 		return core.Bbox{}, errors.New("invalid header version")
 	}
 
-	// TODO we're reading the bounds from the header -- which isn't guaranteed to reflect
-	// the bounds of the geometries in the file -- read the geometries to confirm
 	minX := math.Float64frombits(binary.LittleEndian.Uint64(header[36:44]))
 	minY := math.Float64frombits(binary.LittleEndian.Uint64(header[44:52]))
 	maxX := math.Float64frombits(binary.LittleEndian.Uint64(header[52:60]))
 	maxY := math.Float64frombits(binary.LittleEndian.Uint64(header[60:68]))
 
-	// check if any values represent no data
-	if minX <= -1e38 {
-		minX = math.Inf(1)
+	// the header isn't guaranteed to reflect the geometries in the file, some writers
+	// leave it zeroed or never update it
+	zeroed := minX == 0 && minY == 0 && maxX == 0 && maxY == 0
+	if opts.ScanGeometries || zeroed || boundsInvalid(minX, minY, maxX, maxY) {
+		return scanShapeRecords(r)
 	}
-	if minY <= -1e38 {
-		minY = math.Inf(1)
+
+	return core.Bbox{
+		Left:   minX,
+		Bottom: minY,
+		Right:  maxX,
+		Top:    maxY,
+	}, nil
+}
+
+// Shape types from the ESRI Shapefile Technical Description
+const (
+	shpNull        = 0
+	shpPoint       = 1
+	shpPolyLine    = 3
+	shpPolygon     = 5
+	shpMultiPoint  = 8
+	shpPointZ      = 11
+	shpPolyLineZ   = 13
+	shpPolygonZ    = 15
+	shpMultiPointZ = 18
+	shpPointM      = 21
+	shpPolyLineM   = 23
+	shpPolygonM    = 25
+	shpMultiPointM = 28
+	shpMultiPatch  = 31
+)
+
+// shpMaxRecordSize limits the memory used for a single record in a corrupt file
+const shpMaxRecordSize = 1 << 30
+
+// scanShapeRecords computes the bounds of the vertices of the shape records that follow the header.
+// Records are read one at a time, so the whole file is never held in memory.
+func scanShapeRecords(r io.Reader) (core.Bbox, error) {
+	br := bufio.NewReader(r)
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	visit := func(x, y float64) {
+		updateBounds(&minX, &minY, &maxX, &maxY, x, y)
 	}
-	if maxX <= -1e38 {
-		maxX = math.Inf(-1)
+
+	recordHeader := make([]byte, 8)
+	var record []byte
+	for recordNumber := 1; ; recordNumber++ {
+		if _, err := io.ReadFull(br, recordHeader); err == io.EOF {
+			break
+		} else if err != nil {
+			return core.Bbox{}, fmt.Errorf("shapefile record %d: truncated record header", recordNumber)
+		}
+
+		// content length is in 16-bit words
+		contentLength := int64(binary.BigEndian.Uint32(recordHeader[4:8])) * 2
+		if contentLength > shpMaxRecordSize {
+			return core.Bbox{}, fmt.Errorf("shapefile record %d: invalid content length %d", recordNumber, contentLength)
+		}
+		if int64(cap(record)) < contentLength {
+			record = make([]byte, contentLength)
+		}
+		record = record[:contentLength]
+		if _, err := io.ReadFull(br, record); err != nil {
+			return core.Bbox{}, fmt.Errorf("shapefile record %d: truncated record", recordNumber)
+		}
+
+		if err := shapeRecordVertices(record, visit); err != nil {
+			return core.Bbox{}, fmt.Errorf("shapefile record %d: %w", recordNumber, err)
+		}
 	}
-	if maxY <= -1e38 {
-		maxY = math.Inf(-1)
+
+	// every record was a null shape, or there were no records
+	if minX > maxX {
+		return core.Bbox{}, ErrNoFeaturesFound
 	}
 
 	return core.Bbox{
@@ -89,3 +175,57 @@ func ParseShapefile(r io.Reader) (core.Bbox, error) {
 		Top:    maxY,
 	}, nil
 }
+
+// shapeRecordVertices calls visit with the x and y of every vertex in the contents of a shape record.
+// Z and M values are ignored.
+func shapeRecordVertices(record []byte, visit func(x, y float64)) error {
+	if len(record) < 4 {
+		return errors.New("missing shape type")
+	}
+	shapeType := binary.LittleEndian.Uint32(record[:4])
+	content := record[4:]
+
+	// offset of the points array in the record content, after the shape's bounding box
+	// and the counts
+	var pointsOffset, numPoints int
+	switch shapeType {
+	case shpNull:
+		return nil
+	case shpPoint, shpPointZ, shpPointM:
+		numPoints = 1
+	case shpMultiPoint, shpMultiPointZ, shpMultiPointM:
+		if len(content) < 36 {
+			return errors.New("truncated multipoint")
+		}
+		numPoints = int(binary.LittleEndian.Uint32(content[32:36]))
+		pointsOffset = 36
+	case shpPolyLine, shpPolyLineZ, shpPolyLineM, shpPolygon, shpPolygonZ, shpPolygonM, shpMultiPatch:
+		if len(content) < 40 {
+			return errors.New("truncated shape")
+		}
+		numParts := int(binary.LittleEndian.Uint32(content[32:36]))
+		numPoints = int(binary.LittleEndian.Uint32(content[36:40]))
+		// parts index array, and for multipatches the part types array
+		partArrays := 1
+		if shapeType == shpMultiPatch {
+			partArrays = 2
+		}
+		if numParts > len(content)/4 {
+			return fmt.Errorf("invalid number of parts %d", numParts)
+		}
+		pointsOffset = 40 + 4*numParts*partArrays
+	default:
+		return fmt.Errorf("unsupported shape type %d", shapeType)
+	}
+
+	if numPoints < 0 || pointsOffset > len(content) || numPoints > (len(content)-pointsOffset)/16 {
+		return fmt.Errorf("invalid number of points %d", numPoints)
+	}
+	for i := 0; i < numPoints; i++ {
+		p := content[pointsOffset+16*i:]
+		x := math.Float64frombits(binary.LittleEndian.Uint64(p[0:8]))
+		y := math.Float64frombits(binary.LittleEndian.Uint64(p[8:16]))
+		visit(x, y)
+	}
+	return nil
+}
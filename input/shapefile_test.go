@@ -0,0 +1,298 @@
+package input
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mikeocool/bbox/core"
+)
+
+// shpRecord is the content of a shape record, starting with the shape type
+type shpRecord []byte
+
+func nullRecord() shpRecord {
+	return binary.LittleEndian.AppendUint32(nil, shpNull)
+}
+
+func pointRecord(shapeType uint32, x, y float64) shpRecord {
+	b := binary.LittleEndian.AppendUint32(nil, shapeType)
+	b = appendFloats(b, x, y)
+	switch shapeType {
+	case shpPointZ:
+		b = appendFloats(b, 100, 0) // z, m
+	case shpPointM:
+		b = appendFloats(b, 0) // m
+	}
+	return b
+}
+
+// multiPointRecord writes a multipoint, the record's box is deliberately wrong, since it shouldn't be used
+func multiPointRecord(shapeType uint32, points ...[2]float64) shpRecord {
+	b := binary.LittleEndian.AppendUint32(nil, shapeType)
+	b = appendFloats(b, 0, 0, 0, 0)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(points)))
+	for _, p := range points {
+		b = appendFloats(b, p[0], p[1])
+	}
+	b = appendZM(b, shapeType, len(points))
+	return b
+}
+
+// polyRecord writes a polyline or polygon with each part as a ring of points
+func polyRecord(shapeType uint32, parts ...[][2]float64) shpRecord {
+	numPoints := 0
+	for _, part := range parts {
+		numPoints += len(part)
+	}
+
+	b := binary.LittleEndian.AppendUint32(nil, shapeType)
+	b = appendFloats(b, 0, 0, 0, 0)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(parts)))
+	b = binary.LittleEndian.AppendUint32(b, uint32(numPoints))
+	start := 0
+	for _, part := range parts {
+		b = binary.LittleEndian.AppendUint32(b, uint32(start))
+		start += len(part)
+	}
+	for _, part := range parts {
+		for _, p := range part {
+			b = appendFloats(b, p[0], p[1])
+		}
+	}
+	b = appendZM(b, shapeType, numPoints)
+	return b
+}
+
+// appendZM appends the Z and M ranges and arrays of Z and M shapes, with values that would
+// throw off the bounds if they were read as x or y
+func appendZM(b []byte, shapeType uint32, numPoints int) []byte {
+	ranges := 0
+	switch shapeType {
+	case shpPolyLineZ, shpPolygonZ, shpMultiPointZ:
+		ranges = 2
+	case shpPolyLineM, shpPolygonM, shpMultiPointM:
+		ranges = 1
+	}
+	for i := 0; i < ranges; i++ {
+		b = appendFloats(b, -5000, 5000)
+		for j := 0; j < numPoints; j++ {
+			b = appendFloats(b, 5000)
+		}
+	}
+	return b
+}
+
+func appendFloats(b []byte, values ...float64) []byte {
+	for _, v := range values {
+		b = binary.LittleEndian.AppendUint64(b, math.Float64bits(v))
+	}
+	return b
+}
+
+// buildShapefile writes a .shp file with the given header bounds and records
+func buildShapefile(headerBounds [4]float64, records ...shpRecord) []byte {
+	var body []byte
+	for i, record := range records {
+		body = binary.BigEndian.AppendUint32(body, uint32(i+1))
+		body = binary.BigEndian.AppendUint32(body, uint32(len(record)/2))
+		body = append(body, record...)
+	}
+
+	header := make([]byte, shpHeaderSize)
+	binary.BigEndian.PutUint32(header[0:4], shpFileCode)
+	binary.BigEndian.PutUint32(header[24:28], uint32((shpHeaderSize+len(body))/2))
+	binary.LittleEndian.PutUint32(header[28:32], shpHeaderVersion)
+	binary.LittleEndian.PutUint32(header[32:36], shpPoint)
+	for i, v := range headerBounds {
+		binary.LittleEndian.PutUint64(header[36+8*i:], math.Float64bits(v))
+	}
+	return append(header, body...)
+}
+
+func TestParseShapefile(t *testing.T) {
+	noData := -1e39
+	staleHeader := [4]float64{0, 0, 1, 1}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		opts    ReadOptions
+		want    core.Bbox
+		wantErr error
+	}{
+		{
+			name: "header bounds",
+			data: buildShapefile(staleHeader, pointRecord(shpPoint, 10, 20)),
+			want: core.Bbox{Left: 0, Bottom: 0, Right: 1, Top: 1},
+		},
+		{
+			name: "scan points",
+			data: buildShapefile(staleHeader,
+				pointRecord(shpPoint, 10, 20),
+				pointRecord(shpPointZ, -10, 5),
+				pointRecord(shpPointM, 3, 40),
+			),
+			opts: ReadOptions{ScanGeometries: true},
+			want: core.Bbox{Left: -10, Bottom: 5, Right: 10, Top: 40},
+		},
+		{
+			name: "scan skips null shapes",
+			data: buildShapefile(staleHeader,
+				nullRecord(),
+				pointRecord(shpPoint, 10, 20),
+				nullRecord(),
+				pointRecord(shpPoint, 12, 22),
+				nullRecord(),
+			),
+			opts: ReadOptions{ScanGeometries: true},
+			want: core.Bbox{Left: 10, Bottom: 20, Right: 12, Top: 22},
+		},
+		{
+			name: "scan polylines and polygons",
+			data: buildShapefile(staleHeader,
+				polyRecord(shpPolyLine, [][2]float64{{1, 2}, {3, 4}}),
+				polyRecord(shpPolygonZ,
+					[][2]float64{{-20, -20}, {-20, 20}, {20, 20}, {-20, -20}},
+					[][2]float64{{-5, -5}, {-5, 5}, {5, 5}, {-5, -5}},
+				),
+				polyRecord(shpPolyLineM, [][2]float64{{30, 0}, {31, 1}}, [][2]float64{{0, -30}, {1, -31}}),
+			),
+			opts: ReadOptions{ScanGeometries: true},
+			want: core.Bbox{Left: -20, Bottom: -31, Right: 31, Top: 20},
+		},
+		{
+			name: "scan multipoints",
+			data: buildShapefile(staleHeader,
+				multiPointRecord(shpMultiPoint, [2]float64{1, 1}, [2]float64{2, 2}),
+				multiPointRecord(shpMultiPointZ, [2]float64{-3, 4}),
+				multiPointRecord(shpMultiPointM, [2]float64{6, -7}, [2]float64{0, 0}),
+			),
+			opts: ReadOptions{ScanGeometries: true},
+			want: core.Bbox{Left: -3, Bottom: -7, Right: 6, Top: 4},
+		},
+		{
+			name: "no data header falls back to scan",
+			data: buildShapefile([4]float64{noData, noData, noData, noData},
+				pointRecord(shpPoint, 10, 20),
+				pointRecord(shpPoint, 11, 21),
+			),
+			want: core.Bbox{Left: 10, Bottom: 20, Right: 11, Top: 21},
+		},
+		{
+			name: "zeroed header falls back to scan",
+			data: buildShapefile([4]float64{}, pointRecord(shpPoint, -5, 6), pointRecord(shpPoint, 7, 8)),
+			want: core.Bbox{Left: -5, Bottom: 6, Right: 7, Top: 8},
+		},
+		{
+			name: "inverted header falls back to scan",
+			data: buildShapefile([4]float64{10, 10, -10, -10}, pointRecord(shpPoint, 1, 2)),
+			want: core.Bbox{Left: 1, Bottom: 2, Right: 1, Top: 2},
+		},
+		{
+			name: "NaN header falls back to scan",
+			data: buildShapefile([4]float64{math.NaN(), 0, 1, 1}, pointRecord(shpPoint, 1, 2)),
+			want: core.Bbox{Left: 1, Bottom: 2, Right: 1, Top: 2},
+		},
+		{
+			name:    "only null shapes",
+			data:    buildShapefile(staleHeader, nullRecord(), nullRecord()),
+			opts:    ReadOptions{ScanGeometries: true},
+			wantErr: ErrNoFeaturesFound,
+		},
+		{
+			name:    "no records",
+			data:    buildShapefile(staleHeader),
+			opts:    ReadOptions{ScanGeometries: true},
+			wantErr: ErrNoFeaturesFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseShapefile(bytes.NewReader(tt.data), tt.opts)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("ParseShapefile() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseShapefile() unexpected error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseShapefile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseShapefileInvalidRecords(t *testing.T) {
+	opts := ReadOptions{ScanGeometries: true}
+	header := [4]float64{0, 0, 1, 1}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{
+			name: "truncated record",
+			data: buildShapefile(header, pointRecord(shpPoint, 1, 2))[:shpHeaderSize+12],
+		},
+		{
+			name: "truncated record header",
+			data: buildShapefile(header, pointRecord(shpPoint, 1, 2))[:shpHeaderSize+4],
+		},
+		{
+			name: "unsupported shape type",
+			data: buildShapefile(header, binary.LittleEndian.AppendUint32(nil, 99)),
+		},
+		{
+			name: "more points than the record holds",
+			data: func() []byte {
+				record := multiPointRecord(shpMultiPoint, [2]float64{1, 2})
+				binary.LittleEndian.PutUint32(record[36:40], 1000)
+				return buildShapefile(header, record)
+			}(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseShapefile(bytes.NewReader(tt.data), opts); err == nil {
+				t.Errorf("ParseShapefile() expected error")
+			}
+		})
+	}
+
+	t.Run("ParseData returns the error", func(t *testing.T) {
+		data := buildShapefile(header, pointRecord(shpPoint, 1, 2))[:shpHeaderSize+12]
+		_, _, err := ParseData(bytes.NewReader(data), opts)
+		if err == nil || !strings.Contains(err.Error(), "shapefile record 1") {
+			t.Errorf("ParseData() error = %v, want the shapefile's error", err)
+		}
+	})
+}
+
+func TestParseShapefileScanRealFile(t *testing.T) {
+	data, err := os.ReadFile("../integration_tests/data/ne_10m_populated_places_simple/ne_10m_populated_places_simple.shp")
+	if err != nil {
+		t.Skipf("Skipping real shapefile test: %v", err)
+	}
+
+	header, err := ParseShapefile(bytes.NewReader(data), ReadOptions{})
+	if err != nil {
+		t.Fatalf("ParseShapefile() unexpected error = %v", err)
+	}
+	scanned, err := ParseShapefile(bytes.NewReader(data), ReadOptions{ScanGeometries: true})
+	if err != nil {
+		t.Fatalf("ParseShapefile() unexpected error = %v", err)
+	}
+	if scanned != header {
+		t.Errorf("ParseShapefile() scanned bounds %v, want header bounds %v", scanned, header)
+	}
+}
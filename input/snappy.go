@@ -0,0 +1,83 @@
+package input
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var errSnappyCorrupt = errors.New("corrupt snappy data")
+
+// snappyDecode decompresses a snappy block, the raw format without framing that parquet uses.
+// maxSize limits the decompressed size of corrupt or malicious data.
+func snappyDecode(src []byte, maxSize int) ([]byte, error) {
+	length, n := binary.Uvarint(src)
+	if n <= 0 || length > uint64(maxSize) {
+		return nil, errSnappyCorrupt
+	}
+	src = src[n:]
+	dst := make([]byte, 0, length)
+
+	for len(src) > 0 {
+		tag := src[0]
+		var copyLength, offset int
+		switch tag & 3 {
+		case 0:
+			// literal, lengths over 60 are in the 1 to 4 bytes after the tag
+			literalLength := int(tag >> 2)
+			src = src[1:]
+			if literalLength >= 60 {
+				extra := literalLength - 59
+				if len(src) < extra {
+					return nil, errSnappyCorrupt
+				}
+				literalLength = 0
+				for i := extra - 1; i >= 0; i-- {
+					literalLength = literalLength<<8 | int(src[i])
+				}
+				src = src[extra:]
+			}
+			literalLength++
+			if literalLength > len(src) || len(dst)+literalLength > int(length) {
+				return nil, errSnappyCorrupt
+			}
+			dst = append(dst, src[:literalLength]...)
+			src = src[literalLength:]
+			continue
+		case 1:
+			if len(src) < 2 {
+				return nil, errSnappyCorrupt
+			}
+			copyLength = int(tag>>2&7) + 4
+			offset = int(tag>>5)<<8 | int(src[1])
+			src = src[2:]
+		case 2:
+			if len(src) < 3 {
+				return nil, errSnappyCorrupt
+			}
+			copyLength = int(tag>>2) + 1
+			offset = int(binary.LittleEndian.Uint16(src[1:3]))
+			src = src[3:]
+		case 3:
+			if len(src) < 5 {
+				return nil, errSnappyCorrupt
+			}
+			copyLength = int(tag>>2) + 1
+			offset = int(binary.LittleEndian.Uint32(src[1:5]))
+			src = src[5:]
+		}
+
+		if offset <= 0 || offset > len(dst) || len(dst)+copyLength > int(length) {
+			return nil, errSnappyCorrupt
+		}
+		// copies can overlap the bytes they produce, so they're done a byte at a time
+		start := len(dst) - offset
+		for i := 0; i < copyLength; i++ {
+			dst = append(dst, dst[start+i])
+		}
+	}
+
+	if len(dst) != int(length) {
+		return nil, errSnappyCorrupt
+	}
+	return dst, nil
+}
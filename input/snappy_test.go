@@ -0,0 +1,70 @@
+package input
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSnappyDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     []byte
+		want    []byte
+		wantErr bool
+	}{
+		{
+			name: "literal",
+			src:  []byte{5, 4 << 2, 'h', 'e', 'l', 'l', 'o'},
+			want: []byte("hello"),
+		},
+		{
+			// "ab" then a 1 byte offset copy of 6 bytes at offset 2, which overlaps its output
+			name: "overlapping copy",
+			src:  []byte{8, 1 << 2, 'a', 'b', (6-4)<<2 | 1, 2},
+			want: []byte("abababab"),
+		},
+		{
+			name: "2 byte offset copy",
+			src:  []byte{6, 2 << 2, 'x', 'y', 'z', (3-1)<<2 | 2, 3, 0},
+			want: []byte("xyzxyz"),
+		},
+		{
+			name: "extended literal length",
+			src:  append([]byte{61, 60 << 2, 60}, bytes.Repeat([]byte{'a'}, 61)...),
+			want: bytes.Repeat([]byte{'a'}, 61),
+		},
+		{
+			name:    "copy before start",
+			src:     []byte{4, (4-4)<<2 | 1, 1},
+			wantErr: true,
+		},
+		{
+			name:    "length mismatch",
+			src:     []byte{6, 4 << 2, 'h', 'e', 'l', 'l', 'o'},
+			wantErr: true,
+		},
+		{
+			name:    "over max size",
+			src:     []byte{0xff, 0xff, 0xff, 0xff, 0x0f},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := snappyDecode(tt.src, 1024)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("snappyDecode() expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("snappyDecode() unexpected error = %v", err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("snappyDecode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
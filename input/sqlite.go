@@ -0,0 +1,459 @@
+package input
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var sqliteMagic = []byte("SQLite format 3\x00")
+
+var errSqliteCorrupt = errors.New("corrupt SQLite database")
+
+// sqliteJournalMagic starts a rollback journal that still has to be played back. Journals that
+// are done with are deleted, truncated or have their header zeroed, depending on the journal mode.
+var sqliteJournalMagic = []byte{0xd9, 0xd5, 0x05, 0xf9, 0x20, 0xa1, 0x63, 0xd7}
+
+// sqliteMaxDepth limits the depth of b-trees, so a corrupt database with a cycle can't recurse forever
+const sqliteMaxDepth = 32
+
+// SQLite b-tree page types
+const (
+	sqliteInteriorTable = 0x05
+	sqliteLeafTable     = 0x0d
+)
+
+// sqliteDB reads tables from a SQLite database file. It only reads rowid tables, which is all
+// GeoPackage and MBTiles need, and doesn't read the journal or WAL, so files with one next to them
+// are rejected by checkSqliteJournal rather than read as they were before the changes in it.
+type sqliteDB struct {
+	r        io.ReaderAt
+	pageSize int
+	// usableSize is the page size less the space reserved at the end of each page for extensions
+	usableSize int
+	numPages   uint32
+}
+
+// sqliteTable is a rowid table, with the column names from its CREATE TABLE statement
+type sqliteTable struct {
+	db       *sqliteDB
+	name     string
+	rootPage uint32
+	columns  []string
+	// rowidColumn is the INTEGER PRIMARY KEY column that's an alias for the rowid, or -1
+	rowidColumn int
+}
+
+// sqliteRow is the values of a row: nil, int64, float64, string or []byte
+type sqliteRow []any
+
+// checkSqliteJournal returns an error if a database file has a WAL with changes that haven't been
+// checkpointed into it yet, or a hot journal from a write that didn't finish
+func checkSqliteJournal(filename string) error {
+	if info, err := os.Stat(filename + "-wal"); err == nil && info.Size() > 0 {
+		return fmt.Errorf("%s has changes in %s that can't be read, close any programs using it so they're written to the database",
+			filepath.Base(filename), filepath.Base(filename)+"-wal")
+	}
+
+	journal, err := os.Open(filename + "-journal")
+	if err != nil {
+		return nil
+	}
+	defer journal.Close()
+	magic := make([]byte, len(sqliteJournalMagic))
+	if _, err := io.ReadFull(journal, magic); err == nil && bytes.Equal(magic, sqliteJournalMagic) {
+		return fmt.Errorf("%s has an unfinished write in %s, open it with SQLite to roll it back",
+			filepath.Base(filename), filepath.Base(filename)+"-journal")
+	}
+	return nil
+}
+
+func openSqlite(r io.ReaderAt, size int64) (*sqliteDB, error) {
+	header := make([]byte, 100)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("failed to read SQLite header: %w", err)
+	}
+	if !bytes.HasPrefix(header, sqliteMagic) {
+		return nil, errors.New("not a SQLite database")
+	}
+
+	pageSize := int(binary.BigEndian.Uint16(header[16:]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	if pageSize < 512 || pageSize&(pageSize-1) != 0 {
+		return nil, fmt.Errorf("invalid SQLite page size %d", pageSize)
+	}
+	if encoding := binary.BigEndian.Uint32(header[56:]); encoding > 1 {
+		return nil, errors.New("only UTF-8 SQLite databases are supported")
+	}
+
+	return &sqliteDB{
+		r:          r,
+		pageSize:   pageSize,
+		usableSize: pageSize - int(header[20]),
+		numPages:   uint32(size / int64(pageSize)),
+	}, nil
+}
+
+func (db *sqliteDB) page(number uint32) ([]byte, error) {
+	if number < 1 || number > db.numPages {
+		return nil, fmt.Errorf("%w: page %d out of range", errSqliteCorrupt, number)
+	}
+	page := make([]byte, db.pageSize)
+	if _, err := db.r.ReadAt(page, int64(number-1)*int64(db.pageSize)); err != nil {
+		return nil, fmt.Errorf("failed to read SQLite page %d: %w", number, err)
+	}
+	return page, nil
+}
+
+// table returns the table with the name, or nil if the database doesn't have it
+func (db *sqliteDB) table(name string) (*sqliteTable, error) {
+	// sqlite_schema has the columns type, name, tbl_name, rootpage and sql
+	schema := &sqliteTable{db: db, name: "sqlite_schema", rootPage: 1, rowidColumn: -1}
+	var table *sqliteTable
+	err := schema.scan(func(row sqliteRow) error {
+		if len(row) < 5 || row[0] != "table" {
+			return nil
+		}
+		tableName, _ := row[1].(string)
+		if !strings.EqualFold(tableName, name) {
+			return nil
+		}
+		rootPage, _ := row[3].(int64)
+		sql, _ := row[4].(string)
+		columns, rowidColumn := sqliteColumns(sql)
+		table = &sqliteTable{db: db, name: tableName, rootPage: uint32(rootPage), columns: columns, rowidColumn: rowidColumn}
+		return nil
+	})
+	return table, err
+}
+
+// column returns the index of the column with the name, or -1
+func (t *sqliteTable) column(name string) int {
+	for i, column := range t.columns {
+		if strings.EqualFold(column, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// scan calls fn with every row of the table, in rowid order
+func (t *sqliteTable) scan(fn func(row sqliteRow) error) error {
+	return t.scanPage(t.rootPage, 0, fn)
+}
+
+func (t *sqliteTable) scanPage(number uint32, depth int, fn func(row sqliteRow) error) error {
+	if depth > sqliteMaxDepth {
+		return fmt.Errorf("%w: b-tree is too deep", errSqliteCorrupt)
+	}
+	page, err := t.db.page(number)
+	if err != nil {
+		return err
+	}
+
+	// the first page starts with the database header
+	headerStart := 0
+	if number == 1 {
+		headerStart = 100
+	}
+	pageType := page[headerStart]
+	cellCount := int(binary.BigEndian.Uint16(page[headerStart+3:]))
+	cellPointers := headerStart + 8
+	if pageType == sqliteInteriorTable {
+		cellPointers += 4
+	} else if pageType != sqliteLeafTable {
+		return fmt.Errorf("%w: unexpected page type %d in table %s", errSqliteCorrupt, pageType, t.name)
+	}
+	if cellPointers+cellCount*2 > len(page) {
+		return errSqliteCorrupt
+	}
+
+	for i := 0; i < cellCount; i++ {
+		cell := int(binary.BigEndian.Uint16(page[cellPointers+i*2:]))
+		if cell+4 > t.db.usableSize {
+			return errSqliteCorrupt
+		}
+
+		if pageType == sqliteInteriorTable {
+			// the left child pointer, then the rowid key
+			if err := t.scanPage(binary.BigEndian.Uint32(page[cell:]), depth+1, fn); err != nil {
+				return err
+			}
+			continue
+		}
+
+		payloadSize, n := sqliteVarint(page[cell:t.db.usableSize])
+		if n == 0 {
+			return errSqliteCorrupt
+		}
+		cell += n
+		rowid, n := sqliteVarint(page[cell:t.db.usableSize])
+		if n == 0 {
+			return errSqliteCorrupt
+		}
+		cell += n
+		payload, err := t.db.payload(page, cell, payloadSize)
+		if err != nil {
+			return err
+		}
+		row, err := decodeSqliteRecord(payload)
+		if err != nil {
+			return err
+		}
+		if t.rowidColumn >= 0 && t.rowidColumn < len(row) && row[t.rowidColumn] == nil {
+			row[t.rowidColumn] = int64(rowid)
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+
+	if pageType == sqliteInteriorTable {
+		return t.scanPage(binary.BigEndian.Uint32(page[headerStart+8:]), depth+1, fn)
+	}
+	return nil
+}
+
+// payload returns the payload of a table leaf cell that starts at pos, following the chain of
+// overflow pages if it doesn't fit in the page
+func (db *sqliteDB) payload(page []byte, pos int, size uint64) ([]byte, error) {
+	if size > uint64(db.numPages)*uint64(db.pageSize) {
+		return nil, errSqliteCorrupt
+	}
+
+	// how much of the payload is stored in the page, from the SQLite file format docs
+	u := uint64(db.usableSize)
+	maxLocal := u - 35
+	local := size
+	if size > maxLocal {
+		minLocal := (u-12)*32/255 - 23
+		local = minLocal + (size-minLocal)%(u-4)
+		if local > maxLocal {
+			local = minLocal
+		}
+	}
+	if uint64(pos)+local > u || (local < size && uint64(pos)+local+4 > u) {
+		return nil, errSqliteCorrupt
+	}
+
+	payload := make([]byte, 0, size)
+	payload = append(payload, page[pos:pos+int(local)]...)
+	if local == size {
+		return payload, nil
+	}
+
+	next := binary.BigEndian.Uint32(page[pos+int(local):])
+	for pages := uint32(0); uint64(len(payload)) < size; pages++ {
+		if next == 0 || pages > db.numPages {
+			return nil, fmt.Errorf("%w: invalid overflow page", errSqliteCorrupt)
+		}
+		overflow, err := db.page(next)
+		if err != nil {
+			return nil, err
+		}
+		n := min(size-uint64(len(payload)), u-4)
+		payload = append(payload, overflow[4:4+n]...)
+		next = binary.BigEndian.Uint32(overflow)
+	}
+	return payload, nil
+}
+
+// sqliteVarint decodes a SQLite varint, which unlike a protocol buffer varint is big endian and
+// uses all 8 bits of the 9th byte. The size is 0 if the data is truncated.
+func sqliteVarint(b []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < 9; i++ {
+		if i >= len(b) {
+			return 0, 0
+		}
+		if i == 8 {
+			return v<<8 | uint64(b[i]), 9
+		}
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i]&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+	return v, 9
+}
+
+// decodeSqliteRecord decodes the values of a row from the SQLite record format
+func decodeSqliteRecord(payload []byte) (sqliteRow, error) {
+	headerSize, n := sqliteVarint(payload)
+	if n == 0 || headerSize < uint64(n) || headerSize > uint64(len(payload)) {
+		return nil, errSqliteCorrupt
+	}
+	header := payload[n:headerSize]
+	body := payload[headerSize:]
+
+	var row sqliteRow
+	for len(header) > 0 {
+		serialType, n := sqliteVarint(header)
+		if n == 0 {
+			return nil, errSqliteCorrupt
+		}
+		header = header[n:]
+
+		var size int
+		switch {
+		case serialType == 0, serialType == 8, serialType == 9:
+			size = 0
+		case serialType <= 4:
+			size = int(serialType)
+		case serialType == 5:
+			size = 6
+		case serialType == 6, serialType == 7:
+			size = 8
+		case serialType >= 12:
+			if (serialType-12)/2 > uint64(len(body)) {
+				return nil, errSqliteCorrupt
+			}
+			size = int(serialType-12) / 2
+		default:
+			return nil, fmt.Errorf("%w: invalid serial type %d", errSqliteCorrupt, serialType)
+		}
+		if size > len(body) {
+			return nil, errSqliteCorrupt
+		}
+		value := body[:size]
+		body = body[size:]
+
+		switch {
+		case serialType == 0:
+			row = append(row, nil)
+		case serialType == 8, serialType == 9:
+			row = append(row, int64(serialType-8))
+		case serialType <= 6:
+			// big endian two's complement integers
+			v := int64(int8(value[0]))
+			for _, b := range value[1:] {
+				v = v<<8 | int64(b)
+			}
+			row = append(row, v)
+		case serialType == 7:
+			row = append(row, math.Float64frombits(binary.BigEndian.Uint64(value)))
+		case serialType%2 == 0:
+			row = append(row, value)
+		default:
+			row = append(row, string(value))
+		}
+	}
+	return row, nil
+}
+
+// value returns the value of a column, or nil if the row is short, which happens when
+// columns are added to a table after rows are written
+func (row sqliteRow) value(index int) any {
+	if index >= 0 && index < len(row) {
+		return row[index]
+	}
+	return nil
+}
+
+// sqliteFloat returns a numeric value as a float64, or false if it's NULL or not a number
+func sqliteFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// sqliteColumns returns the column names from a CREATE TABLE statement, and the index of the
+// INTEGER PRIMARY KEY column that's an alias for the rowid, or -1
+func sqliteColumns(sql string) ([]string, int) {
+	start := strings.Index(sql, "(")
+	end := strings.LastIndex(sql, ")")
+	if start < 0 || end < start {
+		return nil, -1
+	}
+
+	var columns []string
+	rowidColumn := -1
+	for _, def := range splitSqlList(sql[start+1 : end]) {
+		name, rest := sqlToken(def)
+		switch strings.ToUpper(name) {
+		case "CONSTRAINT", "PRIMARY", "UNIQUE", "CHECK", "FOREIGN":
+			// table constraints come after the columns
+			return columns, rowidColumn
+		}
+		upper := strings.ToUpper(rest)
+		columnType, _ := sqlToken(rest)
+		if strings.EqualFold(columnType, "INTEGER") && strings.Contains(upper, "PRIMARY KEY") && !strings.Contains(upper, "DESC") {
+			rowidColumn = len(columns)
+		}
+		columns = append(columns, name)
+	}
+	return columns, rowidColumn
+}
+
+// splitSqlList splits a list on the commas that aren't in quotes or parentheses
+func splitSqlList(s string) []string {
+	var parts []string
+	depth := 0
+	var quote byte
+	last := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '[':
+			quote = ']'
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, s[last:i])
+			last = i + 1
+		}
+	}
+	return append(parts, s[last:])
+}
+
+// sqlToken returns the first identifier or keyword in s, unquoted, and the rest of s
+func sqlToken(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", ""
+	}
+	closing := map[byte]byte{'"': '"', '`': '`', '[': ']', '\'': '\''}[s[0]]
+	if closing != 0 {
+		// doubled quotes inside quoted identifiers are escaped quotes
+		var b strings.Builder
+		for i := 1; i < len(s); i++ {
+			if s[i] == closing {
+				if closing != ']' && i+1 < len(s) && s[i+1] == closing {
+					b.WriteByte(closing)
+					i++
+					continue
+				}
+				return b.String(), s[i+1:]
+			}
+			b.WriteByte(s[i])
+		}
+		return b.String(), ""
+	}
+	end := strings.IndexFunc(s, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '('
+	})
+	if end < 0 {
+		return s, ""
+	}
+	return s[:end], s[end:]
+}
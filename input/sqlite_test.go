@@ -0,0 +1,86 @@
+package input
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSqliteVarint(t *testing.T) {
+	tests := []struct {
+		data     []byte
+		want     uint64
+		wantSize int
+	}{
+		{data: []byte{0x05}, want: 5, wantSize: 1},
+		{data: []byte{0x81, 0x00}, want: 128, wantSize: 2},
+		{data: []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, want: 1<<64 - 1, wantSize: 9},
+		{data: []byte{0x81}, want: 0, wantSize: 0},
+	}
+
+	for _, tt := range tests {
+		got, size := sqliteVarint(tt.data)
+		if got != tt.want || size != tt.wantSize {
+			t.Errorf("sqliteVarint(%x) = %d, %d, want %d, %d", tt.data, got, size, tt.want, tt.wantSize)
+		}
+	}
+}
+
+func TestDecodeSqliteRecord(t *testing.T) {
+	// NULL, a 1 byte integer, a negative 2 byte integer, the constant 1, a float, text and a blob
+	record := []byte{8, 0, 1, 2, 9, 7, 19, 16,
+		42,
+		0xff, 0x38,
+		0x40, 0x09, 0x21, 0xfb, 0x54, 0x44, 0x2d, 0x18,
+		'a', 'b', 'c',
+		0x01, 0x02}
+	want := sqliteRow{nil, int64(42), int64(-200), int64(1), 3.141592653589793, "abc", []byte{1, 2}}
+
+	got, err := decodeSqliteRecord(record)
+	if err != nil {
+		t.Fatalf("decodeSqliteRecord() unexpected error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decodeSqliteRecord() = %#v, want %#v", got, want)
+	}
+
+	if _, err := decodeSqliteRecord(record[:len(record)-1]); err == nil {
+		t.Errorf("decodeSqliteRecord() expected an error for a truncated record")
+	}
+}
+
+func TestSqliteColumns(t *testing.T) {
+	tests := []struct {
+		name        string
+		sql         string
+		want        []string
+		wantRowidAt int
+	}{
+		{
+			name:        "gpkg_contents",
+			sql:         `CREATE TABLE gpkg_contents (table_name TEXT NOT NULL PRIMARY KEY, data_type TEXT NOT NULL, last_change DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ','now')), min_x DOUBLE, CONSTRAINT fk_gc_r_srs_id FOREIGN KEY (srs_id) REFERENCES gpkg_spatial_ref_sys(srs_id))`,
+			want:        []string{"table_name", "data_type", "last_change", "min_x"},
+			wantRowidAt: -1,
+		},
+		{
+			name:        "quoted names and rowid alias",
+			sql:         "CREATE TABLE \"portage trails\" (\"fid\" INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL, [the geometry] LINESTRING, `a \"\"b` TEXT, \"c\"\"d\" TEXT)",
+			want:        []string{"fid", "the geometry", `a ""b`, `c"d`},
+			wantRowidAt: 0,
+		},
+		{
+			name:        "integer primary key descending isn't an alias",
+			sql:         "CREATE TABLE t (id INTEGER PRIMARY KEY DESC, value)",
+			want:        []string{"id", "value"},
+			wantRowidAt: -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, rowidAt := sqliteColumns(tt.sql)
+			if !reflect.DeepEqual(got, tt.want) || rowidAt != tt.wantRowidAt {
+				t.Errorf("sqliteColumns() = %q, %d, want %q, %d", got, rowidAt, tt.want, tt.wantRowidAt)
+			}
+		})
+	}
+}
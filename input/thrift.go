@@ -0,0 +1,226 @@
+package input
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Thrift compact protocol types
+const (
+	thriftStop   = 0
+	thriftTrue   = 1
+	thriftFalse  = 2
+	thriftByte   = 3
+	thriftI16    = 4
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftDouble = 7
+	thriftBinary = 8
+	thriftList   = 9
+	thriftSet    = 10
+	thriftMap    = 11
+	thriftStruct = 12
+)
+
+var errThriftTruncated = errors.New("truncated thrift message")
+
+// thriftMaxDepth limits the nesting of structs and lists, so corrupt data can't exhaust the stack
+const thriftMaxDepth = 64
+
+// thriftReader reads values encoded with the thrift compact protocol, which parquet uses for
+// its metadata, without a schema
+type thriftReader struct {
+	data  []byte
+	pos   int
+	depth int
+}
+
+func newThriftReader(data []byte) *thriftReader {
+	return &thriftReader{data: data}
+}
+
+// readStruct calls fn with the id and type of each field of a struct. fn has to read the
+// value, or skip it with r.skip. Booleans are encoded in the type, as thriftTrue or thriftFalse.
+func (r *thriftReader) readStruct(fn func(field int16, fieldType byte) error) error {
+	r.depth++
+	defer func() { r.depth-- }()
+	if r.depth > thriftMaxDepth {
+		return errors.New("thrift message nested too deeply")
+	}
+
+	var lastField int16
+	for {
+		if r.pos >= len(r.data) {
+			return errThriftTruncated
+		}
+		header := r.data[r.pos]
+		r.pos++
+		fieldType := header & 0x0f
+		if fieldType == thriftStop {
+			return nil
+		}
+
+		// the field id is a delta from the last one, or follows the header if the delta is 0
+		var field int16
+		if delta := header >> 4; delta != 0 {
+			field = lastField + int16(delta)
+		} else {
+			v, err := r.varint()
+			if err != nil {
+				return err
+			}
+			field = int16(zigzag(v))
+		}
+		lastField = field
+
+		if err := fn(field, fieldType); err != nil {
+			return err
+		}
+	}
+}
+
+// readList calls fn for each element of a list or set, with the element type
+func (r *thriftReader) readList(fn func(elemType byte) error) error {
+	if r.pos >= len(r.data) {
+		return errThriftTruncated
+	}
+	header := r.data[r.pos]
+	r.pos++
+	elemType := header & 0x0f
+	size := int(header >> 4)
+	if size == 15 {
+		v, err := r.varint()
+		if err != nil {
+			return err
+		}
+		if v > uint64(len(r.data)) {
+			return errThriftTruncated
+		}
+		size = int(v)
+	}
+
+	r.depth++
+	defer func() { r.depth-- }()
+	if r.depth > thriftMaxDepth {
+		return errors.New("thrift message nested too deeply")
+	}
+	for i := 0; i < size; i++ {
+		if err := fn(elemType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *thriftReader) varint() (uint64, error) {
+	v, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 {
+		return 0, errThriftTruncated
+	}
+	r.pos += n
+	return v, nil
+}
+
+func (r *thriftReader) i32() (int32, error) {
+	v, err := r.varint()
+	return int32(zigzag(v)), err
+}
+
+func (r *thriftReader) i64() (int64, error) {
+	v, err := r.varint()
+	return zigzag(v), err
+}
+
+func (r *thriftReader) binary() ([]byte, error) {
+	length, err := r.varint()
+	if err != nil {
+		return nil, err
+	}
+	if length > uint64(len(r.data)-r.pos) {
+		return nil, errThriftTruncated
+	}
+	b := r.data[r.pos : r.pos+int(length)]
+	r.pos += int(length)
+	return b, nil
+}
+
+func (r *thriftReader) string() (string, error) {
+	b, err := r.binary()
+	return string(b), err
+}
+
+// boolElem reads a boolean list element, which unlike a boolean field is a byte
+func (r *thriftReader) boolElem() (bool, error) {
+	if r.pos >= len(r.data) {
+		return false, errThriftTruncated
+	}
+	r.pos++
+	return r.data[r.pos-1] == thriftTrue, nil
+}
+
+// skip skips a value that isn't needed
+func (r *thriftReader) skip(fieldType byte) error {
+	switch fieldType {
+	case thriftTrue, thriftFalse:
+		// field booleans are in the type
+		return nil
+	case thriftByte:
+		if r.pos >= len(r.data) {
+			return errThriftTruncated
+		}
+		r.pos++
+		return nil
+	case thriftI16, thriftI32, thriftI64:
+		_, err := r.varint()
+		return err
+	case thriftDouble:
+		if r.pos+8 > len(r.data) {
+			return errThriftTruncated
+		}
+		r.pos += 8
+		return nil
+	case thriftBinary:
+		_, err := r.binary()
+		return err
+	case thriftList, thriftSet:
+		return r.readList(r.skipElem)
+	case thriftMap:
+		size, err := r.varint()
+		if err != nil {
+			return err
+		}
+		if size == 0 {
+			return nil
+		}
+		if r.pos >= len(r.data) || size > uint64(len(r.data)) {
+			return errThriftTruncated
+		}
+		types := r.data[r.pos]
+		r.pos++
+		for i := uint64(0); i < size; i++ {
+			if err := r.skipElem(types >> 4); err != nil {
+				return err
+			}
+			if err := r.skipElem(types & 0x0f); err != nil {
+				return err
+			}
+		}
+		return nil
+	case thriftStruct:
+		return r.readStruct(func(field int16, fieldType byte) error {
+			return r.skip(fieldType)
+		})
+	default:
+		return fmt.Errorf("unsupported thrift type %d", fieldType)
+	}
+}
+
+// skipElem skips a list or map element, where booleans are a byte rather than in the type
+func (r *thriftReader) skipElem(elemType byte) error {
+	if elemType == thriftTrue || elemType == thriftFalse {
+		_, err := r.boolElem()
+		return err
+	}
+	return r.skip(elemType)
+}
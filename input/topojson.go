@@ -0,0 +1,226 @@
+package input
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/mikeocool/bbox/core"
+)
+
+// SniffTopojson checks whether a fragment of the data is a JSON object whose first type member is
+// "Topology", as TopoJSON is written
+func SniffTopojson(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return false
+	}
+	i := bytes.Index(trimmed, []byte(`"type"`))
+	if i < 0 {
+		return false
+	}
+	rest := bytes.TrimLeft(trimmed[i+len(`"type"`):], " \t\r\n")
+	if len(rest) == 0 || rest[0] != ':' {
+		return false
+	}
+	return bytes.HasPrefix(bytes.TrimLeft(rest[1:], " \t\r\n"), []byte(`"Topology"`))
+}
+
+// topojsonTopology is what's been read of a TopoJSON topology's members. They can be in any order,
+// so the extent is worked out once the topology ends.
+type topojsonTopology struct {
+	// arcs are the extents of each arc, both of its positions as they are and after delta
+	// decoding them, since whether they're quantized depends on the transform member
+	arcs      []topojsonArc
+	transform *topojsonTransform
+	// objects are the names of all the objects, and selected is how many of them are being read
+	objects  []string
+	selected int
+	// arcRefs are the arcs of the objects being read, and points the extent of their Points and
+	// MultiPoints
+	arcRefs []int
+	points  geojsonExtent
+}
+
+type topojsonArc struct {
+	absolute, delta geojsonExtent
+}
+
+type topojsonTransform struct {
+	Scale     [2]float64 `json:"scale"`
+	Translate [2]float64 `json:"translate"`
+}
+
+func (t *topojsonTransform) apply(x, y float64) (float64, float64) {
+	return x*t.Scale[0] + t.Translate[0], y*t.Scale[1] + t.Translate[1]
+}
+
+// readTransform reads a transform member, which is left out if it isn't valid
+func (t *topojsonTopology) readTransform(s *jsonScanner) error {
+	raw, err := s.raw()
+	if err != nil {
+		return err
+	}
+	var transform topojsonTransform
+	if json.Unmarshal(raw, &transform) == nil && transform.Scale[0] != 0 && transform.Scale[1] != 0 {
+		t.transform = &transform
+	}
+	return nil
+}
+
+// readArcs reads the arcs member, keeping only the extent of each arc. Since the members of a
+// GeoJSON object could be a topology's, values that aren't arcs or positions are skipped.
+func (t *topojsonTopology) readArcs(s *jsonScanner) error {
+	return s.array(func() error {
+		var arc topojsonArc
+		if next, err := s.peek(); err != nil {
+			return err
+		} else if next != '[' {
+			t.arcs = append(t.arcs, arc)
+			return s.skip()
+		}
+		var x, y float64
+		err := s.array(func() error {
+			if next, err := s.peek(); err != nil {
+				return err
+			} else if next != '[' {
+				return s.skip()
+			}
+			values := 0
+			var position [2]float64
+			err := s.array(func() error {
+				if next, err := s.peek(); err != nil {
+					return err
+				} else if next != '-' && (next < '0' || next > '9') {
+					return s.skip()
+				}
+				value, err := s.float()
+				if values < 2 {
+					position[values] = value
+				}
+				values++
+				return err
+			})
+			if err != nil || values < 2 {
+				return err
+			}
+			x, y = x+position[0], y+position[1]
+			arc.absolute.add(position[0], position[1])
+			arc.delta.add(x, y)
+			return nil
+		})
+		t.arcs = append(t.arcs, arc)
+		return err
+	})
+}
+
+// readObjects reads the objects member, keeping the arcs and points of the selected objects. All
+// of them are selected if layer is empty.
+func (t *topojsonTopology) readObjects(s *jsonScanner, layer string) error {
+	return s.object(func(name string) error {
+		t.objects = append(t.objects, name)
+		if layer != "" && !strings.EqualFold(name, layer) {
+			return s.skip()
+		}
+		t.selected++
+		return t.readGeometry(s)
+	})
+}
+
+// readGeometry reads a TopoJSON geometry object, including the geometries of a GeometryCollection
+func (t *topojsonTopology) readGeometry(s *jsonScanner) error {
+	if next, err := s.peek(); err != nil {
+		return err
+	} else if next != '{' {
+		return s.skip()
+	}
+	return s.object(func(key string) error {
+		switch key {
+		case "arcs":
+			return t.readArcRefs(s)
+		case "coordinates":
+			var coordinates geojsonCoordinates
+			if err := coordinates.read(s, 1); err != nil {
+				return err
+			}
+			// only the x and y are quantized, so any heights are left out
+			if extent := coordinates.extent; extent.found {
+				t.points.add(extent.minX, extent.minY)
+				t.points.add(extent.maxX, extent.maxY)
+			}
+			return nil
+		case "geometries":
+			if next, err := s.peek(); err != nil {
+				return err
+			} else if next != '[' {
+				return s.skip()
+			}
+			return s.array(func() error { return t.readGeometry(s) })
+		default:
+			return s.skip()
+		}
+	})
+}
+
+// readArcRefs reads the arc indexes of a geometry, which are nested as deeply as its type needs.
+// Negative indexes are the ones' complement of an arc that's reversed.
+func (t *topojsonTopology) readArcRefs(s *jsonScanner) error {
+	next, err := s.peek()
+	if err != nil {
+		return err
+	}
+	switch {
+	case next == '[':
+		return s.array(func() error { return t.readArcRefs(s) })
+	case next == '-' || (next >= '0' && next <= '9'):
+		value, err := s.float()
+		if err != nil {
+			return err
+		}
+		if value != math.Trunc(value) || math.Abs(value) > math.MaxInt32 {
+			return s.errorf("invalid TopoJSON arc index %v", value)
+		}
+		index := int(value)
+		if index < 0 {
+			index = ^index
+		}
+		t.arcRefs = append(t.arcRefs, index)
+		return nil
+	default:
+		return s.skip()
+	}
+}
+
+// extent returns the extent of the selected objects
+func (t *topojsonTopology) extent(layer string) (core.Bbox, error) {
+	if layer != "" && t.selected == 0 {
+		return core.Bbox{}, fmt.Errorf("object %q not found in TopoJSON, it has: %s", layer, strings.Join(t.objects, ", "))
+	}
+	if t.selected == 0 {
+		return core.Bbox{}, ErrNoFeaturesFound
+	}
+
+	var extent geojsonExtent
+	for _, index := range t.arcRefs {
+		if index >= len(t.arcs) {
+			return core.Bbox{}, fmt.Errorf("%w: TopoJSON arc %d doesn't exist, there are %d", ErrCouldNotParseGeoJSON, index, len(t.arcs))
+		}
+		if t.transform != nil {
+			extent.union(t.arcs[index].delta)
+		} else {
+			extent.union(t.arcs[index].absolute)
+		}
+	}
+	extent.union(t.points)
+
+	if t.transform != nil && extent.found {
+		// the scale can be negative, so both corners are transformed
+		var transformed geojsonExtent
+		transformed.add(t.transform.apply(extent.minX, extent.minY))
+		transformed.add(t.transform.apply(extent.maxX, extent.maxY))
+		extent = transformed
+	}
+	return extent.result()
+}
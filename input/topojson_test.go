@@ -0,0 +1,124 @@
+package input
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/mikeocool/bbox/core"
+)
+
+func TestParseTopojson(t *testing.T) {
+	// two quantized arcs, from (0,0) to (10,10) and from (10,10) to (20,4)
+	quantized := `"transform":{"scale":[0.5,0.25],"translate":[100,-10]},` +
+		`"arcs":[[[0,0],[10,0],[0,10]],[[10,10],[10,-6]]]`
+	objects := `"objects":{` +
+		`"land":{"type":"Polygon","arcs":[[0]]},` +
+		`"coast":{"type":"GeometryCollection","geometries":[{"type":"LineString","arcs":[-2]},{"type":null}]},` +
+		`"towns":{"type":"MultiPoint","coordinates":[[2,40],[4,-2]]}}`
+
+	tests := []struct {
+		name     string
+		input    string
+		opts     ReadOptions
+		want     core.Bbox
+		errorMsg string
+	}{
+		{
+			name:  "quantized arcs and points",
+			input: `{"type":"Topology",` + quantized + `,` + objects + `}`,
+			want:  core.Bbox{Left: 100, Bottom: -10.5, Right: 110, Top: 0},
+		},
+		{
+			name:  "object selected by name",
+			input: `{"type":"Topology",` + objects + `,` + quantized + `}`,
+			opts:  ReadOptions{Layer: "Coast"},
+			want:  core.Bbox{Left: 105, Bottom: -9, Right: 110, Top: -7.5},
+		},
+		{
+			name:  "arcs that aren't quantized",
+			input: `{"type":"Topology","objects":{"lines":{"type":"MultiLineString","arcs":[[0],[-2]]}},"arcs":[[[-1.5,2],[3,4]],[[5,6],[-7,8]]]}`,
+			want:  core.Bbox{Left: -7, Bottom: 2, Right: 5, Top: 8},
+		},
+		{
+			name:  "bbox member is used",
+			input: `{"type":"Topology","bbox":[-10,-20,10,20],` + quantized + `,` + objects + `}`,
+			want:  core.Bbox{Left: -10, Bottom: -20, Right: 10, Top: 20},
+		},
+		{
+			name:  "bbox member isn't used for an object",
+			input: `{"type":"Topology","bbox":[-10,-20,10,20],` + quantized + `,` + objects + `}`,
+			opts:  ReadOptions{Layer: "land"},
+			want:  core.Bbox{Left: 100, Bottom: -10, Right: 105, Top: -7.5},
+		},
+		{
+			name:     "object that doesn't exist",
+			input:    `{"type":"Topology",` + quantized + `,` + objects + `}`,
+			opts:     ReadOptions{Layer: "rivers"},
+			errorMsg: `object "rivers" not found in TopoJSON, it has: land, coast, towns`,
+		},
+		{
+			name:     "arc that doesn't exist",
+			input:    `{"type":"Topology","objects":{"land":{"type":"Polygon","arcs":[[0,-3]]}},"arcs":[[[0,0],[1,1]]]}`,
+			errorMsg: "TopoJSON arc 2 doesn't exist, there are 1",
+		},
+		{
+			name:  "GeoJSON with members named like a topology's",
+			input: `{"type":"Feature","arcs":[1,{"a":2}],"objects":[],"transform":null,"geometry":{"type":"Point","coordinates":[1,2]}}`,
+			want:  core.Bbox{Left: 1, Bottom: 2, Right: 1, Top: 2},
+		},
+		{
+			name:     "no objects",
+			input:    `{"type":"Topology","objects":{},"arcs":[]}`,
+			errorMsg: "no features found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !SniffGeojson([]byte(tt.input)) {
+				t.Fatalf("SniffGeojson() = false")
+			}
+			got, crs, err := ParseData(strings.NewReader(tt.input), tt.opts)
+			if tt.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("ParseData() error = %v, want it to contain %q", err, tt.errorMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseData() unexpected error = %v", err)
+			}
+			if !bboxWithin(got, tt.want, 1e-9) {
+				t.Errorf("ParseData() = %v, want %v", got, tt.want)
+			}
+			if crs != nil {
+				t.Errorf("ParseData() crs = %v, want nil", crs)
+			}
+		})
+	}
+
+	t.Run("nothing after the bbox is read", func(t *testing.T) {
+		head := `{"type": "Topology", "bbox": [1, 2, 3, 4], "arcs": [`
+		r := io.MultiReader(strings.NewReader(head), iotest.ErrReader(errors.New("read past the bbox")))
+		got, _, err := ParseGeojson(r, ReadOptions{})
+		if err != nil || got != (core.Bbox{Left: 1, Bottom: 2, Right: 3, Top: 4}) {
+			t.Errorf("ParseGeojson() = %v, %v", got, err)
+		}
+	})
+
+	if !SniffTopojson([]byte("{\n  \"type\" : \"Topology\",\n  \"arcs\": [")) {
+		t.Errorf("SniffTopojson() = false for an indented topology")
+	}
+	for _, input := range []string{
+		`{"type":"FeatureCollection","features":[{"type":"Feature","properties":{"type":"Topology"}}]}`,
+		`["type", "Topology"]`,
+		`{"name":"Topology"}`,
+	} {
+		if SniffTopojson([]byte(input)) {
+			t.Errorf("SniffTopojson(%q) = true", input)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package input
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/mikeocool/bbox/core"
+	"github.com/mikeocool/bbox/proj"
+)
+
+// WKB geometry types. ISO WKB adds 1000 for Z, 2000 for M and 3000 for ZM.
+const (
+	wkbPoint              = 1
+	wkbLineString         = 2
+	wkbPolygon            = 3
+	wkbMultiPoint         = 4
+	wkbMultiLineString    = 5
+	wkbMultiPolygon       = 6
+	wkbGeometryCollection = 7
+	wkbCircularString     = 8
+	wkbCompoundCurve      = 9
+	wkbCurvePolygon       = 10
+	wkbMultiCurve         = 11
+	wkbMultiSurface       = 12
+	wkbPolyhedralSurface  = 15
+	wkbTin                = 16
+	wkbTriangle           = 17
+)
+
+// EWKB flags in the high bits of the geometry type, used by PostGIS
+const (
+	ewkbZ    = 0x80000000
+	ewkbM    = 0x40000000
+	ewkbSRID = 0x20000000
+)
+
+var errWkbTruncated = errors.New("truncated WKB geometry")
+
+// wkbMaxDepth limits the nesting of collections, so corrupt data can't exhaust the stack
+const wkbMaxDepth = 32
+
+type wkbReader struct {
+	data  []byte
+	pos   int
+	depth int
+	// srid is the EWKB SRID of the last geometry read, or 0 if it didn't have one
+	srid int
+}
+
+// SniffWkb checks for a WKB or EWKB geometry, in binary or as hex text like PostGIS outputs
+func SniffWkb(data []byte) bool {
+	text := trimWkbHexPrefix(string(bytes.TrimLeft(data, " \t\r\n")))
+	if len(text) >= 10 && text[0] == '0' {
+		// only the start of the geometry needs to be decoded, the hex may continue past the data
+		decoded, err := hex.DecodeString(text[:10])
+		return err == nil && wkbHeaderValid(decoded)
+	}
+	return len(data) >= 9 && wkbHeaderValid(data)
+}
+
+// wkbHeaderValid reports whether the data starts with a byte order and a known geometry type
+func wkbHeaderValid(data []byte) bool {
+	if len(data) < 5 || data[0] > 1 {
+		return false
+	}
+	var geomType uint32
+	if data[0] == 0 {
+		geomType = binary.BigEndian.Uint32(data[1:])
+	} else {
+		geomType = binary.LittleEndian.Uint32(data[1:])
+	}
+	geomType &^= ewkbZ | ewkbM | ewkbSRID
+	base := geomType % 1000
+	return geomType/1000 <= 3 && base >= wkbPoint && base <= wkbTriangle && base != 13 && base != 14
+}
+
+// trimWkbHexPrefix removes the \x of PostgreSQL bytea output or a 0x prefix
+func trimWkbHexPrefix(text string) string {
+	for _, prefix := range []string{"\\x", "0x", "0X"} {
+		if strings.HasPrefix(text, prefix) {
+			return text[len(prefix):]
+		}
+	}
+	return text
+}
+
+// ParseWkb reads the bounds of WKB or EWKB geometries. Binary data can have several geometries one
+// after another, and hex text several geometries separated by whitespace, like the output of
+// psql -At. The SRID of EWKB is used as the CRS, and every geometry is transformed to the CRS of
+// the first.
+func ParseWkb(r io.Reader) (core.Bbox, *proj.CRS, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return core.Bbox{}, nil, fmt.Errorf("failed to read WKB: %w", err)
+	}
+
+	geometries := [][]byte{data}
+	if text := bytes.TrimLeft(data, " \t\r\n"); len(text) > 0 && (text[0] == '0' || text[0] == '\\') {
+		geometries = nil
+		for _, field := range strings.Fields(string(text)) {
+			decoded, err := hex.DecodeString(trimWkbHexPrefix(field))
+			if err != nil {
+				return core.Bbox{}, nil, fmt.Errorf("invalid hex WKB: %w", err)
+			}
+			geometries = append(geometries, decoded)
+		}
+	}
+
+	var union bboxUnion
+	for _, geometry := range geometries {
+		reader := &wkbReader{data: geometry}
+		// a trailing newline is often left after binary data
+		for len(bytes.TrimSpace(reader.data[reader.pos:])) > 0 {
+			minX, minY := math.Inf(1), math.Inf(1)
+			maxX, maxY := math.Inf(-1), math.Inf(-1)
+			reader.srid = 0
+			err := reader.geometry(func(x, y float64) {
+				updateBounds(&minX, &minY, &maxX, &maxY, x, y)
+			})
+			if err != nil {
+				return core.Bbox{}, nil, fmt.Errorf("invalid WKB: %w", err)
+			}
+			if minX > maxX {
+				continue
+			}
+			if err := union.add(core.Bbox{Left: minX, Bottom: minY, Right: maxX, Top: maxY}, sridCrs(reader.srid)); err != nil {
+				return core.Bbox{}, nil, err
+			}
+		}
+	}
+	return union.result()
+}
+
+// wkbVertices calls visit with the x and y of every vertex of a WKB or EWKB geometry.
+// Empty points, which are encoded with NaN coordinates, are skipped.
+func wkbVertices(data []byte, visit func(x, y float64)) error {
+	r := &wkbReader{data: data}
+	return r.geometry(visit)
+}
+
+func (r *wkbReader) geometry(visit func(x, y float64)) error {
+	r.depth++
+	defer func() { r.depth-- }()
+	if r.depth > wkbMaxDepth {
+		return errors.New("WKB geometry nested too deeply")
+	}
+
+	if r.pos >= len(r.data) {
+		return errWkbTruncated
+	}
+	var order binary.ByteOrder
+	switch r.data[r.pos] {
+	case 0:
+		order = binary.BigEndian
+	case 1:
+		order = binary.LittleEndian
+	default:
+		return fmt.Errorf("invalid WKB byte order %d", r.data[r.pos])
+	}
+	r.pos++
+
+	geomType, err := r.uint32(order)
+	if err != nil {
+		return err
+	}
+	dims := 2
+	if geomType&ewkbZ != 0 {
+		dims++
+	}
+	if geomType&ewkbM != 0 {
+		dims++
+	}
+	hasSrid := geomType&ewkbSRID != 0
+	geomType &^= ewkbZ | ewkbM | ewkbSRID
+	switch geomType / 1000 {
+	case 0:
+	case 1, 2:
+		dims++
+	case 3:
+		dims += 2
+	default:
+		return fmt.Errorf("unsupported WKB geometry type %d", geomType)
+	}
+	if hasSrid {
+		srid, err := r.uint32(order)
+		if err != nil {
+			return err
+		}
+		if r.depth == 1 {
+			r.srid = int(srid)
+		}
+	}
+
+	switch geomType % 1000 {
+	case wkbPoint:
+		return r.points(order, 1, dims, visit)
+	case wkbLineString, wkbCircularString:
+		n, err := r.count(order, dims*8)
+		if err != nil {
+			return err
+		}
+		return r.points(order, n, dims, visit)
+	case wkbPolygon, wkbTriangle:
+		rings, err := r.count(order, 4)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < rings; i++ {
+			n, err := r.count(order, dims*8)
+			if err != nil {
+				return err
+			}
+			if err := r.points(order, n, dims, visit); err != nil {
+				return err
+			}
+		}
+		return nil
+	case wkbMultiPoint, wkbMultiLineString, wkbMultiPolygon, wkbGeometryCollection,
+		wkbCompoundCurve, wkbCurvePolygon, wkbMultiCurve, wkbMultiSurface, wkbPolyhedralSurface, wkbTin:
+		// each member is a complete WKB geometry, with its own byte order
+		n, err := r.count(order, 5)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < n; i++ {
+			if err := r.geometry(visit); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported WKB geometry type %d", geomType)
+	}
+}
+
+func (r *wkbReader) uint32(order binary.ByteOrder) (uint32, error) {
+	if r.pos+4 > len(r.data) {
+		return 0, errWkbTruncated
+	}
+	v := order.Uint32(r.data[r.pos:])
+	r.pos += 4
+	return v, nil
+}
+
+// count reads the number of elements that follow, checking they could fit in the remaining data
+func (r *wkbReader) count(order binary.ByteOrder, minElemSize int) (int, error) {
+	n, err := r.uint32(order)
+	if err != nil {
+		return 0, err
+	}
+	if int64(n)*int64(minElemSize) > int64(len(r.data)-r.pos) {
+		return 0, errWkbTruncated
+	}
+	return int(n), nil
+}
+
+func (r *wkbReader) points(order binary.ByteOrder, n int, dims int, visit func(x, y float64)) error {
+	if r.pos+n*dims*8 > len(r.data) {
+		return errWkbTruncated
+	}
+	for i := 0; i < n; i++ {
+		x := math.Float64frombits(order.Uint64(r.data[r.pos:]))
+		y := math.Float64frombits(order.Uint64(r.data[r.pos+8:]))
+		r.pos += dims * 8
+		if math.IsNaN(x) || math.IsNaN(y) {
+			continue
+		}
+		visit(x, y)
+	}
+	return nil
+}
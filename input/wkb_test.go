@@ -0,0 +1,187 @@
+package input
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/mikeocool/bbox/core"
+)
+
+// appendWkb appends a WKB geometry header in the given byte order
+func appendWkb(b []byte, order binary.AppendByteOrder, geomType uint32) []byte {
+	if order == binary.LittleEndian {
+		b = append(b, 1)
+	} else {
+		b = append(b, 0)
+	}
+	return order.AppendUint32(b, geomType)
+}
+
+func appendWkbCoords(b []byte, order binary.AppendByteOrder, coords ...float64) []byte {
+	for _, c := range coords {
+		b = order.AppendUint64(b, math.Float64bits(c))
+	}
+	return b
+}
+
+func wkbPointData(x, y float64) []byte {
+	return appendWkbCoords(appendWkb(nil, binary.LittleEndian, wkbPoint), binary.LittleEndian, x, y)
+}
+
+func TestWkbVertices(t *testing.T) {
+	le, be := binary.LittleEndian, binary.BigEndian
+
+	// a big endian multi point, with a little endian point inside
+	multiPoint := appendWkb(nil, be, wkbMultiPoint)
+	multiPoint = be.AppendUint32(multiPoint, 2)
+	multiPoint = append(multiPoint, wkbPointData(1, 2)...)
+	multiPoint = appendWkbCoords(appendWkb(multiPoint, be, wkbPoint), be, 3, -4)
+
+	// ISO WKB LineString Z
+	lineZ := le.AppendUint32(appendWkb(nil, le, 1000+wkbLineString), 2)
+	lineZ = appendWkbCoords(lineZ, le, 10, 20, 100, 11, 21, 200)
+
+	// EWKB polygon ZM with an SRID
+	polygon := appendWkb(nil, le, wkbPolygon|ewkbZ|ewkbM|ewkbSRID)
+	polygon = le.AppendUint32(polygon, 4326)
+	polygon = le.AppendUint32(polygon, 1)
+	polygon = le.AppendUint32(polygon, 3)
+	polygon = appendWkbCoords(polygon, le, 0, 0, 1, 1, 5, 0, 1, 1, 5, 5, 1, 1)
+
+	// empty geometries
+	emptyPoint := wkbPointData(math.NaN(), math.NaN())
+	emptyCollection := le.AppendUint32(appendWkb(nil, le, wkbGeometryCollection), 0)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    [][2]float64
+		wantErr bool
+	}{
+		{name: "point", data: wkbPointData(1.5, -2.5), want: [][2]float64{{1.5, -2.5}}},
+		{name: "mixed byte order multi point", data: multiPoint, want: [][2]float64{{1, 2}, {3, -4}}},
+		{name: "ISO Z line string", data: lineZ, want: [][2]float64{{10, 20}, {11, 21}}},
+		{name: "EWKB polygon", data: polygon, want: [][2]float64{{0, 0}, {5, 0}, {5, 5}}},
+		{name: "empty point", data: emptyPoint},
+		{name: "empty collection", data: emptyCollection},
+		{name: "truncated", data: wkbPointData(1, 2)[:12], wantErr: true},
+		{name: "huge count", data: le.AppendUint32(appendWkb(nil, le, wkbLineString), math.MaxUint32), wantErr: true},
+		{name: "invalid byte order", data: []byte{2, 1, 0, 0, 0}, wantErr: true},
+		{name: "unsupported type", data: appendWkb(nil, le, 99), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got [][2]float64
+			err := wkbVertices(tt.data, func(x, y float64) {
+				got = append(got, [2]float64{x, y})
+			})
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("wkbVertices() expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("wkbVertices() unexpected error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("wkbVertices() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("wkbVertices() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseWkb(t *testing.T) {
+	le, be := binary.LittleEndian, binary.BigEndian
+
+	// EWKB as PostGIS outputs it, a point in web mercator
+	mercatorPoint := le.AppendUint32(appendWkb(nil, le, wkbPoint|ewkbSRID), 3857)
+	mercatorPoint = appendWkbCoords(mercatorPoint, le, 111319.49079327357, 0)
+	// a big endian ISO WKB line string M
+	lineM := be.AppendUint32(appendWkb(nil, be, 2000+wkbLineString), 2)
+	lineM = appendWkbCoords(lineM, be, -1, -2, 0, 3, 4, 10)
+	wgs84Point := appendWkbCoords(le.AppendUint32(appendWkb(nil, le, wkbPoint|ewkbSRID), 4326), le, 0, 0)
+	emptyCollection := le.AppendUint32(appendWkb(nil, le, wkbGeometryCollection), 0)
+
+	tests := []struct {
+		name     string
+		input    []byte
+		want     core.Bbox
+		wantCode int
+		errorMsg string
+	}{
+		{
+			name:     "upper case hex EWKB",
+			input:    []byte(strings.ToUpper(hex.EncodeToString(mercatorPoint))),
+			want:     core.Bbox{Left: 111319.49079327357, Bottom: 0, Right: 111319.49079327357, Top: 0},
+			wantCode: 3857,
+		},
+		{
+			name:     "psql rows are transformed to the first CRS",
+			input:    []byte("\\x" + hex.EncodeToString(wgs84Point) + "\n" + hex.EncodeToString(mercatorPoint) + "\n"),
+			want:     core.Bbox{Left: 0, Bottom: 0, Right: 1, Top: 0},
+			wantCode: 4326,
+		},
+		{
+			name:  "concatenated binary with a trailing newline",
+			input: append(append(append(lineM, emptyCollection...), wkbPointData(5, 6)...), '\n'),
+			want:  core.Bbox{Left: -1, Bottom: -2, Right: 5, Top: 6},
+		},
+		{
+			name:     "only empty geometries",
+			input:    emptyCollection,
+			errorMsg: "no features found",
+		},
+		{
+			name:     "truncated hex",
+			input:    []byte(hex.EncodeToString(lineM[:30])),
+			errorMsg: "invalid WKB: truncated WKB geometry",
+		},
+		{
+			name:     "odd length hex",
+			input:    []byte(hex.EncodeToString(lineM) + "0"),
+			errorMsg: "invalid hex WKB",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !SniffWkb(tt.input) {
+				t.Fatalf("SniffWkb() = false")
+			}
+			got, crs, err := ParseRaw(tt.input, ReadOptions{})
+			if tt.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("ParseRaw() error = %v, want it to contain %q", err, tt.errorMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRaw() unexpected error = %v", err)
+			}
+			if !bboxWithin(got, tt.want, 1e-6) {
+				t.Errorf("ParseRaw() = %v, want %v", got, tt.want)
+			}
+			if tt.wantCode == 0 && crs != nil {
+				t.Errorf("ParseRaw() crs = %v, want nil", crs)
+			} else if tt.wantCode != 0 && (crs == nil || crs.Code != tt.wantCode) {
+				t.Errorf("ParseRaw() crs = %v, want EPSG:%d", crs, tt.wantCode)
+			}
+		})
+	}
+
+	for _, input := range []string{"1 2 3 4", "0123456789 0 1", "POINT (1 2)"} {
+		if SniffWkb([]byte(input)) {
+			t.Errorf("SniffWkb(%q) = true", input)
+		}
+	}
+}
@@ -0,0 +1,333 @@
+package input
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/mikeocool/bbox/core"
+	"github.com/mikeocool/bbox/proj"
+)
+
+// wktMaxDepth limits the nesting of geometry collections
+const wktMaxDepth = 32
+
+// wktTypes are the geometry types, by the structure of their text
+var wktTypes = map[string]string{
+	"POINT":              "point",
+	"LINESTRING":         "positions",
+	"CIRCULARSTRING":     "positions",
+	"POLYGON":            "rings",
+	"TRIANGLE":           "rings",
+	"MULTIPOINT":         "points",
+	"MULTILINESTRING":    "rings",
+	"MULTIPOLYGON":       "polygons",
+	"POLYHEDRALSURFACE":  "polygons",
+	"TIN":                "polygons",
+	"GEOMETRYCOLLECTION": "collection",
+}
+
+// SniffWkt checks whether the text starts with a WKT geometry type, or an EWKT SRID. The type has
+// to be followed by its parentheses or a Z, M, ZM or EMPTY, so CSV headers like point_id aren't WKT.
+func SniffWkt(data []byte) bool {
+	p := &wktGeometryParser{s: string(data[:min(len(data), 64)])}
+	p.skipSpace()
+	if p.hasPrefix("SRID=") {
+		return true
+	}
+	word := strings.ToUpper(p.word())
+	_, ok := wktTypes[word]
+	// EWKT has the M dimension in the type, like POINTM
+	_, okM := wktTypes[strings.TrimSuffix(word, "M")]
+	if !ok && !okM {
+		return false
+	}
+
+	p.pos += len(word)
+	p.skipSpace()
+	if p.peek() == '(' {
+		return true
+	}
+	switch strings.ToUpper(p.word()) {
+	case "Z", "M", "ZM", "EMPTY":
+		return true
+	}
+	return false
+}
+
+// ParseWkt reads the bounds of one or more WKT or EWKT geometries, separated by whitespace.
+// EWKT SRIDs are returned as the CRS, and every geometry is transformed to the CRS of the first.
+func ParseWkt(r io.Reader) (core.Bbox, *proj.CRS, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return core.Bbox{}, nil, fmt.Errorf("failed to read WKT: %w", err)
+	}
+
+	var union bboxUnion
+	p := &wktGeometryParser{s: string(data)}
+	for {
+		p.skipSpace()
+		if p.pos == len(p.s) {
+			break
+		}
+		minX, minY := math.Inf(1), math.Inf(1)
+		maxX, maxY := math.Inf(-1), math.Inf(-1)
+		srid, err := p.parse(func(x, y float64) {
+			updateBounds(&minX, &minY, &maxX, &maxY, x, y)
+		})
+		if err != nil {
+			return core.Bbox{}, nil, err
+		}
+		if minX > maxX {
+			continue
+		}
+		if err := union.add(core.Bbox{Left: minX, Bottom: minY, Right: maxX, Top: maxY}, sridCrs(srid)); err != nil {
+			return core.Bbox{}, nil, err
+		}
+	}
+	return union.result()
+}
+
+// wktVertices calls visit with the x and y of every position of WKT or EWKT geometry text
+func wktVertices(text string, visit func(x, y float64)) error {
+	p := &wktGeometryParser{s: text}
+	if _, err := p.parse(visit); err != nil {
+		return err
+	}
+	p.skipSpace()
+	if p.pos != len(p.s) {
+		return p.errorf("unexpected text after the geometry")
+	}
+	return nil
+}
+
+// sridCrs returns the CRS of an EWKT or EWKB SRID, or nil if there isn't one
+func sridCrs(srid int) *proj.CRS {
+	if srid <= 0 {
+		return nil
+	}
+	if crs, err := proj.Lookup(srid); err == nil {
+		return crs
+	}
+	return proj.Unsupported(srid, fmt.Sprintf("EPSG:%d", srid), false)
+}
+
+type wktGeometryParser struct {
+	s   string
+	pos int
+	// dimensions is the number of values in each position of the current geometry, or 0 if it
+	// wasn't given and can be 2 to 4
+	dimensions int
+	depth      int
+}
+
+// parse reads an optional EWKT SRID and a geometry, calling visit with each position. The SRID
+// is 0 if there isn't one.
+func (p *wktGeometryParser) parse(visit func(x, y float64)) (int, error) {
+	p.skipSpace()
+	srid := 0
+	if p.hasPrefix("SRID=") {
+		p.pos += len("SRID=")
+		end := strings.IndexByte(p.s[p.pos:], ';')
+		if end < 0 {
+			return 0, p.errorf("missing ; after the SRID")
+		}
+		var err error
+		srid, err = strconv.Atoi(strings.TrimSpace(p.s[p.pos : p.pos+end]))
+		if err != nil {
+			return 0, p.errorf("invalid SRID %q", p.s[p.pos:p.pos+end])
+		}
+		p.pos += end + 1
+	}
+	return srid, p.geometry(visit)
+}
+
+// geometry reads a tagged geometry, like POINT Z (1 2 3)
+func (p *wktGeometryParser) geometry(visit func(x, y float64)) error {
+	p.skipSpace()
+	start := p.pos
+	word := strings.ToUpper(p.word())
+	p.pos += len(word)
+
+	geometryType, ok := wktTypes[word]
+	dimensions := ""
+	if !ok {
+		// EWKT has the M dimension in the type, like POINTM
+		if base, found := strings.CutSuffix(word, "M"); found {
+			geometryType, ok = wktTypes[base]
+			dimensions = "M"
+		}
+	}
+	if !ok {
+		p.pos = start
+		return p.errorf("unknown geometry type %q", word)
+	}
+
+	p.skipSpace()
+	if dimensions == "" {
+		switch next := strings.ToUpper(p.word()); next {
+		case "Z", "M", "ZM":
+			dimensions = next
+			p.pos += len(next)
+		}
+	}
+	switch dimensions {
+	case "":
+		p.dimensions = 0
+	case "ZM":
+		p.dimensions = 4
+	default:
+		p.dimensions = 3
+	}
+
+	if p.empty() {
+		return nil
+	}
+	switch geometryType {
+	case "point":
+		return p.list(func() error { return p.position(visit) })
+	case "positions":
+		return p.positions(visit)
+	case "rings":
+		return p.list(func() error { return p.emptyOr(func() error { return p.positions(visit) }) })
+	case "points":
+		// points in a MULTIPOINT can be in parentheses or not
+		return p.list(func() error {
+			return p.emptyOr(func() error {
+				if p.peek() == '(' {
+					return p.list(func() error { return p.position(visit) })
+				}
+				return p.position(visit)
+			})
+		})
+	case "polygons":
+		return p.list(func() error {
+			return p.emptyOr(func() error {
+				return p.list(func() error { return p.emptyOr(func() error { return p.positions(visit) }) })
+			})
+		})
+	default:
+		if p.depth >= wktMaxDepth {
+			return p.errorf("geometry collections are nested too deeply")
+		}
+		p.depth++
+		defer func() { p.depth-- }()
+		return p.list(func() error { return p.geometry(visit) })
+	}
+}
+
+// list reads a comma separated list of items in parentheses
+func (p *wktGeometryParser) list(item func() error) error {
+	if err := p.expect('('); err != nil {
+		return err
+	}
+	for {
+		if err := item(); err != nil {
+			return err
+		}
+		p.skipSpace()
+		if p.peek() == ')' {
+			p.pos++
+			return nil
+		}
+		if err := p.expect(','); err != nil {
+			return err
+		}
+	}
+}
+
+// positions reads a list of positions, like the coordinates of a LINESTRING
+func (p *wktGeometryParser) positions(visit func(x, y float64)) error {
+	return p.list(func() error { return p.position(visit) })
+}
+
+// position reads the numbers of a position. Only the x and y are visited, not the z or m.
+func (p *wktGeometryParser) position(visit func(x, y float64)) error {
+	var values []float64
+	for {
+		p.skipSpace()
+		end := p.pos
+		for end < len(p.s) && strings.IndexByte("+-.0123456789eE", p.s[end]) >= 0 {
+			end++
+		}
+		if end == p.pos {
+			break
+		}
+		value, err := strconv.ParseFloat(p.s[p.pos:end], 64)
+		if err != nil {
+			return p.errorf("invalid number %q", p.s[p.pos:end])
+		}
+		values = append(values, value)
+		p.pos = end
+	}
+
+	if p.dimensions == 0 && (len(values) < 2 || len(values) > 4) {
+		return p.errorf("positions must have 2 to 4 values, not %d", len(values))
+	} else if p.dimensions != 0 && len(values) != p.dimensions {
+		return p.errorf("positions must have %d values, not %d", p.dimensions, len(values))
+	}
+	visit(values[0], values[1])
+	return nil
+}
+
+// emptyOr reads EMPTY, or calls read
+func (p *wktGeometryParser) emptyOr(read func() error) error {
+	if p.empty() {
+		return nil
+	}
+	return read()
+}
+
+// empty reads EMPTY, if it's next
+func (p *wktGeometryParser) empty() bool {
+	p.skipSpace()
+	if strings.ToUpper(p.word()) == "EMPTY" {
+		p.pos += len("EMPTY")
+		return true
+	}
+	return false
+}
+
+// word returns the letters at the current position, without reading them
+func (p *wktGeometryParser) word() string {
+	end := p.pos
+	for end < len(p.s) && (p.s[end] >= 'A' && p.s[end] <= 'Z' || p.s[end] >= 'a' && p.s[end] <= 'z') {
+		end++
+	}
+	return p.s[p.pos:end]
+}
+
+func (p *wktGeometryParser) hasPrefix(prefix string) bool {
+	return len(p.s)-p.pos >= len(prefix) && strings.EqualFold(p.s[p.pos:p.pos+len(prefix)], prefix)
+}
+
+func (p *wktGeometryParser) peek() byte {
+	if p.pos < len(p.s) {
+		return p.s[p.pos]
+	}
+	return 0
+}
+
+func (p *wktGeometryParser) expect(c byte) error {
+	p.skipSpace()
+	if p.peek() != c {
+		if p.pos == len(p.s) {
+			return p.errorf("expected %q, but the text ended", c)
+		}
+		return p.errorf("expected %q", c)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *wktGeometryParser) skipSpace() {
+	for p.pos < len(p.s) && strings.IndexByte(" \t\r\n", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+func (p *wktGeometryParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("invalid WKT at position %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
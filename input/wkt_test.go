@@ -0,0 +1,151 @@
+package input
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mikeocool/bbox/core"
+)
+
+func TestWktVertices(t *testing.T) {
+	tests := []struct {
+		name    string
+		wkt     string
+		want    [][2]float64
+		wantErr bool
+	}{
+		{name: "point", wkt: "POINT (1 2)", want: [][2]float64{{1, 2}}},
+		{name: "point z", wkt: "point z (1 2 3)", want: [][2]float64{{1, 2}}},
+		{name: "empty", wkt: "LINESTRING EMPTY"},
+		{name: "ewkt", wkt: "SRID=3857;MULTIPOINT ((1 2), (3 -4e1))", want: [][2]float64{{1, 2}, {3, -40}}},
+		{
+			name: "collection",
+			wkt:  "GEOMETRYCOLLECTION (POINT EMPTY, POLYGON ((0 0, 1 0, 1 1, 0 0)))",
+			want: [][2]float64{{0, 0}, {1, 0}, {1, 1}, {0, 0}},
+		},
+		{name: "not wkt", wkt: "POINT", wantErr: true},
+		{name: "one number", wkt: "POINT (1)", wantErr: true},
+		{name: "not a number", wkt: "POINT (1 a2)", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got [][2]float64
+			err := wktVertices(tt.wkt, func(x, y float64) {
+				got = append(got, [2]float64{x, y})
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("wktVertices() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("wktVertices() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWkt(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		want     core.Bbox
+		wantCode int
+		errorMsg string
+	}{
+		{
+			name:  "polygon",
+			input: "POLYGON((1.0 1.0, 2.0 1.0, 2.0 2.0, 1.0 2.0, 1.0 1.0))",
+			want:  core.Bbox{Left: 1, Bottom: 1, Right: 2, Top: 2},
+		},
+		{
+			name:  "multipolygon with a hole and an empty polygon",
+			input: "MultiPolygon (((0 0, 10 0, 10 10, 0 0), (1 1, 2 1, 2 2, 1 1)), EMPTY, ((20 -5, 21 -5, 21 -4, 20 -5)))",
+			want:  core.Bbox{Left: 0, Bottom: -5, Right: 21, Top: 10},
+		},
+		{
+			name:  "multipoint without parentheses",
+			input: "MULTIPOINT (1 2, 3 4)",
+			want:  core.Bbox{Left: 1, Bottom: 2, Right: 3, Top: 4},
+		},
+		{
+			name:  "z, m and zm",
+			input: "GEOMETRYCOLLECTION ZM (POINT Z (1 2 300), LINESTRING M (3 4 5, 6 7 8), POINT ZM (-1 -2 -3 -4), POINTM (0 9 1))",
+			want:  core.Bbox{Left: -1, Bottom: -2, Right: 6, Top: 9},
+		},
+		{
+			name:     "ewkt from PostGIS",
+			input:    "SRID=3857;POINT(-10018754.17 5621521.49)",
+			want:     core.Bbox{Left: -10018754.17, Bottom: 5621521.49, Right: -10018754.17, Top: 5621521.49},
+			wantCode: 3857,
+		},
+		{
+			name:     "several geometries are transformed to the first CRS",
+			input:    "SRID=4326;POINT (0 0)\nSRID=3857;POINT (111319.49079327357 0)\n",
+			want:     core.Bbox{Left: 0, Bottom: 0, Right: 1, Top: 0},
+			wantCode: 4326,
+		},
+		{
+			name:     "only empty geometries",
+			input:    "GEOMETRYCOLLECTION (POINT EMPTY, LINESTRING EMPTY)",
+			errorMsg: "no features found",
+		},
+		{
+			name:     "dimensions don't match",
+			input:    "POINT Z (1 2)",
+			errorMsg: "positions must have 3 values, not 2",
+		},
+		{
+			name:     "unbalanced parentheses",
+			input:    "LINESTRING (1 2, 3 4",
+			errorMsg: "but the text ended",
+		},
+		{
+			name:     "unknown type",
+			input:    "POINT (1 2) CURVE (1 2)",
+			errorMsg: `unknown geometry type "CURVE"`,
+		},
+		{
+			name:     "deeply nested collections",
+			input:    strings.Repeat("GEOMETRYCOLLECTION (", 40) + "POINT (1 2)" + strings.Repeat(")", 40),
+			errorMsg: "nested too deeply",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !SniffWkt([]byte(tt.input)) {
+				t.Fatalf("SniffWkt() = false")
+			}
+			got, crs, err := ParseRaw([]byte(tt.input), ReadOptions{})
+			if tt.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("ParseRaw() error = %v, want it to contain %q", err, tt.errorMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRaw() unexpected error = %v", err)
+			}
+			if !bboxWithin(got, tt.want, 1e-9) {
+				t.Errorf("ParseRaw() = %v, want %v", got, tt.want)
+			}
+			if tt.wantCode == 0 && crs != nil {
+				t.Errorf("ParseRaw() crs = %v, want nil", crs)
+			} else if tt.wantCode != 0 && (crs == nil || crs.Code != tt.wantCode) {
+				t.Errorf("ParseRaw() crs = %v, want EPSG:%d", crs, tt.wantCode)
+			}
+		})
+	}
+	// CSV headers that start with a geometry type
+	for _, input := range []string{"point_id,lat,lon\n1,40.1,-75.2\n", "tin,lat,lon\n1,40.1,-75.2\n"} {
+		if SniffWkt([]byte(input)) {
+			t.Errorf("SniffWkt(%q) = true", input)
+		}
+		got, _, err := ParseRaw([]byte(input), ReadOptions{})
+		want := core.Bbox{Left: -75.2, Bottom: 40.1, Right: -75.2, Top: 40.1}
+		if err != nil || !bboxWithin(got, want, 1e-9) {
+			t.Errorf("ParseRaw(%q) = %v, %v, want %v", input, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package input
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/mikeocool/bbox/core"
+)
+
+// newXmlDecoder returns a decoder for the XML formats: KML, GPX and OSM
+func newXmlDecoder(r io.Reader) *xml.Decoder {
+	decoder := xml.NewDecoder(r)
+	// files are sometimes declared as ISO-8859-1, the coordinates are ASCII either way
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	return decoder
+}
+
+// xmlAttr returns the value of an attribute of the element, or "" if it doesn't have it
+func xmlAttr(start xml.StartElement, name string) string {
+	for _, attr := range start.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// parseXmlBounds parses the minlon, minlat, maxlon and maxlat attributes of a bounds
+// element, like the ones in GPX and OSM files
+func parseXmlBounds(start xml.StartElement) (core.Bbox, error) {
+	var values [4]float64
+	for i, name := range []string{"minlon", "minlat", "maxlon", "maxlat"} {
+		v, err := strconv.ParseFloat(xmlAttr(start, name), 64)
+		if err != nil {
+			return core.Bbox{}, fmt.Errorf("invalid bounds %s=%q", name, xmlAttr(start, name))
+		}
+		values[i] = v
+	}
+	return core.Bbox{Left: values[0], Bottom: values[1], Right: values[2], Top: values[3]}, nil
+}
@@ -97,3 +97,27 @@ setup() {
     assert_output "10 17 20 20"
     assert_success
 }
+
+@test "tile zxy" {
+    run ./bbox tile 10 10 20 20 --zoom 1-2 -o zxy
+    assert_output "$(printf '1/1/0\n2/2/1')"
+    assert_success
+}
+
+@test "tile max tiles" {
+    run ./bbox tile 10 10 20 20 --zoom 20 --max-tiles 10
+    assert_output --partial "more than --max-tiles"
+    assert_failure
+}
+
+@test "to-crs" {
+    run ./bbox --to-crs EPSG:3857 -- 1 2 3 4
+    assert_output "111319.49079327357 222684.20850554318 333958.4723798207 445640.10965602525"
+    assert_success
+}
+
+@test "shapefile prj crs" {
+    run ./bbox --file $DIR/data/campsites/Wilderness_Campsites.shp --to-crs EPSG:4326 -o ewkt
+    assert_output --partial "SRID=4326;POLYGON((-92.434258"
+    assert_success
+}
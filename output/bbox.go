@@ -43,42 +43,111 @@ func DublinCoreFormat(_ OutputSettings, bbox core.Bbox) (string, error) {
 
 // GeojsonFormat formats a Bbox as a GeoJSON Polygon geometry.
 // The returned string will be a complete GeoJSON Polygon representing the bounding box.
+// Boxes that cross the antimeridian are formatted as a MultiPolygon split at the antimeridian.
 func GeojsonFormat(settings OutputSettings, bbox core.Bbox) (string, error) {
 	geojsonType := strings.ToLower(settings.GeojsonType)
 
 	geom := []geojson.Geometry{
-		geojson.PolygonGeometry([][][2]float64{bbox.Polygon()}),
+		bboxGeometry(bbox),
 	}
 
-	return geojson.Format(geom, geojsonType, settings.GeojsonIndent)
+	return geojson.Format(geom, geojsonType, settings.GeojsonIndent, settings.GeojsonBbox)
+}
+
+// bboxGeometry returns the GeoJSON geometry for a Bbox, either a Polygon or
+// a MultiPolygon if the box crosses the antimeridian. Its Bbox is the box itself, so a box that
+// crosses the antimeridian keeps its west edge east of its east edge, and a box with heights is
+// three dimensional.
+func bboxGeometry(bbox core.Bbox) geojson.Geometry {
+	var geom geojson.Geometry
+	if bbox.CrossesAntimeridian() {
+		polygons := bbox.Polygons()
+		coords := make([][][][2]float64, len(polygons))
+		for i, polygon := range polygons {
+			coords[i] = [][][2]float64{polygon}
+		}
+		geom = geojson.MultiPolygonGeometry(coords)
+	} else {
+		geom = geojson.PolygonGeometry([][][2]float64{bbox.Polygon()})
+	}
+	geom.Bbox = []float64{bbox.Left, bbox.Bottom, bbox.Right, bbox.Top}
+	if bbox.HasZ {
+		geom.Bbox = []float64{bbox.Left, bbox.Bottom, bbox.MinZ, bbox.Right, bbox.Top, bbox.MaxZ}
+	}
+	return geom
 }
 
 // WktFormat formats a Bbox as a WKT (Well-Known Text) Polygon geometry.
 // The returned string will be in the format "POLYGON((x1 y1, x2 y2, x3 y3, x4 y4, x1 y1))".
+// Boxes that cross the antimeridian are formatted as a MULTIPOLYGON split at the antimeridian.
 func WktFormat(_ OutputSettings, bbox core.Bbox) (string, error) {
-	coords := bbox.Polygon()
+	if bbox.CrossesAntimeridian() {
+		polygons := bbox.Polygons()
+		parts := make([]string, len(polygons))
+		for i, polygon := range polygons {
+			parts[i] = "(" + wktRing(polygon) + ")"
+		}
+		return "MULTIPOLYGON(" + strings.Join(parts, ", ") + ")", nil
+	}
 
-	// Build WKT polygon string
-	wkt := "POLYGON(("
+	return "POLYGON(" + wktRing(bbox.Polygon()) + ")", nil
+}
+
+// EwktFormat formats a Bbox as PostGIS EWKT, WKT prefixed with the SRID of the box.
+// The returned string will be in the format "SRID=4326;POLYGON((x1 y1, ...))".
+func EwktFormat(settings OutputSettings, bbox core.Bbox) (string, error) {
+	wkt, err := WktFormat(settings, bbox)
+	if err != nil {
+		return "", err
+	}
+	return ewktPrefix(settings) + wkt, nil
+}
+
+// wktRing formats a ring of coordinates in the format "(x1 y1, x2 y2, ...)"
+func wktRing(coords [][2]float64) string {
+	wkt := "("
 	for i, coord := range coords {
 		if i > 0 {
 			wkt += ", "
 		}
 		wkt += fmt.Sprintf("%g %g", coord[0], coord[1])
 	}
-	wkt += "))"
-
-	return wkt, nil
+	wkt += ")"
+	return wkt
 }
 
 // WkbhexFormat formats a Bbox as a WKB (Well-Known Binary) Polygon geometry encoded as hexadecimal.
 // The returned string will be the hexadecimal representation of the WKB binary data.
+// Boxes that cross the antimeridian are formatted as a MultiPolygon split at the antimeridian.
 func WkbhexFormat(_ OutputSettings, bbox core.Bbox) (string, error) {
-	coords := bbox.Polygon()
-
 	// Create buffer for WKB data
 	buf := new(bytes.Buffer)
 
+	if bbox.CrossesAntimeridian() {
+		polygons := bbox.Polygons()
+
+		// Write byte order (little endian)
+		binary.Write(buf, binary.LittleEndian, uint8(1))
+
+		// Write geometry type (multipolygon = 6)
+		binary.Write(buf, binary.LittleEndian, uint32(6))
+
+		// Write number of polygons
+		binary.Write(buf, binary.LittleEndian, uint32(len(polygons)))
+
+		for _, polygon := range polygons {
+			writeWkbPolygon(buf, polygon)
+		}
+	} else {
+		writeWkbPolygon(buf, bbox.Polygon())
+	}
+
+	// Convert to hex string
+	return strings.ToUpper(hex.EncodeToString(buf.Bytes())), nil
+}
+
+// writeWkbPolygon writes a little endian WKB Polygon with a single ring to the buffer
+func writeWkbPolygon(buf *bytes.Buffer, coords [][2]float64) {
 	// Write byte order (little endian)
 	binary.Write(buf, binary.LittleEndian, uint8(1))
 
@@ -96,9 +165,6 @@ func WkbhexFormat(_ OutputSettings, bbox core.Bbox) (string, error) {
 		binary.Write(buf, binary.LittleEndian, coord[0])
 		binary.Write(buf, binary.LittleEndian, coord[1])
 	}
-
-	// Convert to hex string
-	return strings.ToUpper(hex.EncodeToString(buf.Bytes())), nil
 }
 
 // UrlFormat formats a Bbox as a URL to visualize it on various mapping services.
@@ -136,6 +202,7 @@ var bboxOutputFormatters = map[string]func(OutputSettings, core.Bbox) (string, e
 	FormatTab:        TabFormat,
 	FormatGeoJson:    GeojsonFormat,
 	FormatWkt:        WktFormat,
+	FormatEwkt:       EwktFormat,
 	FormatWkbhex:     WkbhexFormat,
 	FormatDublinCore: DublinCoreFormat,
 	FormatUrl:        UrlFormat,
@@ -174,6 +174,12 @@ func TestWktFormat(t *testing.T) {
 			expected:    "POLYGON((0.0001 0.0002, 0.0003 0.0002, 0.0003 0.0004, 0.0001 0.0004, 0.0001 0.0002))",
 			expectError: false,
 		},
+		{
+			name:        "Antimeridian crossing",
+			bbox:        core.Bbox{Left: 177, Bottom: -20, Right: -178, Top: -12},
+			expected:    "MULTIPOLYGON(((177 -20, 180 -20, 180 -12, 177 -12, 177 -20)), ((-180 -20, -178 -20, -178 -12, -180 -12, -180 -20)))",
+			expectError: false,
+		},
 	}
 
 	for _, tc := range tests {
@@ -561,6 +567,12 @@ func TestCommaFormat(t *testing.T) {
 			expected:    "-10.5,20.25,-5.75,15.125",
 			expectError: false,
 		},
+		{
+			name:        "Large projected coordinates",
+			bbox:        core.Bbox{Left: -20037508.342789244, Bottom: 1e6, Right: 20037508.342789244, Top: 2e6},
+			expected:    "-2.0037508342789244e+07,1e+06,2.0037508342789244e+07,2e+06",
+			expectError: false,
+		},
 	}
 
 	for _, tc := range tests {
@@ -852,6 +864,26 @@ func TestGeojsonFormatWithIndent(t *testing.T) {
 	}
 }
 
+func TestGeojsonFormatHeights(t *testing.T) {
+	bbox := core.Bbox{Left: 1, Bottom: 2, Right: 3, Top: 4, HasZ: true, MinZ: -10, MaxZ: 250}
+
+	result, err := GeojsonFormat(OutputSettings{GeojsonType: "feature", GeojsonBbox: true}, bbox)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(result, `{"type":"Feature","bbox":[1,2,-10,3,4,250],`) {
+		t.Errorf("Expected a three dimensional bbox but got %s", result)
+	}
+
+	result, err = GeojsonFormat(OutputSettings{GeojsonType: "feature"}, bbox)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Contains(result, "bbox") || strings.Contains(result, "250") {
+		t.Errorf("Expected the heights to be left out without a bbox but got %s", result)
+	}
+}
+
 func TestWkbhexFormat(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -926,6 +958,66 @@ func TestWkbhexFormat(t *testing.T) {
 	}
 }
 
+func TestAntimeridianFormats(t *testing.T) {
+	bbox := core.Bbox{Left: 177, Bottom: -20, Right: -178, Top: -12}
+
+	t.Run("GeoJSON MultiPolygon", func(t *testing.T) {
+		result, err := GeojsonFormat(OutputSettings{}, bbox)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		expected := `{"type":"MultiPolygon","coordinates":[[[[177,-20],[180,-20],[180,-12],[177,-12],[177,-20]]],[[[-180,-20],[-178,-20],[-178,-12],[-180,-12],[-180,-20]]]]}`
+		if result != expected {
+			t.Errorf("Expected %s but got %s", expected, result)
+		}
+	})
+
+	t.Run("GeoJSON collection", func(t *testing.T) {
+		result, err := GeojsonFormatCollection(OutputSettings{}, []core.Bbox{bbox, {Left: 1, Bottom: 2, Right: 3, Top: 4}})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !strings.Contains(result, `"MultiPolygon"`) || !strings.Contains(result, `"Polygon"`) {
+			t.Errorf("Expected a MultiPolygon and a Polygon but got %s", result)
+		}
+	})
+
+	t.Run("GeoJSON bbox members", func(t *testing.T) {
+		settings := OutputSettings{GeojsonType: "feature", GeojsonBbox: true}
+		result, err := GeojsonFormat(settings, bbox)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !strings.HasPrefix(result, `{"type":"Feature","bbox":[177,-20,-178,-12],"geometry":{"type":"MultiPolygon","coordinates"`) {
+			t.Errorf("Expected the feature's bbox to cross the antimeridian but got %s", result)
+		}
+
+		result, err = GeojsonFormatCollection(OutputSettings{GeojsonBbox: true}, []core.Bbox{bbox, {Left: 1, Bottom: 2, Right: 3, Top: 4}})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !strings.HasPrefix(result, `{"type":"FeatureCollection","bbox":[1,-20,-178,4],`) {
+			t.Errorf("Expected the collection's bbox to cross the antimeridian but got %s", result)
+		}
+	})
+
+	t.Run("WKB MultiPolygon", func(t *testing.T) {
+		result, err := WkbhexFormat(OutputSettings{}, bbox)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		// little endian multipolygon with 2 polygons, followed by a little endian polygon
+		if !strings.HasPrefix(result, "01060000000200000001030000000100000005000000") {
+			t.Errorf("Expected a WKB MultiPolygon but got %s", result)
+		}
+		// header + 2 polygons of (header + 5 points)
+		expectedLen := (1 + 4 + 4 + 2*(1+4+4+4+5*16)) * 2
+		if len(result) != expectedLen {
+			t.Errorf("Expected %d hex characters but got %d", expectedLen, len(result))
+		}
+	})
+}
+
 func TestDublinCoreFormat(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -996,3 +1088,24 @@ func TestDublinCoreFormat(t *testing.T) {
 		})
 	}
 }
+
+func TestEwktFormat(t *testing.T) {
+	bbox := core.Bbox{Left: 1, Bottom: 2, Right: 3, Top: 4}
+
+	result, err := EwktFormat(OutputSettings{Srid: 3857}, bbox)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := "SRID=3857;POLYGON((1 2, 3 2, 3 4, 1 4, 1 2))"; result != expected {
+		t.Errorf("Expected %q but got %q", expected, result)
+	}
+
+	// without a known SRID it's plain WKT
+	result, err = EwktFormat(OutputSettings{}, bbox)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := "POLYGON((1 2, 3 2, 3 4, 1 4, 1 2))"; result != expected {
+		t.Errorf("Expected %q but got %q", expected, result)
+	}
+}
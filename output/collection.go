@@ -48,10 +48,10 @@ func GeojsonFormatCollection(settings OutputSettings, boxes []core.Bbox) (string
 
 	geoms := make([]geojson.Geometry, len(boxes))
 	for i, box := range boxes {
-		geoms[i] = geojson.PolygonGeometry([][][2]float64{box.Polygon()})
+		geoms[i] = bboxGeometry(box)
 	}
 
-	return geojson.Format(geoms, geojsonType, settings.GeojsonIndent)
+	return geojson.Format(geoms, geojsonType, settings.GeojsonIndent, settings.GeojsonBbox)
 }
 
 // WktFormatCollection formats a collection of bboxes as a WKT GEOMETRYCOLLECTION.
@@ -65,6 +65,15 @@ func WktFormatCollection(settings OutputSettings, boxes []core.Bbox) (string, er
 	return val, nil
 }
 
+// EwktFormatCollection formats a collection of bboxes as an EWKT GEOMETRYCOLLECTION with the SRID of the boxes.
+func EwktFormatCollection(settings OutputSettings, boxes []core.Bbox) (string, error) {
+	wkt, err := WktFormatCollection(settings, boxes)
+	if err != nil {
+		return "", err
+	}
+	return ewktPrefix(settings) + wkt, nil
+}
+
 // collectionOutputFormatters maps format type constants to their corresponding format functions
 var collectionOutputFormatters = map[string]func(OutputSettings, []core.Bbox) (string, error){
 	FormatGoTpl:   TemplatedFormatCollection,
@@ -72,6 +81,7 @@ var collectionOutputFormatters = map[string]func(OutputSettings, []core.Bbox) (s
 	FormatSpace:   SpaceFormatCollection,
 	FormatTab:     TabFormatCollection,
 	FormatWkt:     WktFormatCollection,
+	FormatEwkt:    EwktFormatCollection,
 	FormatGeoJson: GeojsonFormatCollection,
 }
 
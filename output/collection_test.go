@@ -289,3 +289,14 @@ func TestTemplatedFormatCollection(t *testing.T) {
 		})
 	}
 }
+
+func TestEwktFormatCollection(t *testing.T) {
+	boxes := []core.Bbox{{Left: 0, Bottom: 0, Right: 1, Top: 1}}
+	result, err := FormatCollection(boxes, OutputSettings{FormatType: FormatEwkt, Srid: 32633})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := "SRID=32633;GEOMETRYCOLLECTION(POLYGON((0 0, 1 0, 1 1, 0 1, 0 0)))"; result != expected {
+		t.Errorf("Expected %q but got %q", expected, result)
+	}
+}
@@ -2,8 +2,9 @@ package output
 
 import (
 	"bytes"
-	"html/template"
+	"fmt"
 	"strings"
+	"text/template"
 )
 
 type OutputSettings struct {
@@ -11,6 +12,10 @@ type OutputSettings struct {
 	FormatDetails string
 	GeojsonIndent int
 	GeojsonType   string
+	// GeojsonBbox writes a bbox member on GeoJSON Features, collections and lone geometries
+	GeojsonBbox bool
+	// Srid is the EPSG code of the coordinates, 0 if it isn't known
+	Srid int
 }
 
 // ParseFormat parses a format string into format type and details.
@@ -43,6 +48,14 @@ func FormatWithTemplate(templateStr string, geom any) (string, error) {
 	return buf.String(), nil
 }
 
+// ewktPrefix returns the SRID prefix for EWKT output, or nothing if the SRID isn't known
+func ewktPrefix(settings OutputSettings) string {
+	if settings.Srid == 0 {
+		return ""
+	}
+	return fmt.Sprintf("SRID=%d;", settings.Srid)
+}
+
 // Format type constants
 const (
 	FormatGoTpl      = "go-template"
@@ -51,7 +64,9 @@ const (
 	FormatTab        = "tab"
 	FormatGeoJson    = "geojson"
 	FormatWkt        = "wkt"
+	FormatEwkt       = "ewkt"
 	FormatWkbhex     = "wkbhex"
 	FormatDublinCore = "dcsv"
 	FormatUrl        = "url"
+	FormatZxy        = "zxy"
 )
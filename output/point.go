@@ -53,7 +53,17 @@ func GeojsonFormatPoint(settings OutputSettings, coords [2]float64) (string, err
 		geojson.PointGeometry(coords[0], coords[1]),
 	}
 
-	return geojson.Format(geom, geojsonType, settings.GeojsonIndent)
+	return geojson.Format(geom, geojsonType, settings.GeojsonIndent, settings.GeojsonBbox)
+}
+
+// EwktFormatPoint formats a point as an EWKT Point geometry with the SRID of the point.
+// The returned string will be in the format "SRID=4326;POINT (X Y)".
+func EwktFormatPoint(settings OutputSettings, point [2]float64) (string, error) {
+	wkt, err := WktFormatPoint(settings, point)
+	if err != nil {
+		return "", err
+	}
+	return ewktPrefix(settings) + wkt, nil
 }
 
 // pointOutputFormatters maps format type constants to their corresponding format functions
@@ -63,6 +73,7 @@ var pointOutputFormatters = map[string]func(OutputSettings, [2]float64) (string,
 	FormatSpace:   SpaceFormatPoint,
 	FormatTab:     TabFormatPoint,
 	FormatWkt:     WktFormatPoint,
+	FormatEwkt:    EwktFormatPoint,
 	FormatGeoJson: GeojsonFormatPoint,
 	// TODO url?
 }
@@ -495,3 +495,13 @@ func TestFormatPoint(t *testing.T) {
 		})
 	}
 }
+
+func TestEwktFormatPoint(t *testing.T) {
+	result, err := FormatPoint([2]float64{1.5, 2.5}, OutputSettings{FormatType: FormatEwkt, Srid: 4326})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := "SRID=4326;POINT (1.5 2.5)"; result != expected {
+		t.Errorf("Expected %q but got %q", expected, result)
+	}
+}
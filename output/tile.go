@@ -0,0 +1,30 @@
+package output
+
+import (
+	"strings"
+
+	"github.com/mikeocool/bbox/core"
+)
+
+// ZxyFormatTiles formats a collection of tiles as z/x/y lines.
+func ZxyFormatTiles(_ OutputSettings, tiles []core.Tile) (string, error) {
+	out := make([]string, len(tiles))
+	for i, tile := range tiles {
+		out[i] = tile.String()
+	}
+	return strings.Join(out, "\n"), nil
+}
+
+// FormatTiles formats a collection of tiles using the specified format type.
+// Tiles are output as z/x/y lines, or as their bounding boxes for any of the collection formats.
+func FormatTiles(tiles []core.Tile, settings OutputSettings) (string, error) {
+	if settings.FormatType == FormatZxy {
+		return ZxyFormatTiles(settings, tiles)
+	}
+
+	boxes := make([]core.Bbox, len(tiles))
+	for i, tile := range tiles {
+		boxes[i] = tile.Bbox()
+	}
+	return FormatCollection(boxes, settings)
+}
@@ -0,0 +1,52 @@
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mikeocool/bbox/core"
+)
+
+func TestFormatTiles(t *testing.T) {
+	tiles := []core.Tile{{Z: 1, X: 0, Y: 0}, {Z: 1, X: 1, Y: 0}}
+
+	t.Run("zxy", func(t *testing.T) {
+		got, err := FormatTiles(tiles, OutputSettings{FormatType: FormatZxy})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != "1/0/0\n1/1/0" {
+			t.Errorf("FormatTiles() = %q", got)
+		}
+	})
+
+	t.Run("Collection format", func(t *testing.T) {
+		got, err := FormatTiles(tiles, OutputSettings{FormatType: FormatComma})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		lines := strings.Split(got, "\n")
+		if len(lines) != 2 {
+			t.Fatalf("expected 2 lines, got %q", got)
+		}
+		if !strings.HasPrefix(lines[0], "-180,") || !strings.HasPrefix(lines[1], "0,") {
+			t.Errorf("unexpected tile bounds: %q", got)
+		}
+	})
+
+	t.Run("GeoJSON", func(t *testing.T) {
+		got, err := FormatTiles(tiles, OutputSettings{FormatType: FormatGeoJson})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if strings.Count(got, `"type":"Feature"`) != 2 {
+			t.Errorf("expected a feature per tile, got %s", got)
+		}
+	})
+
+	t.Run("Unknown format", func(t *testing.T) {
+		if _, err := FormatTiles(tiles, OutputSettings{FormatType: "nope"}); err == nil {
+			t.Errorf("expected error for unknown format")
+		}
+	})
+}
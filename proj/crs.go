@@ -0,0 +1,238 @@
+package proj
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CRS is a coordinate reference system identified by its EPSG code
+type CRS struct {
+	// Code is 0 if the EPSG code isn't known
+	Code  int
+	Name  string
+	Datum Datum
+	// Projection is nil for geographic coordinate systems with coordinates in degrees
+	Projection Projection
+
+	unsupported bool
+	// metersPerUnit is the length of the unit of an unsupported projected CRS, or 0 if it isn't known
+	metersPerUnit float64
+}
+
+const EPSGWgs84 = 4326
+
+var ErrUnsupportedCRS = errors.New("unsupported CRS")
+
+// Unsupported returns a CRS that was detected in some data, but that we can't transform.
+// code may be 0 if it isn't known.
+func Unsupported(code int, name string, geographic bool) *CRS {
+	crs := &CRS{Code: code, Name: name, Datum: Datum{Name: "unknown", Ellipsoid: WGS84}, unsupported: true}
+	if !geographic {
+		crs.Projection = unsupportedProjection{}
+	}
+	return crs
+}
+
+// IsGeographic returns true if the CRS has longitude and latitude coordinates in degrees
+func (c *CRS) IsGeographic() bool {
+	return c.Projection == nil
+}
+
+// IsSupported returns false if the CRS can't be transformed to or from other CRSs
+func (c *CRS) IsSupported() bool {
+	return !c.unsupported
+}
+
+// MetersPerUnit returns the length in meters of a projected CRS's coordinate unit, and false if
+// the CRS is geographic or its unit isn't known. The supported projected CRSs are all in meters.
+func (c *CRS) MetersPerUnit() (float64, bool) {
+	if c.IsGeographic() {
+		return 0, false
+	}
+	if c.IsSupported() {
+		return 1, true
+	}
+	return c.metersPerUnit, c.metersPerUnit > 0
+}
+
+// IsWgs84Compatible returns true if the CRS has longitude and latitude coordinates
+// on a datum that's within a meter or so of WGS84
+func (c *CRS) IsWgs84Compatible() bool {
+	return c.IsSupported() && c.IsGeographic() && c.Datum.ToWgs84 == nil
+}
+
+// Equal returns true if both CRSs are the same
+func (c *CRS) Equal(other *CRS) bool {
+	if c.Code != 0 || other.Code != 0 {
+		return c.Code == other.Code
+	}
+	return c.Name == other.Name
+}
+
+// String returns the CRS in the form "EPSG:4326", or its name if it doesn't have an EPSG code
+func (c *CRS) String() string {
+	if c.Code == 0 {
+		return c.Name
+	}
+	return fmt.Sprintf("EPSG:%d", c.Code)
+}
+
+// ToWgs84 converts a coordinate in the CRS to WGS84 longitude and latitude in degrees
+func (c *CRS) ToWgs84(x, y float64) (float64, float64, error) {
+	lon, lat := x*deg, y*deg
+	if c.Projection != nil {
+		var err error
+		lon, lat, err = c.Projection.Inverse(x, y)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	lon, lat = c.Datum.toWgs84(lon, lat)
+	return lon / deg, lat / deg, nil
+}
+
+// FromWgs84 converts WGS84 longitude and latitude in degrees to a coordinate in the CRS
+func (c *CRS) FromWgs84(lon, lat float64) (float64, float64, error) {
+	lonRad, latRad := c.Datum.fromWgs84(lon*deg, lat*deg)
+	if c.Projection != nil {
+		return c.Projection.Forward(lonRad, latRad)
+	}
+	return lonRad / deg, latRad / deg, nil
+}
+
+// crsDefinitions holds the coordinate systems that aren't part of a numbered series like the UTM zones
+var crsDefinitions = map[int]func() *CRS{
+	4326: func() *CRS { return &CRS{Code: 4326, Name: "WGS 84", Datum: DatumWGS84} },
+	4269: func() *CRS { return &CRS{Code: 4269, Name: "NAD83", Datum: DatumNAD83} },
+	4258: func() *CRS { return &CRS{Code: 4258, Name: "ETRS89", Datum: DatumETRS89} },
+	4283: func() *CRS { return &CRS{Code: 4283, Name: "GDA94", Datum: DatumGDA94} },
+	4167: func() *CRS { return &CRS{Code: 4167, Name: "NZGD2000", Datum: DatumNZGD2000} },
+	4171: func() *CRS { return &CRS{Code: 4171, Name: "RGF93", Datum: DatumRGF93} },
+	4277: func() *CRS { return &CRS{Code: 4277, Name: "OSGB 1936", Datum: DatumOSGB36} },
+	3857: func() *CRS {
+		// web mercator projects WGS84 coordinates as if they were on a sphere
+		sphere := Ellipsoid{Name: "WGS 84 sphere", A: WGS84.A}
+		return &CRS{Code: 3857, Name: "WGS 84 / Pseudo-Mercator", Datum: DatumWGS84,
+			Projection: NewMercator(sphere, 0, 1, 85.0511287798066)}
+	},
+	3395: func() *CRS {
+		return &CRS{Code: 3395, Name: "WGS 84 / World Mercator", Datum: DatumWGS84,
+			Projection: NewMercator(WGS84, 0, 1, 85.08405903)}
+	},
+	27700: func() *CRS {
+		return &CRS{Code: 27700, Name: "OSGB 1936 / British National Grid", Datum: DatumOSGB36,
+			Projection: NewTransverseMercator(Airy1830, 49, -2, 0.9996012717, 400000, -100000)}
+	},
+	2154: func() *CRS {
+		return &CRS{Code: 2154, Name: "RGF93 / Lambert-93", Datum: DatumRGF93,
+			Projection: NewLambertConformalConic(GRS80, 49, 44, 46.5, 3, 700000, 6600000)}
+	},
+	3035: func() *CRS {
+		return &CRS{Code: 3035, Name: "ETRS89 / LAEA Europe", Datum: DatumETRS89,
+			Projection: NewLambertAzimuthalEqualArea(GRS80, 52, 10, 4321000, 3210000)}
+	},
+	5070: func() *CRS {
+		return &CRS{Code: 5070, Name: "NAD83 / Conus Albers", Datum: DatumNAD83,
+			Projection: NewAlbersEqualArea(GRS80, 29.5, 45.5, 23, -96, 0, 0)}
+	},
+	3577: func() *CRS {
+		return &CRS{Code: 3577, Name: "GDA94 / Australian Albers", Datum: DatumGDA94,
+			Projection: NewAlbersEqualArea(GRS80, -18, -36, 0, 132, 0, 0)}
+	},
+	2193: func() *CRS {
+		return &CRS{Code: 2193, Name: "NZGD2000 / New Zealand Transverse Mercator 2000", Datum: DatumNZGD2000,
+			Projection: NewTransverseMercator(GRS80, 0, 173, 0.9996, 1600000, 10000000)}
+	},
+}
+
+// utmSeries describes a range of EPSG codes for consecutive UTM zones
+type utmSeries struct {
+	firstCode int
+	firstZone int
+	lastZone  int
+	south     bool
+	datum     Datum
+	name      string
+}
+
+var utmSeriesDefinitions = []utmSeries{
+	{firstCode: 32601, firstZone: 1, lastZone: 60, datum: DatumWGS84, name: "WGS 84"},
+	{firstCode: 32701, firstZone: 1, lastZone: 60, south: true, datum: DatumWGS84, name: "WGS 84"},
+	{firstCode: 26901, firstZone: 1, lastZone: 23, datum: DatumNAD83, name: "NAD83"},
+	{firstCode: 25828, firstZone: 28, lastZone: 38, datum: DatumETRS89, name: "ETRS89"},
+	{firstCode: 28348, firstZone: 48, lastZone: 58, south: true, datum: DatumGDA94, name: "GDA94 / MGA"},
+}
+
+// Lookup returns the CRS for an EPSG code
+func Lookup(code int) (*CRS, error) {
+	if def, ok := crsDefinitions[code]; ok {
+		return def(), nil
+	}
+
+	for _, series := range utmSeriesDefinitions {
+		zone := code - series.firstCode + series.firstZone
+		if zone < series.firstZone || zone > series.lastZone {
+			continue
+		}
+		hemisphere := "N"
+		if series.south {
+			hemisphere = "S"
+		}
+		return &CRS{
+			Code:       code,
+			Name:       fmt.Sprintf("%s / UTM zone %d%s", series.name, zone, hemisphere),
+			Datum:      series.datum,
+			Projection: NewUTM(series.datum.Ellipsoid, zone, series.south),
+		}, nil
+	}
+
+	return nil, fmt.Errorf("%w: EPSG:%d", ErrUnsupportedCRS, code)
+}
+
+// Parse parses a CRS from an EPSG code like "EPSG:32633", "epsg:4326" or "3857"
+func Parse(s string) (*CRS, error) {
+	code, err := ParseCode(s)
+	if err != nil {
+		return nil, err
+	}
+	return Lookup(code)
+}
+
+// ParseCode parses the EPSG code from a CRS identifier. Along with the forms accepted by Parse,
+// it accepts the URNs and URLs used by GeoJSON and OGC services, like "urn:ogc:def:crs:EPSG::3857",
+// "http://www.opengis.net/def/crs/EPSG/0/3857" and "urn:ogc:def:crs:OGC:1.3:CRS84".
+func ParseCode(s string) (int, error) {
+	trimmed := strings.TrimSpace(s)
+	lower := strings.ToLower(trimmed)
+
+	// CRS84 is WGS84 with the longitude first, which is how we treat EPSG:4326 anyway
+	if lower == "crs84" || strings.HasSuffix(lower, ":crs84") || strings.HasSuffix(lower, "/crs84") {
+		return EPSGWgs84, nil
+	}
+
+	codeStr := trimmed
+	switch {
+	case strings.HasPrefix(lower, "urn:ogc:def:crs:epsg:"):
+		// urn:ogc:def:crs:EPSG:[version]:code
+		codeStr = trimmed[strings.LastIndex(trimmed, ":")+1:]
+	case strings.HasPrefix(lower, "http://www.opengis.net/def/crs/epsg/"),
+		strings.HasPrefix(lower, "https://www.opengis.net/def/crs/epsg/"):
+		// http://www.opengis.net/def/crs/EPSG/[version]/code
+		codeStr = trimmed[strings.LastIndex(trimmed, "/")+1:]
+	default:
+		if prefix, rest, found := strings.Cut(trimmed, ":"); found {
+			if !strings.EqualFold(strings.TrimSpace(prefix), "EPSG") {
+				return 0, fmt.Errorf("unsupported CRS authority %q, only EPSG codes are supported", prefix)
+			}
+			codeStr = rest
+		}
+	}
+
+	code, err := strconv.Atoi(strings.TrimSpace(codeStr))
+	if err != nil {
+		return 0, fmt.Errorf("invalid CRS %q, expected an EPSG code like EPSG:4326", s)
+	}
+	return code, nil
+}
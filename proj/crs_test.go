@@ -0,0 +1,69 @@
+package proj
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		wantCode int
+		wantName string
+		wantErr  bool
+	}{
+		{input: "EPSG:4326", wantCode: 4326, wantName: "WGS 84"},
+		{input: "epsg:3857", wantCode: 3857, wantName: "WGS 84 / Pseudo-Mercator"},
+		{input: "27700", wantCode: 27700, wantName: "OSGB 1936 / British National Grid"},
+		{input: " EPSG: 32633 ", wantCode: 32633, wantName: "WGS 84 / UTM zone 33N"},
+		{input: "EPSG:32760", wantCode: 32760, wantName: "WGS 84 / UTM zone 60S"},
+		{input: "EPSG:26915", wantCode: 26915, wantName: "NAD83 / UTM zone 15N"},
+		{input: "EPSG:25832", wantCode: 25832, wantName: "ETRS89 / UTM zone 32N"},
+		{input: "EPSG:28355", wantCode: 28355, wantName: "GDA94 / MGA / UTM zone 55S"},
+		{input: "urn:ogc:def:crs:EPSG::3857", wantCode: 3857, wantName: "WGS 84 / Pseudo-Mercator"},
+		{input: "urn:ogc:def:crs:EPSG:6.6:27700", wantCode: 27700, wantName: "OSGB 1936 / British National Grid"},
+		{input: "http://www.opengis.net/def/crs/EPSG/0/2154", wantCode: 2154, wantName: "RGF93 / Lambert-93"},
+		{input: "urn:ogc:def:crs:OGC:1.3:CRS84", wantCode: 4326, wantName: "WGS 84"},
+		{input: "EPSG:32661", wantErr: true},
+		{input: "EPSG:9999", wantErr: true},
+		{input: "ESRI:102003", wantErr: true},
+		{input: "wgs84", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Code != tt.wantCode || got.Name != tt.wantName {
+				t.Errorf("Parse(%q) = %d %q, want %d %q", tt.input, got.Code, got.Name, tt.wantCode, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestCRSDatumShift(t *testing.T) {
+	// Big Ben, the WGS84 and OSGB36 coordinates differ by around 100 meters
+	wgs84Lon, wgs84Lat := -0.124625, 51.500729
+
+	osgb36, _ := Lookup(4277)
+	lon, lat, err := osgb36.FromWgs84(wgs84Lon, wgs84Lat)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if math.Abs(lon-(-0.123054)) > 1e-4 || math.Abs(lat-51.500214) > 1e-4 {
+		t.Errorf("FromWgs84() = %f, %f, want about -0.123054, 51.500214", lon, lat)
+	}
+
+	backLon, backLat, err := osgb36.ToWgs84(lon, lat)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if math.Abs(backLon-wgs84Lon) > 1e-6 || math.Abs(backLat-wgs84Lat) > 1e-6 {
+		t.Errorf("ToWgs84() = %f, %f, want %f, %f", backLon, backLat, wgs84Lon, wgs84Lat)
+	}
+}
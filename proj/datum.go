@@ -0,0 +1,123 @@
+package proj
+
+import "math"
+
+// Ellipsoid describes the shape of the earth used by a datum
+type Ellipsoid struct {
+	Name string
+	// A is the semi-major axis in meters
+	A float64
+	// F is the flattening
+	F float64
+}
+
+var (
+	WGS84    = Ellipsoid{Name: "WGS 84", A: 6378137, F: 1 / 298.257223563}
+	GRS80    = Ellipsoid{Name: "GRS 1980", A: 6378137, F: 1 / 298.257222101}
+	Airy1830 = Ellipsoid{Name: "Airy 1830", A: 6377563.396, F: 1 / 299.3249646}
+)
+
+// E2 returns the square of the eccentricity
+func (e Ellipsoid) E2() float64 {
+	return e.F * (2 - e.F)
+}
+
+// E returns the eccentricity
+func (e Ellipsoid) E() float64 {
+	return math.Sqrt(e.E2())
+}
+
+// Helmert holds the 7 parameters of a position vector transformation to WGS84
+type Helmert struct {
+	// Translations in meters
+	Tx, Ty, Tz float64
+	// Rotations in arc seconds
+	Rx, Ry, Rz float64
+	// Scale difference in parts per million
+	S float64
+}
+
+// Datum is a geodetic datum, an ellipsoid and how to shift coordinates on it to WGS84
+type Datum struct {
+	Name      string
+	Ellipsoid Ellipsoid
+	// ToWgs84 is the transformation to WGS84, nil if the datum is treated as equivalent to WGS84
+	ToWgs84 *Helmert
+}
+
+var (
+	DatumWGS84 = Datum{Name: "WGS 84", Ellipsoid: WGS84}
+	// NAD83, ETRS89, GDA94, NZGD2000 and RGF93 are within a meter or so of WGS84
+	DatumNAD83    = Datum{Name: "NAD83", Ellipsoid: GRS80}
+	DatumETRS89   = Datum{Name: "ETRS89", Ellipsoid: GRS80}
+	DatumGDA94    = Datum{Name: "GDA94", Ellipsoid: GRS80}
+	DatumNZGD2000 = Datum{Name: "NZGD2000", Ellipsoid: GRS80}
+	DatumRGF93    = Datum{Name: "RGF93", Ellipsoid: GRS80}
+	DatumOSGB36   = Datum{
+		Name:      "OSGB 1936",
+		Ellipsoid: Airy1830,
+		ToWgs84:   &Helmert{Tx: 446.448, Ty: -125.157, Tz: 542.06, Rx: 0.15, Ry: 0.247, Rz: 0.842, S: -20.489},
+	}
+)
+
+// toWgs84 converts geodetic coordinates in radians on the datum to WGS84
+func (d Datum) toWgs84(lon, lat float64) (float64, float64) {
+	if d.ToWgs84 == nil {
+		return lon, lat
+	}
+	x, y, z := geodeticToGeocentric(d.Ellipsoid, lon, lat)
+	x, y, z = d.ToWgs84.apply(x, y, z, 1)
+	return geocentricToGeodetic(WGS84, x, y, z)
+}
+
+// fromWgs84 converts WGS84 geodetic coordinates in radians to the datum
+func (d Datum) fromWgs84(lon, lat float64) (float64, float64) {
+	if d.ToWgs84 == nil {
+		return lon, lat
+	}
+	x, y, z := geodeticToGeocentric(WGS84, lon, lat)
+	x, y, z = d.ToWgs84.apply(x, y, z, -1)
+	return geocentricToGeodetic(d.Ellipsoid, x, y, z)
+}
+
+// apply applies the transformation to geocentric coordinates, or its approximate inverse if direction is -1
+func (h Helmert) apply(x, y, z float64, direction float64) (float64, float64, float64) {
+	const arcSec = math.Pi / (180 * 3600)
+	tx, ty, tz := direction*h.Tx, direction*h.Ty, direction*h.Tz
+	rx, ry, rz := direction*h.Rx*arcSec, direction*h.Ry*arcSec, direction*h.Rz*arcSec
+	s := 1 + direction*h.S*1e-6
+
+	return tx + s*(x-rz*y+ry*z),
+		ty + s*(rz*x+y-rx*z),
+		tz + s*(-ry*x+rx*y+z)
+}
+
+// geodeticToGeocentric converts longitude and latitude in radians at height 0 to earth-centered coordinates
+func geodeticToGeocentric(e Ellipsoid, lon, lat float64) (float64, float64, float64) {
+	e2 := e.E2()
+	sinLat := math.Sin(lat)
+	n := e.A / math.Sqrt(1-e2*sinLat*sinLat)
+	return n * math.Cos(lat) * math.Cos(lon),
+		n * math.Cos(lat) * math.Sin(lon),
+		n * (1 - e2) * sinLat
+}
+
+// geocentricToGeodetic converts earth-centered coordinates to longitude and latitude in radians
+func geocentricToGeodetic(e Ellipsoid, x, y, z float64) (float64, float64) {
+	e2 := e.E2()
+	p := math.Hypot(x, y)
+	lon := math.Atan2(y, x)
+
+	lat := math.Atan2(z, p*(1-e2))
+	for range 10 {
+		sinLat := math.Sin(lat)
+		n := e.A / math.Sqrt(1-e2*sinLat*sinLat)
+		next := math.Atan2(z+e2*n*sinLat, p)
+		if math.Abs(next-lat) < 1e-12 {
+			lat = next
+			break
+		}
+		lat = next
+	}
+	return lon, lat
+}
@@ -0,0 +1,407 @@
+package proj
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrOutsideProjection is returned when a coordinate can't be represented in a projection
+var ErrOutsideProjection = errors.New("coordinate is outside of the projection's valid area")
+
+// Projection converts between geodetic coordinates on the ellipsoid and projected coordinates.
+// Longitude and latitude are in radians, projected coordinates in meters.
+type Projection interface {
+	Forward(lon, lat float64) (x, y float64, err error)
+	Inverse(x, y float64) (lon, lat float64, err error)
+}
+
+const deg = math.Pi / 180
+
+// TransverseMercator is the ellipsoidal transverse mercator projection, using Krüger's series
+// which are accurate to well under a millimeter within a few thousand km of the central meridian.
+type TransverseMercator struct {
+	ellipsoid Ellipsoid
+	lon0      float64
+	k0        float64
+	falseE    float64
+	falseN    float64
+
+	n     float64
+	a     float64 // rectifying radius
+	alpha [3]float64
+	beta  [3]float64
+	delta [3]float64
+	m0    float64 // northing of the latitude of origin
+}
+
+// NewTransverseMercator creates a transverse mercator projection, angles are in degrees
+func NewTransverseMercator(e Ellipsoid, lat0, lon0, k0, falseEasting, falseNorthing float64) *TransverseMercator {
+	n := e.F / (2 - e.F)
+	n2, n3 := n*n, n*n*n
+	tm := &TransverseMercator{
+		ellipsoid: e,
+		lon0:      lon0 * deg,
+		k0:        k0,
+		falseE:    falseEasting,
+		falseN:    falseNorthing,
+		n:         n,
+		a:         e.A / (1 + n) * (1 + n2/4 + n2*n2/64),
+		alpha:     [3]float64{n/2 - 2*n2/3 + 5*n3/16, 13*n2/48 - 3*n3/5, 61 * n3 / 240},
+		beta:      [3]float64{n/2 - 2*n2/3 + 37*n3/96, n2/48 + n3/15, 17 * n3 / 480},
+		delta:     [3]float64{2*n - 2*n2/3 - 2*n3, 7*n2/3 - 8*n3/5, 56 * n3 / 15},
+	}
+	_, tm.m0 = tm.project(tm.lon0, lat0*deg)
+	return tm
+}
+
+// NewUTM creates the transverse mercator projection for a UTM zone
+func NewUTM(e Ellipsoid, zone int, south bool) *TransverseMercator {
+	falseNorthing := 0.0
+	if south {
+		falseNorthing = 10000000
+	}
+	return NewTransverseMercator(e, 0, float64(zone*6-183), 0.9996, 500000, falseNorthing)
+}
+
+// project returns the scaled easting and northing relative to the central meridian and the equator
+func (tm *TransverseMercator) project(lon, lat float64) (float64, float64) {
+	dLon := lon - tm.lon0
+	c := 2 * math.Sqrt(tm.n) / (1 + tm.n)
+	t := math.Sinh(math.Atanh(math.Sin(lat)) - c*math.Atanh(c*math.Sin(lat)))
+	xiP := math.Atan2(t, math.Cos(dLon))
+	etaP := math.Atanh(math.Sin(dLon) / math.Sqrt(1+t*t))
+
+	xi, eta := xiP, etaP
+	for j, alpha := range tm.alpha {
+		k := float64(2 * (j + 1))
+		xi += alpha * math.Sin(k*xiP) * math.Cosh(k*etaP)
+		eta += alpha * math.Cos(k*xiP) * math.Sinh(k*etaP)
+	}
+	return tm.k0 * tm.a * eta, tm.k0 * tm.a * xi
+}
+
+func (tm *TransverseMercator) Forward(lon, lat float64) (float64, float64, error) {
+	dLon := math.Remainder(lon-tm.lon0, 2*math.Pi)
+	if math.Abs(dLon) >= math.Pi/2 {
+		return 0, 0, ErrOutsideProjection
+	}
+	x, y := tm.project(tm.lon0+dLon, lat)
+	return tm.falseE + x, tm.falseN + y - tm.m0, nil
+}
+
+func (tm *TransverseMercator) Inverse(x, y float64) (float64, float64, error) {
+	xi := (y - tm.falseN + tm.m0) / (tm.k0 * tm.a)
+	eta := (x - tm.falseE) / (tm.k0 * tm.a)
+
+	xiP, etaP := xi, eta
+	for j, beta := range tm.beta {
+		k := float64(2 * (j + 1))
+		xiP -= beta * math.Sin(k*xi) * math.Cosh(k*eta)
+		etaP -= beta * math.Cos(k*xi) * math.Sinh(k*eta)
+	}
+
+	chi := math.Asin(math.Sin(xiP) / math.Cosh(etaP))
+	lat := chi
+	for j, delta := range tm.delta {
+		lat += delta * math.Sin(float64(2*(j+1))*chi)
+	}
+	lon := tm.lon0 + math.Atan2(math.Sinh(etaP), math.Cos(xiP))
+	return lon, lat, nil
+}
+
+// Mercator is the normal aspect mercator projection.
+// With a spherical ellipsoid (F of 0) on WGS84 coordinates it's the web mercator projection.
+type Mercator struct {
+	ellipsoid Ellipsoid
+	lon0      float64
+	k0        float64
+	maxLat    float64
+}
+
+// NewMercator creates a mercator projection, latitudes beyond maxLat (in degrees) are clamped
+func NewMercator(e Ellipsoid, lon0, k0, maxLat float64) *Mercator {
+	return &Mercator{ellipsoid: e, lon0: lon0 * deg, k0: k0, maxLat: maxLat * deg}
+}
+
+func (m *Mercator) Forward(lon, lat float64) (float64, float64, error) {
+	lat = math.Max(-m.maxLat, math.Min(m.maxLat, lat))
+	e := m.ellipsoid.E()
+	ak := m.ellipsoid.A * m.k0
+	x := ak * (lon - m.lon0)
+	y := ak * math.Log(math.Tan(math.Pi/4+lat/2)*math.Pow((1-e*math.Sin(lat))/(1+e*math.Sin(lat)), e/2))
+	return x, y, nil
+}
+
+func (m *Mercator) Inverse(x, y float64) (float64, float64, error) {
+	ak := m.ellipsoid.A * m.k0
+	t := math.Exp(-y / ak)
+	lat := latFromT(m.ellipsoid.E(), t)
+	return x/ak + m.lon0, lat, nil
+}
+
+// LambertConformalConic is the lambert conformal conic projection with two standard parallels
+type LambertConformalConic struct {
+	ellipsoid Ellipsoid
+	lon0      float64
+	falseE    float64
+	falseN    float64
+
+	n    float64
+	f    float64
+	rho0 float64
+}
+
+// NewLambertConformalConic creates a lambert conformal conic projection, angles are in degrees
+func NewLambertConformalConic(e Ellipsoid, lat1, lat2, lat0, lon0, falseEasting, falseNorthing float64) *LambertConformalConic {
+	ecc := e.E()
+	phi1, phi2 := lat1*deg, lat2*deg
+	m1, m2 := lccM(ecc, phi1), lccM(ecc, phi2)
+	t0, t1, t2 := lccT(ecc, lat0*deg), lccT(ecc, phi1), lccT(ecc, phi2)
+
+	var n float64
+	if lat1 == lat2 {
+		n = math.Sin(phi1)
+	} else {
+		n = (math.Log(m1) - math.Log(m2)) / (math.Log(t1) - math.Log(t2))
+	}
+	f := m1 / (n * math.Pow(t1, n))
+
+	return &LambertConformalConic{
+		ellipsoid: e,
+		lon0:      lon0 * deg,
+		falseE:    falseEasting,
+		falseN:    falseNorthing,
+		n:         n,
+		f:         f,
+		rho0:      e.A * f * math.Pow(t0, n),
+	}
+}
+
+func (l *LambertConformalConic) Forward(lon, lat float64) (float64, float64, error) {
+	if math.Abs(lat) >= math.Pi/2 && lat*l.n < 0 {
+		return 0, 0, ErrOutsideProjection
+	}
+	rho := l.ellipsoid.A * l.f * math.Pow(lccT(l.ellipsoid.E(), lat), l.n)
+	theta := l.n * math.Remainder(lon-l.lon0, 2*math.Pi)
+	return l.falseE + rho*math.Sin(theta), l.falseN + l.rho0 - rho*math.Cos(theta), nil
+}
+
+func (l *LambertConformalConic) Inverse(x, y float64) (float64, float64, error) {
+	dx := x - l.falseE
+	dy := l.rho0 - (y - l.falseN)
+	sign := math.Copysign(1, l.n)
+	rho := sign * math.Hypot(dx, dy)
+	theta := math.Atan2(sign*dx, sign*dy)
+
+	t := math.Pow(rho/(l.ellipsoid.A*l.f), 1/l.n)
+	lat := latFromT(l.ellipsoid.E(), t)
+	return theta/l.n + l.lon0, lat, nil
+}
+
+// AlbersEqualArea is the albers equal area conic projection
+type AlbersEqualArea struct {
+	ellipsoid Ellipsoid
+	lon0      float64
+	falseE    float64
+	falseN    float64
+
+	n    float64
+	c    float64
+	rho0 float64
+}
+
+// NewAlbersEqualArea creates an albers equal area projection, angles are in degrees
+func NewAlbersEqualArea(e Ellipsoid, lat1, lat2, lat0, lon0, falseEasting, falseNorthing float64) *AlbersEqualArea {
+	ecc := e.E()
+	phi1, phi2 := lat1*deg, lat2*deg
+	m1, m2 := lccM(ecc, phi1), lccM(ecc, phi2)
+	q0, q1, q2 := authalicQ(ecc, lat0*deg), authalicQ(ecc, phi1), authalicQ(ecc, phi2)
+
+	var n float64
+	if lat1 == lat2 {
+		n = math.Sin(phi1)
+	} else {
+		n = (m1*m1 - m2*m2) / (q2 - q1)
+	}
+	c := m1*m1 + n*q1
+
+	return &AlbersEqualArea{
+		ellipsoid: e,
+		lon0:      lon0 * deg,
+		falseE:    falseEasting,
+		falseN:    falseNorthing,
+		n:         n,
+		c:         c,
+		rho0:      e.A * math.Sqrt(c-n*q0) / n,
+	}
+}
+
+func (p *AlbersEqualArea) Forward(lon, lat float64) (float64, float64, error) {
+	q := authalicQ(p.ellipsoid.E(), lat)
+	rho := p.ellipsoid.A * math.Sqrt(p.c-p.n*q) / p.n
+	theta := p.n * math.Remainder(lon-p.lon0, 2*math.Pi)
+	return p.falseE + rho*math.Sin(theta), p.falseN + p.rho0 - rho*math.Cos(theta), nil
+}
+
+func (p *AlbersEqualArea) Inverse(x, y float64) (float64, float64, error) {
+	dx := x - p.falseE
+	dy := p.rho0 - (y - p.falseN)
+	sign := math.Copysign(1, p.n)
+	rho := math.Hypot(dx, dy)
+	theta := math.Atan2(sign*dx, sign*dy)
+
+	a := p.ellipsoid.A
+	q := (p.c - rho*rho*p.n*p.n/(a*a)) / p.n
+	lat, err := latFromQ(p.ellipsoid.E(), q)
+	if err != nil {
+		return 0, 0, err
+	}
+	return theta/p.n + p.lon0, lat, nil
+}
+
+// LambertAzimuthalEqualArea is the oblique ellipsoidal lambert azimuthal equal area projection
+type LambertAzimuthalEqualArea struct {
+	ellipsoid Ellipsoid
+	lat0      float64
+	lon0      float64
+	falseE    float64
+	falseN    float64
+
+	qp    float64
+	beta1 float64
+	rq    float64
+	d     float64
+}
+
+// NewLambertAzimuthalEqualArea creates a lambert azimuthal equal area projection, angles are in degrees
+func NewLambertAzimuthalEqualArea(e Ellipsoid, lat0, lon0, falseEasting, falseNorthing float64) *LambertAzimuthalEqualArea {
+	ecc := e.E()
+	phi0 := lat0 * deg
+	qp := authalicQ(ecc, math.Pi/2)
+	beta1 := math.Asin(authalicQ(ecc, phi0) / qp)
+	rq := e.A * math.Sqrt(qp/2)
+
+	return &LambertAzimuthalEqualArea{
+		ellipsoid: e,
+		lat0:      phi0,
+		lon0:      lon0 * deg,
+		falseE:    falseEasting,
+		falseN:    falseNorthing,
+		qp:        qp,
+		beta1:     beta1,
+		rq:        rq,
+		d:         e.A * lccM(ecc, phi0) / (rq * math.Cos(beta1)),
+	}
+}
+
+func (p *LambertAzimuthalEqualArea) Forward(lon, lat float64) (float64, float64, error) {
+	beta := math.Asin(math.Max(-1, math.Min(1, authalicQ(p.ellipsoid.E(), lat)/p.qp)))
+	dLon := lon - p.lon0
+	denom := 1 + math.Sin(p.beta1)*math.Sin(beta) + math.Cos(p.beta1)*math.Cos(beta)*math.Cos(dLon)
+	if denom <= 1e-12 {
+		// the antipode of the center can't be projected
+		return 0, 0, ErrOutsideProjection
+	}
+	b := p.rq * math.Sqrt(2/denom)
+	x := b * p.d * math.Cos(beta) * math.Sin(dLon)
+	y := (b / p.d) * (math.Cos(p.beta1)*math.Sin(beta) - math.Sin(p.beta1)*math.Cos(beta)*math.Cos(dLon))
+	return p.falseE + x, p.falseN + y, nil
+}
+
+func (p *LambertAzimuthalEqualArea) Inverse(x, y float64) (float64, float64, error) {
+	x -= p.falseE
+	y -= p.falseN
+	rho := math.Hypot(x/p.d, p.d*y)
+	if rho < 1e-9 {
+		return p.lon0, p.lat0, nil
+	}
+	if rho > 2*p.rq {
+		return 0, 0, ErrOutsideProjection
+	}
+
+	ce := 2 * math.Asin(rho/(2*p.rq))
+	q := p.qp * (math.Cos(ce)*math.Sin(p.beta1) + p.d*y*math.Sin(ce)*math.Cos(p.beta1)/rho)
+	lat, err := latFromQ(p.ellipsoid.E(), q)
+	if err != nil {
+		return 0, 0, err
+	}
+	lon := p.lon0 + math.Atan2(x*math.Sin(ce), p.d*rho*math.Cos(p.beta1)*math.Cos(ce)-p.d*p.d*y*math.Sin(p.beta1)*math.Sin(ce))
+	return lon, lat, nil
+}
+
+// lccM returns cos(φ) / sqrt(1 - e²sin²(φ))
+func lccM(e, lat float64) float64 {
+	sinLat := math.Sin(lat)
+	return math.Cos(lat) / math.Sqrt(1-e*e*sinLat*sinLat)
+}
+
+// lccT returns the isometric latitude function t used by the conformal projections
+func lccT(e, lat float64) float64 {
+	eSinLat := e * math.Sin(lat)
+	return math.Tan(math.Pi/4-lat/2) / math.Pow((1-eSinLat)/(1+eSinLat), e/2)
+}
+
+// latFromT inverts lccT by iteration
+func latFromT(e, t float64) float64 {
+	lat := math.Pi/2 - 2*math.Atan(t)
+	for range 15 {
+		eSinLat := e * math.Sin(lat)
+		next := math.Pi/2 - 2*math.Atan(t*math.Pow((1-eSinLat)/(1+eSinLat), e/2))
+		if math.Abs(next-lat) < 1e-12 {
+			return next
+		}
+		lat = next
+	}
+	return lat
+}
+
+// authalicQ returns q, used by the equal area projections
+func authalicQ(e, lat float64) float64 {
+	sinLat := math.Sin(lat)
+	if e == 0 {
+		return 2 * sinLat
+	}
+	e2 := e * e
+	return (1 - e2) * (sinLat/(1-e2*sinLat*sinLat) - 1/(2*e)*math.Log((1-e*sinLat)/(1+e*sinLat)))
+}
+
+// latFromQ inverts authalicQ by iteration
+func latFromQ(e, q float64) (float64, error) {
+	qp := authalicQ(e, math.Pi/2)
+	if math.Abs(q) > qp+1e-9 {
+		return 0, ErrOutsideProjection
+	}
+	if math.Abs(q) >= qp-1e-12 {
+		return math.Copysign(math.Pi/2, q), nil
+	}
+
+	e2 := e * e
+	lat := math.Asin(q / 2)
+	for range 15 {
+		sinLat := math.Sin(lat)
+		oneMinus := 1 - e2*sinLat*sinLat
+		var delta float64
+		if e == 0 {
+			delta = (q/2 - sinLat) / math.Cos(lat)
+		} else {
+			delta = oneMinus * oneMinus / (2 * math.Cos(lat)) *
+				(q/(1-e2) - sinLat/oneMinus + 1/(2*e)*math.Log((1-e*sinLat)/(1+e*sinLat)))
+		}
+		lat += delta
+		if math.Abs(delta) < 1e-12 {
+			break
+		}
+	}
+	return lat, nil
+}
+
+// unsupportedProjection is the projection of a CRS that was detected but can't be transformed
+type unsupportedProjection struct{}
+
+func (unsupportedProjection) Forward(lon, lat float64) (float64, float64, error) {
+	return 0, 0, ErrUnsupportedCRS
+}
+
+func (unsupportedProjection) Inverse(x, y float64) (float64, float64, error) {
+	return 0, 0, ErrUnsupportedCRS
+}
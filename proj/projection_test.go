@@ -0,0 +1,167 @@
+package proj
+
+import (
+	"math"
+	"testing"
+)
+
+var clarke1866 = Ellipsoid{Name: "Clarke 1866", A: 6378206.4, F: 1 / 294.9786982}
+
+// US survey foot
+const ftUS = 1200.0 / 3937
+
+func TestProjectionReferencePoints(t *testing.T) {
+	tests := []struct {
+		name       string
+		projection Projection
+		lon, lat   float64
+		x, y       float64
+		tolerance  float64
+	}{
+		{
+			// Ordnance Survey "A guide to coordinate systems in Great Britain" worked example
+			name:       "Transverse Mercator - British National Grid",
+			projection: NewTransverseMercator(Airy1830, 49, -2, 0.9996012717, 400000, -100000),
+			lon:        1 + 43/60.0 + 4.5177/3600,
+			lat:        52 + 39/60.0 + 27.2531/3600,
+			x:          651409.903,
+			y:          313177.270,
+			tolerance:  0.001,
+		},
+		{
+			name:       "UTM - edge of zone 31 at the equator",
+			projection: NewUTM(WGS84, 31, false),
+			lon:        0,
+			lat:        0,
+			x:          166021.443,
+			y:          0,
+			tolerance:  0.001,
+		},
+		{
+			// EPSG guidance note 7-2 example
+			name:       "Lambert Conformal Conic - Texas South Central",
+			projection: NewLambertConformalConic(clarke1866, 28+23/60.0, 30+17/60.0, 27+50/60.0, -99, 2000000*ftUS, 0),
+			lon:        -96,
+			lat:        28.5,
+			x:          2963503.91 * ftUS,
+			y:          254759.80 * ftUS,
+			tolerance:  0.01,
+		},
+		{
+			// Snyder, Map Projections - A Working Manual, numerical example
+			name:       "Albers Equal Area",
+			projection: NewAlbersEqualArea(clarke1866, 29.5, 45.5, 23, -96, 0, 0),
+			lon:        -75,
+			lat:        35,
+			x:          1885472.7,
+			y:          1535925.0,
+			tolerance:  0.1,
+		},
+		{
+			// EPSG guidance note 7-2 example
+			name:       "Lambert Azimuthal Equal Area - LAEA Europe",
+			projection: NewLambertAzimuthalEqualArea(GRS80, 52, 10, 4321000, 3210000),
+			lon:        5,
+			lat:        50,
+			x:          3962799.45,
+			y:          2999718.85,
+			tolerance:  0.01,
+		},
+		{
+			name:       "Mercator - web mercator edge",
+			projection: NewMercator(Ellipsoid{A: WGS84.A}, 0, 1, 85.0511287798066),
+			lon:        180,
+			lat:        85.0511287798066,
+			x:          20037508.342789244,
+			y:          20037508.342789244,
+			tolerance:  0.001,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x, y, err := tt.projection.Forward(tt.lon*deg, tt.lat*deg)
+			if err != nil {
+				t.Fatalf("Forward() unexpected error: %v", err)
+			}
+			if math.Abs(x-tt.x) > tt.tolerance || math.Abs(y-tt.y) > tt.tolerance {
+				t.Errorf("Forward() = %f, %f, want %f, %f", x, y, tt.x, tt.y)
+			}
+
+			lon, lat, err := tt.projection.Inverse(tt.x, tt.y)
+			if err != nil {
+				t.Fatalf("Inverse() unexpected error: %v", err)
+			}
+			if math.Abs(lon/deg-tt.lon) > 1e-6 || math.Abs(lat/deg-tt.lat) > 1e-6 {
+				t.Errorf("Inverse() = %f, %f, want %f, %f", lon/deg, lat/deg, tt.lon, tt.lat)
+			}
+		})
+	}
+}
+
+func TestProjectionRoundTrip(t *testing.T) {
+	codes := []int{3857, 3395, 27700, 2154, 3035, 5070, 3577, 2193, 32601, 32633, 32760, 26915, 25832, 28355}
+
+	for _, code := range codes {
+		crs, err := Lookup(code)
+		if err != nil {
+			t.Fatalf("Lookup(%d) unexpected error: %v", code, err)
+		}
+		t.Run(crs.Name, func(t *testing.T) {
+			// pick points near the center of the projection
+			lon0, lat0 := projectionCenter(crs)
+			for _, offset := range [][2]float64{{0, 0}, {1, 1}, {-2, 1.5}, {2.5, -2}} {
+				lon, lat := lon0+offset[0], lat0+offset[1]
+				x, y, err := crs.FromWgs84(lon, lat)
+				if err != nil {
+					t.Fatalf("FromWgs84(%f, %f) unexpected error: %v", lon, lat, err)
+				}
+				gotLon, gotLat, err := crs.ToWgs84(x, y)
+				if err != nil {
+					t.Fatalf("ToWgs84(%f, %f) unexpected error: %v", x, y, err)
+				}
+				if math.Abs(gotLon-lon) > 1e-6 || math.Abs(gotLat-lat) > 1e-6 {
+					t.Errorf("round trip of %f, %f returned %f, %f", lon, lat, gotLon, gotLat)
+				}
+			}
+		})
+	}
+}
+
+// projectionCenter returns a WGS84 point in the area a CRS is used
+func projectionCenter(crs *CRS) (float64, float64) {
+	switch p := crs.Projection.(type) {
+	case *TransverseMercator:
+		lat := 10.0
+		if p.falseN == 10000000 {
+			lat = -30
+		}
+		if crs.Code == 27700 {
+			lat = 53
+		}
+		return p.lon0 / deg, lat
+	case *LambertConformalConic:
+		return p.lon0 / deg, 46.5
+	case *AlbersEqualArea:
+		if p.n < 0 {
+			return p.lon0 / deg, -25
+		}
+		return p.lon0 / deg, 38
+	case *LambertAzimuthalEqualArea:
+		return p.lon0 / deg, p.lat0 / deg
+	default:
+		return 10, 45
+	}
+}
+
+func TestProjectionOutsideArea(t *testing.T) {
+	utm := NewUTM(WGS84, 33, false)
+	if _, _, err := utm.Forward(-165*deg, 10*deg); err == nil {
+		t.Errorf("expected error projecting the far side of the earth with transverse mercator")
+	}
+
+	laea := NewLambertAzimuthalEqualArea(GRS80, 52, 10, 4321000, 3210000)
+	if _, _, err := laea.Forward(-170*deg, -52*deg); err == nil {
+		t.Errorf("expected error projecting the antipode with lambert azimuthal equal area")
+	}
+}
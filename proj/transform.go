@@ -0,0 +1,115 @@
+package proj
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/mikeocool/bbox/core"
+)
+
+// Number of segments each edge of a box is divided into before transforming,
+// so edges that curve in the target CRS are still contained by the result.
+const densifySegments = 32
+
+// Transform converts a coordinate from one CRS to another
+func Transform(from, to *CRS, x, y float64) (float64, float64, error) {
+	if from.Equal(to) {
+		return x, y, nil
+	}
+	if err := checkSupported(from, to); err != nil {
+		return 0, 0, err
+	}
+	lon, lat, err := from.ToWgs84(x, y)
+	if err != nil {
+		return 0, 0, err
+	}
+	return to.FromWgs84(lon, lat)
+}
+
+// TransformBbox converts a bounding box from one CRS to another.
+// Points along each edge of the box are transformed, and the result is the box containing all of them.
+// Only the horizontal coordinates are transformed, the heights are kept as they are.
+func TransformBbox(bbox core.Bbox, from, to *CRS) (core.Bbox, error) {
+	if from.Equal(to) {
+		return bbox, nil
+	}
+	if err := checkSupported(from, to); err != nil {
+		return core.Bbox{}, err
+	}
+
+	parts := bbox.Split()
+	transformed := make([]core.Bbox, len(parts))
+	for i, part := range parts {
+		var err error
+		transformed[i], err = transformBboxPart(part, from, to)
+		if err != nil {
+			return core.Bbox{}, err
+		}
+	}
+
+	if len(transformed) == 2 && to.IsGeographic() {
+		// keep boxes that crossed the antimeridian crossing it in the target CRS
+		return core.Bbox{
+			Left:   transformed[0].Left,
+			Bottom: math.Min(transformed[0].Bottom, transformed[1].Bottom),
+			Right:  transformed[1].Right,
+			Top:    math.Max(transformed[0].Top, transformed[1].Top),
+			HasZ:   bbox.HasZ,
+			MinZ:   bbox.MinZ,
+			MaxZ:   bbox.MaxZ,
+		}, nil
+	}
+
+	result := transformed[0]
+	for _, part := range transformed[1:] {
+		result = result.Union(part)
+	}
+	return result, nil
+}
+
+// checkSupported returns an error if either CRS can't be transformed
+func checkSupported(from, to *CRS) error {
+	for _, crs := range []*CRS{from, to} {
+		if !crs.IsSupported() {
+			return fmt.Errorf("cannot transform from %s to %s: %w %s", from, to, ErrUnsupportedCRS, crs)
+		}
+	}
+	return nil
+}
+
+// transformBboxPart transforms a box that doesn't cross the antimeridian
+func transformBboxPart(bbox core.Bbox, from, to *CRS) (core.Bbox, error) {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+
+	for _, pt := range densifyBbox(bbox, densifySegments) {
+		x, y, err := Transform(from, to, pt[0], pt[1])
+		if err == nil && (math.IsNaN(x) || math.IsNaN(y) || math.IsInf(x, 0) || math.IsInf(y, 0)) {
+			err = ErrOutsideProjection
+		}
+		if err != nil {
+			return core.Bbox{}, fmt.Errorf("could not transform %g %g from %s to %s: %w", pt[0], pt[1], from, to, err)
+		}
+		minX, maxX = math.Min(minX, x), math.Max(maxX, x)
+		minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+	}
+
+	return core.Bbox{Left: minX, Bottom: minY, Right: maxX, Top: maxY, HasZ: bbox.HasZ, MinZ: bbox.MinZ, MaxZ: bbox.MaxZ}, nil
+}
+
+// densifyBbox returns points along the edges of the box, with each edge divided into segments
+func densifyBbox(bbox core.Bbox, segments int) [][2]float64 {
+	points := make([][2]float64, 0, segments*4)
+	width := bbox.Right - bbox.Left
+	height := bbox.Top - bbox.Bottom
+	for i := range segments {
+		f := float64(i) / float64(segments)
+		points = append(points,
+			[2]float64{bbox.Left + f*width, bbox.Bottom},   // bottom, left to right
+			[2]float64{bbox.Right, bbox.Bottom + f*height}, // right, bottom to top
+			[2]float64{bbox.Right - f*width, bbox.Top},     // top, right to left
+			[2]float64{bbox.Left, bbox.Top - f*height},     // left, top to bottom
+		)
+	}
+	return points
+}
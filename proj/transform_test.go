@@ -0,0 +1,110 @@
+package proj
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/mikeocool/bbox/core"
+)
+
+func bboxNear(a, b core.Bbox, tolerance float64) bool {
+	return math.Abs(a.Left-b.Left) <= tolerance &&
+		math.Abs(a.Bottom-b.Bottom) <= tolerance &&
+		math.Abs(a.Right-b.Right) <= tolerance &&
+		math.Abs(a.Top-b.Top) <= tolerance
+}
+
+func TestTransformBbox(t *testing.T) {
+	wgs84, _ := Lookup(4326)
+	webMercator, _ := Lookup(3857)
+	utm33, _ := Lookup(32633)
+	nzgd2000, _ := Lookup(4167)
+
+	tests := []struct {
+		name      string
+		bbox      core.Bbox
+		from, to  *CRS
+		want      core.Bbox
+		tolerance float64
+	}{
+		{
+			name:      "Same CRS",
+			bbox:      core.Bbox{Left: 1, Bottom: 2, Right: 3, Top: 4},
+			from:      wgs84,
+			to:        wgs84,
+			want:      core.Bbox{Left: 1, Bottom: 2, Right: 3, Top: 4},
+			tolerance: 0,
+		},
+		{
+			name:      "WGS84 to web mercator",
+			bbox:      core.Bbox{Left: -180, Bottom: -85.0511287798066, Right: 180, Top: 85.0511287798066},
+			from:      wgs84,
+			to:        webMercator,
+			want:      core.Bbox{Left: -20037508.342789244, Bottom: -20037508.342789244, Right: 20037508.342789244, Top: 20037508.342789244},
+			tolerance: 0.001,
+		},
+		{
+			name:      "Web mercator to WGS84",
+			bbox:      core.Bbox{Left: 0, Bottom: 0, Right: 20037508.342789244, Top: 20037508.342789244},
+			from:      webMercator,
+			to:        wgs84,
+			want:      core.Bbox{Left: 0, Bottom: 0, Right: 180, Top: 85.0511287798066},
+			tolerance: 1e-9,
+		},
+		{
+			// parallels curve in UTM, the bottom of the result is where 50N crosses the central meridian not at a corner
+			name:      "WGS84 to UTM includes curved edges",
+			bbox:      core.Bbox{Left: 12, Bottom: 50, Right: 18, Top: 54},
+			from:      wgs84,
+			to:        utm33,
+			want:      core.Bbox{Left: 285015.76, Bottom: 5538630.70, Right: 714984.24, Top: 5987687.71},
+			tolerance: 1,
+		},
+		{
+			name:      "Antimeridian crossing box stays crossing",
+			bbox:      core.Bbox{Left: 179, Bottom: -10, Right: -179, Top: 10},
+			from:      wgs84,
+			to:        nzgd2000,
+			want:      core.Bbox{Left: 179, Bottom: -10, Right: -179, Top: 10},
+			tolerance: 1e-9,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TransformBbox(tt.bbox, tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("TransformBbox() unexpected error: %v", err)
+			}
+			if !bboxNear(got, tt.want, tt.tolerance) {
+				t.Errorf("TransformBbox() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransformBboxOutsideProjection(t *testing.T) {
+	wgs84, _ := Lookup(4326)
+	utm33, _ := Lookup(32633)
+
+	_, err := TransformBbox(core.Bbox{Left: -180, Bottom: -80, Right: 180, Top: 80}, wgs84, utm33)
+	if err == nil {
+		t.Errorf("expected an error transforming the whole world to a UTM zone")
+	}
+}
+
+func TestTransformBboxUnsupported(t *testing.T) {
+	wgs84, _ := Lookup(4326)
+	unsupported := Unsupported(2263, "NAD83 / New York Long Island (ftUS)", false)
+
+	_, err := TransformBbox(core.Bbox{Left: 1, Bottom: 2, Right: 3, Top: 4}, unsupported, wgs84)
+	if !errors.Is(err, ErrUnsupportedCRS) {
+		t.Errorf("expected ErrUnsupportedCRS, got %v", err)
+	}
+
+	got, err := TransformBbox(core.Bbox{Left: 1, Bottom: 2, Right: 3, Top: 4}, unsupported, unsupported)
+	if err != nil || got != (core.Bbox{Left: 1, Bottom: 2, Right: 3, Top: 4}) {
+		t.Errorf("expected the box to be unchanged transforming to the same CRS, got %v %v", got, err)
+	}
+}
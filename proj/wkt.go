@@ -0,0 +1,335 @@
+package proj
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// wktNode is a keyword and its bracketed values in a WKT CRS definition,
+// like PROJCS["name",GEOGCS[...],...]
+type wktNode struct {
+	Keyword string
+	// Values holds the quoted strings and numbers, in the order they appear
+	Values []string
+	// Children holds the nested nodes
+	Children []*wktNode
+}
+
+// child returns the first child node with one of the keywords
+func (n *wktNode) child(keywords ...string) *wktNode {
+	for _, c := range n.Children {
+		for _, keyword := range keywords {
+			if strings.EqualFold(c.Keyword, keyword) {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// name returns the first value of the node, which is the name for CRS nodes
+func (n *wktNode) name() string {
+	if len(n.Values) == 0 {
+		return ""
+	}
+	return n.Values[0]
+}
+
+var (
+	wktProjectedKeywords  = []string{"PROJCS", "PROJCRS", "PROJECTEDCRS"}
+	wktGeographicKeywords = []string{"GEOGCS", "GEOGCRS", "GEOGRAPHICCRS", "GEODCRS", "GEODETICCRS"}
+	wktCompoundKeywords   = []string{"COMPD_CS", "COMPOUNDCRS"}
+)
+
+// ParseWkt detects the CRS described by a WKT definition, like the contents of a shapefile's
+// .prj file. Both OGC WKT with an AUTHORITY or ID and the ESRI flavor without one are recognized.
+// If the definition is valid but isn't a CRS we can transform, an unsupported CRS is returned.
+func ParseWkt(wkt string) (*CRS, error) {
+	root, err := parseWktNode(wkt)
+	if err != nil {
+		return nil, err
+	}
+
+	// use the horizontal part of compound CRSs
+	if hasKeyword(root, wktCompoundKeywords) {
+		horizontal := root.child(append(wktProjectedKeywords, wktGeographicKeywords...)...)
+		if horizontal == nil {
+			return nil, fmt.Errorf("compound CRS %q does not have a horizontal CRS", root.name())
+		}
+		root = horizontal
+	}
+
+	var geographic bool
+	switch {
+	case hasKeyword(root, wktProjectedKeywords):
+		geographic = false
+	case hasKeyword(root, wktGeographicKeywords):
+		geographic = true
+	default:
+		return nil, fmt.Errorf("unrecognized WKT CRS type: %s", root.Keyword)
+	}
+
+	if code := wktEpsgCode(root); code != 0 {
+		if crs, err := Lookup(code); err == nil {
+			return crs, nil
+		}
+		return unsupportedWkt(code, root, geographic), nil
+	}
+
+	if code := lookupName(root.name()); code != 0 {
+		return Lookup(code)
+	}
+
+	return unsupportedWkt(0, root, geographic), nil
+}
+
+// unsupportedWkt returns an unsupported CRS for a WKT definition, with its unit if it's projected
+func unsupportedWkt(code int, root *wktNode, geographic bool) *CRS {
+	crs := Unsupported(code, root.name(), geographic)
+	if !geographic {
+		crs.metersPerUnit = wktMetersPerUnit(root)
+	}
+	return crs
+}
+
+// wktMetersPerUnit returns the conversion factor of a projected CRS's UNIT["US survey foot",0.3048006096012192]
+// node, or 0 if it doesn't have one. WKT2 can give the unit for each axis instead, and the first one is used.
+func wktMetersPerUnit(root *wktNode) float64 {
+	unit := root.child("UNIT", "LENGTHUNIT")
+	if axis := root.child("AXIS"); unit == nil && axis != nil {
+		unit = axis.child("UNIT", "LENGTHUNIT")
+	}
+	if unit == nil || len(unit.Values) < 2 {
+		return 0
+	}
+	factor, err := strconv.ParseFloat(unit.Values[1], 64)
+	if err != nil || factor <= 0 || math.IsInf(factor, 0) {
+		return 0
+	}
+	return factor
+}
+
+func hasKeyword(n *wktNode, keywords []string) bool {
+	for _, keyword := range keywords {
+		if strings.EqualFold(n.Keyword, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// wktEpsgCode returns the EPSG code from an AUTHORITY["EPSG","32633"] or ID["EPSG",32633] node, or 0
+func wktEpsgCode(n *wktNode) int {
+	authority := n.child("AUTHORITY", "ID")
+	if authority == nil || len(authority.Values) < 2 || !strings.EqualFold(authority.Values[0], "EPSG") {
+		return 0
+	}
+	code, err := strconv.Atoi(authority.Values[1])
+	if err != nil {
+		return 0
+	}
+	return code
+}
+
+// esriNames maps the names ESRI uses in .prj files to EPSG codes, for the CRSs whose names differ from EPSG's
+var esriNames = map[string]int{
+	"GCS_WGS_1984":                              4326,
+	"GCS_North_American_1983":                   4269,
+	"GCS_ETRS_1989":                             4258,
+	"GCS_GDA_1994":                              4283,
+	"GCS_NZGD_2000":                             4167,
+	"GCS_RGF_1993":                              4171,
+	"GCS_OSGB_1936":                             4277,
+	"WGS_1984_Web_Mercator_Auxiliary_Sphere":    3857,
+	"WGS_1984_Web_Mercator":                     3857,
+	"WGS_1984_World_Mercator":                   3395,
+	"British_National_Grid":                     27700,
+	"OSGB_1936_British_National_Grid":           27700,
+	"RGF_1993_Lambert_93":                       2154,
+	"ETRS_1989_LAEA":                            3035,
+	"ETRS_1989_LAEA_Europe":                     3035,
+	"NAD_1983_Contiguous_USA_Albers":            5070,
+	"GDA_1994_Australian_Albers":                3577,
+	"NZGD_2000_New_Zealand_Transverse_Mercator": 2193,
+}
+
+var (
+	esriUtmName = regexp.MustCompile(`(?i)^(WGS_1984|NAD_1983|ETRS_1989)_UTM_Zone_(\d+)([NS])$`)
+	esriMgaName = regexp.MustCompile(`(?i)^GDA_1994_MGA_Zone_(\d+)$`)
+)
+
+// lookupName returns the EPSG code of a supported CRS from its EPSG or ESRI name, or 0
+func lookupName(name string) int {
+	for esriName, code := range esriNames {
+		if strings.EqualFold(name, esriName) {
+			return code
+		}
+	}
+
+	if m := esriUtmName.FindStringSubmatch(name); m != nil {
+		zone, _ := strconv.Atoi(m[2])
+		datum := map[string]string{"wgs_1984": "WGS 84", "nad_1983": "NAD83", "etrs_1989": "ETRS89"}[strings.ToLower(m[1])]
+		return lookupName(fmt.Sprintf("%s / UTM zone %d%s", datum, zone, strings.ToUpper(m[3])))
+	}
+	if m := esriMgaName.FindStringSubmatch(name); m != nil {
+		zone, _ := strconv.Atoi(m[1])
+		return lookupName(fmt.Sprintf("GDA94 / MGA / UTM zone %dS", zone))
+	}
+
+	normalized := normalizeCrsName(name)
+	for _, code := range supportedCodes() {
+		crs, err := Lookup(code)
+		if err == nil && normalizeCrsName(crs.Name) == normalized {
+			return code
+		}
+	}
+	return 0
+}
+
+// normalizeCrsName lowercases a name and drops everything but letters and digits,
+// so "WGS 84 / UTM zone 33N" and "WGS_84_UTM_Zone_33N" match
+func normalizeCrsName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// supportedCodes returns the EPSG codes of all the supported CRSs
+func supportedCodes() []int {
+	var codes []int
+	for code := range crsDefinitions {
+		codes = append(codes, code)
+	}
+	for _, series := range utmSeriesDefinitions {
+		for zone := series.firstZone; zone <= series.lastZone; zone++ {
+			codes = append(codes, series.firstCode+zone-series.firstZone)
+		}
+	}
+	return codes
+}
+
+// parseWktNode parses WKT into a tree of nodes
+func parseWktNode(wkt string) (*wktNode, error) {
+	p := &wktParser{input: strings.TrimSpace(wkt)}
+	node, err := p.node()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		return nil, fmt.Errorf("unexpected %q after WKT CRS at position %d", p.input[p.pos], p.pos)
+	}
+	return node, nil
+}
+
+type wktParser struct {
+	input string
+	pos   int
+}
+
+func (p *wktParser) skipSpace() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
+
+// node parses KEYWORD[value, value, CHILD[...], ...], brackets can be [] or ()
+func (p *wktParser) node() (*wktNode, error) {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.input) && (isWktKeywordChar(p.input[p.pos])) {
+		p.pos++
+	}
+	if start == p.pos {
+		return nil, fmt.Errorf("expected WKT keyword at position %d", p.pos)
+	}
+	node := &wktNode{Keyword: p.input[start:p.pos]}
+
+	p.skipSpace()
+	if p.pos >= len(p.input) || (p.input[p.pos] != '[' && p.input[p.pos] != '(') {
+		// keywords without values, like the axis direction NORTH
+		return node, nil
+	}
+	closing := byte(']')
+	if p.input[p.pos] == '(' {
+		closing = ')'
+	}
+	p.pos++
+
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.input) {
+			return nil, fmt.Errorf("unterminated WKT %s", node.Keyword)
+		}
+
+		c := p.input[p.pos]
+		switch {
+		case c == '"':
+			value, err := p.quoted()
+			if err != nil {
+				return nil, err
+			}
+			node.Values = append(node.Values, value)
+		case c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9'):
+			start := p.pos
+			for p.pos < len(p.input) && strings.IndexByte("+-.0123456789eE", p.input[p.pos]) >= 0 {
+				p.pos++
+			}
+			node.Values = append(node.Values, p.input[start:p.pos])
+		case isWktKeywordChar(c):
+			child, err := p.node()
+			if err != nil {
+				return nil, err
+			}
+			node.Children = append(node.Children, child)
+		default:
+			return nil, fmt.Errorf("unexpected %q in WKT at position %d", c, p.pos)
+		}
+
+		p.skipSpace()
+		if p.pos >= len(p.input) {
+			return nil, fmt.Errorf("unterminated WKT %s", node.Keyword)
+		}
+		switch p.input[p.pos] {
+		case ',':
+			p.pos++
+		case closing:
+			p.pos++
+			return node, nil
+		default:
+			return nil, fmt.Errorf("unexpected %q in WKT at position %d", p.input[p.pos], p.pos)
+		}
+	}
+}
+
+// quoted parses a double quoted string, where "" is an escaped quote
+func (p *wktParser) quoted() (string, error) {
+	p.pos++ // opening quote
+	var b strings.Builder
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		p.pos++
+		if c == '"' {
+			if p.pos < len(p.input) && p.input[p.pos] == '"' {
+				b.WriteByte('"')
+				p.pos++
+				continue
+			}
+			return b.String(), nil
+		}
+		b.WriteByte(c)
+	}
+	return "", fmt.Errorf("unterminated string in WKT")
+}
+
+func isWktKeywordChar(c byte) bool {
+	return c == '_' || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9')
+}
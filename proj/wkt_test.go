@@ -0,0 +1,155 @@
+package proj
+
+import "testing"
+
+func TestParseWkt(t *testing.T) {
+	tests := []struct {
+		name           string
+		wkt            string
+		wantCode       int
+		wantName       string
+		wantSupported  bool
+		wantGeographic bool
+		wantErr        bool
+	}{
+		{
+			name:           "ESRI geographic",
+			wkt:            `GEOGCS["GCS_WGS_1984",DATUM["D_WGS_1984",SPHEROID["WGS_1984",6378137.0,298.257223563]],PRIMEM["Greenwich",0.0],UNIT["Degree",0.0174532925199433]]`,
+			wantCode:       4326,
+			wantName:       "WGS 84",
+			wantSupported:  true,
+			wantGeographic: true,
+		},
+		{
+			name:          "ESRI UTM",
+			wkt:           `PROJCS["WGS_1984_UTM_Zone_33N",GEOGCS["GCS_WGS_1984",DATUM["D_WGS_1984",SPHEROID["WGS_1984",6378137.0,298.257223563]],PRIMEM["Greenwich",0.0],UNIT["Degree",0.0174532925199433]],PROJECTION["Transverse_Mercator"],PARAMETER["False_Easting",500000.0],PARAMETER["False_Northing",0.0],PARAMETER["Central_Meridian",15.0],PARAMETER["Scale_Factor",0.9996],PARAMETER["Latitude_Of_Origin",0.0],UNIT["Meter",1.0]]`,
+			wantCode:      32633,
+			wantName:      "WGS 84 / UTM zone 33N",
+			wantSupported: true,
+		},
+		{
+			name:          "ESRI MGA",
+			wkt:           `PROJCS["GDA_1994_MGA_Zone_55",GEOGCS["GCS_GDA_1994",DATUM["D_GDA_1994",SPHEROID["GRS_1980",6378137.0,298.257222101]],PRIMEM["Greenwich",0.0],UNIT["Degree",0.0174532925199433]],PROJECTION["Transverse_Mercator"],UNIT["Meter",1.0]]`,
+			wantCode:      28355,
+			wantName:      "GDA94 / MGA / UTM zone 55S",
+			wantSupported: true,
+		},
+		{
+			name:          "ESRI web mercator",
+			wkt:           `PROJCS["WGS_1984_Web_Mercator_Auxiliary_Sphere",GEOGCS["GCS_WGS_1984",DATUM["D_WGS_1984",SPHEROID["WGS_1984",6378137.0,298.257223563]],PRIMEM["Greenwich",0.0],UNIT["Degree",0.0174532925199433]],PROJECTION["Mercator_Auxiliary_Sphere"],UNIT["Meter",1.0]]`,
+			wantCode:      3857,
+			wantName:      "WGS 84 / Pseudo-Mercator",
+			wantSupported: true,
+		},
+		{
+			name:          "OGC WKT with authority",
+			wkt:           `PROJCS["OSGB 1936 / British National Grid",GEOGCS["OSGB 1936",DATUM["OSGB_1936",SPHEROID["Airy 1830",6377563.396,299.3249646,AUTHORITY["EPSG","7001"]],AUTHORITY["EPSG","6277"]],PRIMEM["Greenwich",0],UNIT["degree",0.0174532925199433],AUTHORITY["EPSG","4277"]],PROJECTION["Transverse_Mercator"],UNIT["metre",1],AXIS["Easting",EAST],AXIS["Northing",NORTH],AUTHORITY["EPSG","27700"]]`,
+			wantCode:      27700,
+			wantName:      "OSGB 1936 / British National Grid",
+			wantSupported: true,
+		},
+		{
+			name:          "WKT2 with ID",
+			wkt:           `PROJCRS["ETRS89 / LAEA Europe",BASEGEOGCRS["ETRS89",DATUM["European Terrestrial Reference System 1989",ELLIPSOID["GRS 1980",6378137,298.257222101]]],CONVERSION["Europe Equal Area 2001",METHOD["Lambert Azimuthal Equal Area",ID["EPSG",9820]]],CS[Cartesian,2],ID["EPSG",3035]]`,
+			wantCode:      3035,
+			wantName:      "ETRS89 / LAEA Europe",
+			wantSupported: true,
+		},
+		{
+			name:          "Unsupported code",
+			wkt:           `PROJCS["NAD83 / New York Long Island (ftUS)",GEOGCS["NAD83",DATUM["North_American_Datum_1983",SPHEROID["GRS 1980",6378137,298.257222101]]],PROJECTION["Lambert_Conformal_Conic_2SP"],UNIT["US survey foot",0.3048006096012192],AUTHORITY["EPSG","2263"]]`,
+			wantCode:      2263,
+			wantName:      "NAD83 / New York Long Island (ftUS)",
+			wantSupported: false,
+		},
+		{
+			name:           "Unsupported ESRI geographic",
+			wkt:            `GEOGCS["GCS_North_American_1927",DATUM["D_North_American_1927",SPHEROID["Clarke_1866",6378206.4,294.9786982]],PRIMEM["Greenwich",0.0],UNIT["Degree",0.0174532925199433]]`,
+			wantCode:       0,
+			wantName:       "GCS_North_American_1927",
+			wantSupported:  false,
+			wantGeographic: true,
+		},
+		{
+			name:     "Compound CRS",
+			wkt:      `COMPD_CS["WGS 84 + EGM96 height",GEOGCS["WGS 84",DATUM["WGS_1984",SPHEROID["WGS 84",6378137,298.257223563]],AUTHORITY["EPSG","4326"]],VERT_CS["EGM96 height",VERT_DATUM["EGM96 geoid",2005]]]`,
+			wantCode: 4326, wantName: "WGS 84", wantSupported: true, wantGeographic: true,
+		},
+		{
+			name:    "Not a CRS",
+			wkt:     `POINT(1 2)`,
+			wantErr: true,
+		},
+		{
+			name:    "Unterminated",
+			wkt:     `GEOGCS["GCS_WGS_1984",DATUM["D_WGS_1984"`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseWkt(tt.wkt)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseWkt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Code != tt.wantCode || got.Name != tt.wantName {
+				t.Errorf("ParseWkt() = %d %q, want %d %q", got.Code, got.Name, tt.wantCode, tt.wantName)
+			}
+			if got.IsSupported() != tt.wantSupported {
+				t.Errorf("IsSupported() = %v, want %v", got.IsSupported(), tt.wantSupported)
+			}
+			if got.IsGeographic() != tt.wantGeographic {
+				t.Errorf("IsGeographic() = %v, want %v", got.IsGeographic(), tt.wantGeographic)
+			}
+		})
+	}
+}
+
+func TestParseWktMetersPerUnit(t *testing.T) {
+	tests := []struct {
+		name string
+		wkt  string
+		// want is 0 for geographic CRSs and projected ones whose unit isn't known
+		want float64
+	}{
+		{
+			name: "supported projected",
+			wkt:  `PROJCS["WGS_1984_UTM_Zone_33N",GEOGCS["GCS_WGS_1984",DATUM["D_WGS_1984",SPHEROID["WGS_1984",6378137.0,298.257223563]],UNIT["Degree",0.0174532925199433]],PROJECTION["Transverse_Mercator"],UNIT["Meter",1.0]]`,
+			want: 1,
+		},
+		{
+			name: "geographic",
+			wkt:  `GEOGCS["GCS_North_American_1927",DATUM["D_North_American_1927",SPHEROID["Clarke_1866",6378206.4,294.9786982]],UNIT["Degree",0.0174532925199433]]`,
+		},
+		{
+			name: "US survey feet",
+			wkt:  `PROJCS["NAD83 / New York Long Island (ftUS)",GEOGCS["NAD83",DATUM["North_American_Datum_1983",SPHEROID["GRS 1980",6378137,298.257222101]],UNIT["degree",0.0174532925199433]],PROJECTION["Lambert_Conformal_Conic_2SP"],UNIT["US survey foot",0.3048006096012192],AUTHORITY["EPSG","2263"]]`,
+			want: 0.3048006096012192,
+		},
+		{
+			name: "WKT2 with units on the axes",
+			wkt:  `PROJCRS["Custom grid",BASEGEOGCRS["WGS 84",DATUM["World Geodetic System 1984",ELLIPSOID["WGS 84",6378137,298.257223563]]],CONVERSION["Custom",METHOD["Oblique Stereographic"]],CS[Cartesian,2],AXIS["easting",east,LENGTHUNIT["foot",0.3048]],AXIS["northing",north,LENGTHUNIT["foot",0.3048]]]`,
+			want: 0.3048,
+		},
+		{
+			name: "no unit",
+			wkt:  `PROJCS["Custom grid",GEOGCS["WGS 84"],PROJECTION["Oblique_Stereographic"]]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			crs, err := ParseWkt(tt.wkt)
+			if err != nil {
+				t.Fatalf("ParseWkt() error = %v", err)
+			}
+			if got, ok := crs.MetersPerUnit(); got != tt.want || ok != (tt.want > 0) {
+				t.Errorf("MetersPerUnit() = %v, %v, want %v", got, ok, tt.want)
+			}
+		})
+	}
+}